@@ -32,6 +32,18 @@
 //
 //	//go:generate edgeql-go
 //
+// # Fragments
+//
+// A *.fragment.edgeql file selects a shape that several queries have in
+// common, for example:
+//
+//	select User { name, email }
+//
+// Instead of a query function a <name>_fragment_edgeql.go file is generated
+// that holds the fragment's struct types. Queries in the same directory
+// whose result shape has exactly the same fields use the fragment's types
+// instead of declaring their own.
+//
 // [pinning tool dependencies]: https://github.com/golang/go/wiki/Modules#how-can-i-track-tool-dependencies-for-a-module
 // [go generate]: https://go.dev/blog/generate
 package main
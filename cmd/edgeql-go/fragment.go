@@ -0,0 +1,241 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	edgedb "github.com/sebastiean/edgedb-go/internal/client"
+)
+
+const fragmentSuffix = ".fragment.edgeql"
+
+func isFragmentFile(file string) bool {
+	return strings.HasSuffix(file, fragmentSuffix)
+}
+
+// fragmentSet holds the shape fragments defined in one directory.
+// Result types of queries in the same directory that have the same fields
+// as a fragment are replaced by the fragment's type.
+type fragmentSet struct {
+	shapes map[string]*goStruct
+}
+
+// newFragments generates the types for fragmentFiles and writes them to
+// their output files. The returned fragment sets are keyed by directory.
+func newFragments(
+	ctx context.Context,
+	c *edgedb.Client,
+	t *template.Template,
+	fragmentFiles []string,
+	mixedCaps bool,
+) (map[string]*fragmentSet, error) {
+	// Fragments are registered in file name order so that the type used
+	// for a shape that is defined more than once does not change between
+	// runs.
+	sort.Strings(fragmentFiles)
+	fragments := make([][]*goStruct, len(fragmentFiles))
+	errs := make([]error, len(fragmentFiles))
+
+	var wg sync.WaitGroup
+	for i, file := range fragmentFiles {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			fragments[i], errs[i] = newFragment(ctx, c, t, file, mixedCaps)
+		}(i, file)
+	}
+	wg.Wait()
+
+	sets := make(map[string]*fragmentSet)
+	for i, file := range fragmentFiles {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("processing %s: %w", file, errs[i])
+		}
+
+		dir := filepath.Dir(file)
+		set, ok := sets[dir]
+		if !ok {
+			set = &fragmentSet{shapes: make(map[string]*goStruct)}
+			sets[dir] = set
+		}
+
+		for _, typ := range fragments[i] {
+			key := shapeKey(typ)
+			if _, ok := set.shapes[key]; !ok {
+				set.shapes[key] = typ
+			}
+		}
+	}
+
+	return sets, nil
+}
+
+// newFragment generates the types for the shape selected in fragmentFile.
+func newFragment(
+	ctx context.Context,
+	c *edgedb.Client,
+	t *template.Template,
+	fragmentFile string,
+	mixedCaps bool,
+) ([]*goStruct, error) {
+	outFile := getOutFile(fragmentFile)
+	q, err := newQuery(ctx, c, fragmentFile, outFile, mixedCaps)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.ResultTypes) == 0 {
+		return nil, fmt.Errorf(
+			"expected the fragment to select a shape, got %s",
+			q.SignatureReturnType)
+	}
+
+	// Fragments are named after the file without the query's Result
+	// suffix. They embed edgedb.Optional so that they can be used for
+	// optional and required links alike.
+	renamed := make(map[string]string, len(q.ResultTypes))
+	prefix := q.QueryName + "Result"
+	for _, typ := range q.ResultTypes {
+		name := q.QueryName + strings.TrimPrefix(typ.Name, prefix)
+		renamed[typ.Name] = name
+		typ.Name = name
+		typ.QueryFuncName = ""
+		typ.FragmentFile = q.QueryFile
+		typ.Required = false
+	}
+
+	for _, typ := range q.ResultTypes {
+		renameFields(typ, renamed)
+	}
+
+	imports := usedImports(q.imports, fieldTypes(q.ResultTypes))
+	err = writeGoFile(t, outFile, nil, q.ResultTypes, imports)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ResultTypes, nil
+}
+
+// apply replaces the result types of q that have the same fields as a
+// fragment with the fragment's type.
+func (s *fragmentSet) apply(q *Query) {
+	if s == nil {
+		return
+	}
+
+	renamed := make(map[string]string)
+	var kept []*goStruct
+
+	// Nested types are listed after the types that contain them, so
+	// iterating backwards renames the fields of a type before its shape
+	// is compared to the fragments.
+	for i := len(q.ResultTypes) - 1; i >= 0; i-- {
+		typ := q.ResultTypes[i]
+		renameFields(typ, renamed)
+		if fragment, ok := s.shapes[shapeKey(typ)]; ok {
+			renamed[typ.Name] = fragment.Name
+			continue
+		}
+
+		kept = append(kept, typ)
+	}
+
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+
+	q.ResultTypes = kept
+	q.SignatureReturnType = renameType(q.SignatureReturnType, renamed)
+
+	types := fieldTypes(q.ResultTypes)
+	for _, arg := range q.SignatureArgs {
+		types = append(types, arg.Type)
+	}
+	q.imports = usedImports(q.imports, types)
+}
+
+// shapeKey identifies the object type and the fields of a struct
+// independent of the fields' order and of the struct's name. Shapes with the
+// same fields selected from different object types have different keys.
+func shapeKey(typ *goStruct) string {
+	fields := make([]string, len(typ.Fields))
+	for i, field := range typ.Fields {
+		fields[i] = fmt.Sprintf("%s %s `%s`",
+			field.GoName, field.Type, field.Tag)
+	}
+
+	sort.Strings(fields)
+	return typ.TypeName + "\n" + strings.Join(fields, "\n")
+}
+
+func renameFields(typ *goStruct, renamed map[string]string) {
+	for i := range typ.Fields {
+		typ.Fields[i].Type = renameType(typ.Fields[i].Type, renamed)
+	}
+}
+
+// renameType renames a type reference like []name.
+func renameType(typ string, renamed map[string]string) string {
+	name := strings.TrimLeft(typ, "[]")
+	if to, ok := renamed[name]; ok {
+		return typ[:len(typ)-len(name)] + to
+	}
+
+	return typ
+}
+
+func fieldTypes(structs []*goStruct) []string {
+	var types []string
+	for _, typ := range structs {
+		for _, field := range typ.Fields {
+			types = append(types, field.Type)
+		}
+	}
+
+	return types
+}
+
+// usedImports returns the imports that are referenced by types without
+// duplicates.
+func usedImports(imports []string, types []string) []string {
+	var used []string
+	seen := make(map[string]bool, len(imports))
+	for _, imp := range imports {
+		if seen[imp] {
+			continue
+		}
+		seen[imp] = true
+
+		for _, typ := range types {
+			if strings.Contains(typ, path.Base(imp)+".") {
+				used = append(used, imp)
+				break
+			}
+		}
+	}
+
+	return used
+}
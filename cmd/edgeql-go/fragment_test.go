@@ -0,0 +1,113 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFragmentApply(t *testing.T) {
+	avatar := &goStruct{
+		Name:     "UserSummaryavatarItem",
+		TypeName: "default::Avatar",
+		Fields: []goStructField{
+			{EQLName: "url", GoName: "url", Type: "string",
+				Tag: `edgedb:"url"`},
+		},
+	}
+	user := &goStruct{
+		Name:     "UserSummary",
+		TypeName: "default::User",
+		Fields: []goStructField{
+			{EQLName: "name", GoName: "name", Type: "string",
+				Tag: `edgedb:"name"`},
+			{EQLName: "avatar", GoName: "avatar",
+				Type: "UserSummaryavatarItem", Tag: `edgedb:"avatar"`},
+		},
+	}
+	set := &fragmentSet{shapes: map[string]*goStruct{
+		shapeKey(avatar): avatar,
+		shapeKey(user):   user,
+	}}
+
+	q := &Query{
+		ResultTypes: []*goStruct{
+			{
+				Name: "GetPostsResult",
+				Fields: []goStructField{
+					{EQLName: "title", GoName: "title", Type: "string",
+						Tag: `edgedb:"title"`},
+					{EQLName: "posted", GoName: "posted",
+						Type: "time.Time", Tag: `edgedb:"posted"`},
+					{EQLName: "readers", GoName: "readers",
+						Type: "[]GetPostsResultreadersItem",
+						Tag:  `edgedb:"readers"`},
+				},
+			},
+			{
+				Name:     "GetPostsResultreadersItem",
+				TypeName: "default::User",
+				Fields: []goStructField{
+					// fields are matched in any order
+					{EQLName: "avatar", GoName: "avatar",
+						Type: "GetPostsResultreadersItemavatarItem",
+						Tag:  `edgedb:"avatar"`},
+					{EQLName: "name", GoName: "name", Type: "string",
+						Tag: `edgedb:"name"`},
+				},
+			},
+			{
+				Name:     "GetPostsResultreadersItemavatarItem",
+				TypeName: "default::Avatar",
+				Fields: []goStructField{
+					{EQLName: "url", GoName: "url", Type: "string",
+						Tag: `edgedb:"url"`},
+				},
+			},
+		},
+		SignatureReturnType: "[]GetPostsResult",
+		SignatureArgs: []goStructField{
+			{EQLName: "limit", GoName: "limit", Type: "int64"},
+		},
+		imports: []string{"math/big", "time", "time"},
+	}
+
+	set.apply(q)
+
+	assert.Len(t, q.ResultTypes, 1)
+	assert.Equal(t, "GetPostsResult", q.ResultTypes[0].Name)
+	assert.Equal(t, "[]UserSummary", q.ResultTypes[0].Fields[2].Type)
+	assert.Equal(t, "[]GetPostsResult", q.SignatureReturnType)
+	assert.Equal(t, []string{"time"}, q.imports)
+
+	// a nil set leaves the query unchanged
+	var empty *fragmentSet
+	empty.apply(q)
+	assert.Len(t, q.ResultTypes, 1)
+}
+
+func TestShapeKeyIncludesTypeName(t *testing.T) {
+	fields := []goStructField{
+		{EQLName: "name", GoName: "name", Type: "string",
+			Tag: `edgedb:"name"`},
+	}
+	user := &goStruct{TypeName: "default::User", Fields: fields}
+	team := &goStruct{TypeName: "default::Team", Fields: fields}
+	assert.NotEqual(t, shapeKey(user), shapeKey(team))
+}
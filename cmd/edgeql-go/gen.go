@@ -263,7 +263,11 @@ func generateObjectV2(
 	mixedCaps bool,
 ) ([]goType, []string, error) {
 	var imports []string
-	typ := goStruct{Name: nameFromPath(path), Required: required}
+	typ := goStruct{
+		Name:     nameFromPath(path),
+		Required: required,
+		TypeName: desc.Name,
+	}
 	types := []goType{&typ}
 
 	for _, field := range desc.Fields {
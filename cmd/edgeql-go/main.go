@@ -87,8 +87,23 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Fragments are generated before the queries that use them.
+	var queryFiles, fragmentFiles []string
+	for file := range fileQueue {
+		if isFragmentFile(file) {
+			fragmentFiles = append(fragmentFiles, file)
+		} else {
+			queryFiles = append(queryFiles, file)
+		}
+	}
+
+	fragments, err := newFragments(ctx, c, t, fragmentFiles, *mixedCaps)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	var wg sync.WaitGroup
-	for queryFile := range fileQueue {
+	for _, queryFile := range queryFiles {
 		wg.Add(1)
 		go func(queryFile string) {
 			defer wg.Done()
@@ -98,7 +113,8 @@ func main() {
 				log.Fatalf("processing %s: %s", queryFile, e)
 			}
 
-			e = writeGoFile(t, outFile, []*Query{q})
+			fragments[filepath.Dir(queryFile)].apply(q)
+			e = writeGoFile(t, outFile, []*Query{q}, nil, q.imports)
 			if e != nil {
 				log.Fatalf("processing %s: %s", queryFile, e)
 			}
@@ -221,22 +237,20 @@ func writeGoFile(
 	t *template.Template,
 	outFile string,
 	queries []*Query,
+	fragments []*goStruct,
+	imports []string,
 ) error {
 	packageName, err := getPackageName(outFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var imports []string
-	for _, q := range queries {
-		imports = append(imports, q.imports...)
-	}
-
 	var buf bytes.Buffer
 	err = t.Execute(&buf, map[string]any{
 		"PackageName":  packageName,
 		"ExtraImports": imports,
 		"Queries":      queries,
+		"Fragments":    fragments,
 	})
 	if err != nil {
 		return err
@@ -307,7 +321,11 @@ func getPackageName(outFile string) (string, error) {
 
 func getOutFile(queryFile string) string {
 	base := filepath.Base(queryFile)
-	base = strings.TrimSuffix(base, ".edgeql")
+	if isFragmentFile(base) {
+		base = strings.TrimSuffix(base, fragmentSuffix) + "_fragment"
+	} else {
+		base = strings.TrimSuffix(base, ".edgeql")
+	}
 	base += "_edgeql.go"
 	return filepath.Join(filepath.Dir(queryFile), base)
 }
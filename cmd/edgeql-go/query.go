@@ -228,6 +228,7 @@ func cmdVarName(qryFile string) string {
 func queryName(qryFile string) string {
 	name := filepath.Base(qryFile)
 	name = strings.TrimSuffix(name, ".edgeql")
+	name = strings.TrimSuffix(name, ".fragment")
 	return snakeToUpperMixedCase(name)
 }
 
@@ -58,6 +58,14 @@ type goStruct struct {
 	QueryFuncName string
 	Fields        []goStructField
 	Required      bool
+
+	// FragmentFile is the file that defines the struct's shape
+	// if the struct is a shape fragment.
+	FragmentFile string
+
+	// TypeName is the name of the object type the struct is decoded from,
+	// e.g. default::User. Servers before protocol 2.0 don't report it.
+	TypeName string
 }
 
 func (t *goStruct) Reference() string { return t.Name }
@@ -0,0 +1,354 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build conformance
+// +build conformance
+
+// Package conformance checks that the driver's codecs and query flows work
+// with an EdgeDB server. It is only built with the conformance build tag.
+//
+// Applications can verify that their server is compatible with the driver
+// before upgrading either of them by running the suite from their own tests:
+//
+//	func TestEdgeDBConformance(t *testing.T) {
+//	    client, err := edgedb.CreateClient(ctx, edgedb.Options{})
+//	    ...
+//	    conformance.Run(t, client)
+//	}
+//
+//	$ go test -tags conformance -run TestEdgeDBConformance ./...
+//
+// The package's own tests run the suite against every DSN listed, separated
+// by spaces, in the EDGEDB_CONFORMANCE_DSNS environment variable, so that a
+// single run can cover several server versions.
+//
+//	$ export EDGEDB_CONFORMANCE_DSNS="edgedb://host:5656 edgedb://host:5657"
+//	$ go test -tags conformance ./conformance
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+// Case is a single conformance check.
+type Case struct {
+	// Name identifies the case in test output.
+	Name string
+
+	// MinServerVersion is the lowest server major version
+	// that supports the case.
+	MinServerVersion int64
+
+	// Run returns an error if the check fails.
+	Run func(ctx context.Context, client *edgedb.Client) error
+}
+
+// Run runs every case that is supported by the client's server as a subtest
+// of t.
+func Run(t *testing.T, client *edgedb.Client) {
+	ctx := context.Background()
+
+	var version int64
+	err := client.QuerySingle(ctx,
+		"SELECT sys::get_version().major", &version)
+	if err != nil {
+		t.Fatalf("could not get the server version: %v", err)
+	}
+
+	for _, c := range Cases() {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if version < c.MinServerVersion {
+				t.Skipf("requires server version %v, got %v",
+					c.MinServerVersion, version)
+			}
+
+			if err := c.Run(ctx, client); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Cases returns all conformance cases.
+func Cases() []Case {
+	cases := make([]Case, 0, len(scalars)+len(flows))
+	for _, s := range scalars {
+		cases = append(cases, s.roundTrip())
+	}
+
+	return append(cases, flows...)
+}
+
+// scalar is a value that is encoded as an argument
+// and decoded from the query result.
+type scalar struct {
+	typ              string
+	value            interface{}
+	minServerVersion int64
+}
+
+var scalars = []scalar{
+	{typ: "str", value: "conformance"},
+	{typ: "bytes", value: []byte{0, 1, 2, 255}},
+	{typ: "int16", value: int16(-12_345)},
+	{typ: "int32", value: int32(-1_234_567)},
+	{typ: "int64", value: int64(-1_234_567_890_123)},
+	{typ: "float32", value: float32(1.5)},
+	{typ: "float64", value: -2.25},
+	{typ: "bool", value: true},
+	{typ: "uuid", value: edgedb.UUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	{typ: "bigint", value: big.NewInt(-1_234_567_890_123)},
+	{typ: "json", value: []byte(`{"a": [1, 2]}`)},
+	{
+		typ:   "datetime",
+		value: time.Date(2022, 3, 4, 5, 6, 7, 8_000, time.UTC),
+	},
+	{
+		typ:   "cal::local_datetime",
+		value: edgedb.NewLocalDateTime(2022, 3, 4, 5, 6, 7, 8),
+	},
+	{typ: "cal::local_date", value: edgedb.NewLocalDate(2022, 3, 4)},
+	{typ: "cal::local_time", value: edgedb.NewLocalTime(5, 6, 7, 8)},
+	{typ: "duration", value: edgedb.Duration(-3_600_000_001)},
+	{
+		typ:   "cal::relative_duration",
+		value: edgedb.NewRelativeDuration(-1, 2, 3),
+	},
+	{
+		typ:              "cal::date_duration",
+		value:            edgedb.NewDateDuration(1, 2),
+		minServerVersion: 2,
+	},
+	{typ: "cfg::memory", value: edgedb.Memory(1024), minServerVersion: 2},
+	{typ: "array<int64>", value: []int64{1, 2, 3}},
+	{typ: "array<str>", value: []string{}},
+	{
+		typ: "range<int64>",
+		value: edgedb.NewRangeInt64(
+			edgedb.NewOptionalInt64(1),
+			edgedb.NewOptionalInt64(10),
+			true,
+			false,
+		),
+		minServerVersion: 2,
+	},
+}
+
+func (s scalar) roundTrip() Case {
+	return Case{
+		Name:             s.typ,
+		MinServerVersion: s.minServerVersion,
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			typ := reflect.TypeOf(s.value)
+			out := reflect.New(typ)
+			query := fmt.Sprintf("SELECT <%v>$0", s.typ)
+			err := client.QuerySingle(ctx, query, out.Interface(), s.value)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(s.value, out.Elem().Interface())
+		},
+	}
+}
+
+var flows = []Case{
+	{
+		Name: "Execute",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			return client.Execute(ctx, "SELECT 1; SELECT 2;")
+		},
+	},
+	{
+		Name: "Query",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result []int64
+			err := client.Query(ctx, "SELECT {1, 2, 3}", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual([]int64{1, 2, 3}, result)
+		},
+	},
+	{
+		Name: "QuerySingle/NoData",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result edgedb.OptionalStr
+			err := client.QuerySingle(ctx, "SELECT <str>{}", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(edgedb.OptionalStr{}, result)
+		},
+	},
+	{
+		Name: "QueryJSON",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result []byte
+			err := client.QueryJSON(ctx, "SELECT {1, 2}", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual("[1, 2]", string(result))
+		},
+	},
+	{
+		Name: "QuerySingleJSON",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result []byte
+			err := client.QuerySingleJSON(ctx, "SELECT (a := 1)", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(`{"a" : 1}`, string(result))
+		},
+	},
+	{
+		Name: "NamedArguments",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result int64
+			err := client.QuerySingle(ctx,
+				"SELECT <int64>$a + <int64>$b",
+				&result,
+				map[string]interface{}{"a": int64(1), "b": int64(2)})
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(int64(3), result)
+		},
+	},
+	{
+		Name: "Tuple",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result struct {
+				First  int64  `edgedb:"0"`
+				Second string `edgedb:"1"`
+			}
+			err := client.QuerySingle(ctx, "SELECT (1, 'a')", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(int64(1), result.First)
+		},
+	},
+	{
+		Name: "NamedTuple",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result struct {
+				A int64  `edgedb:"a"`
+				B string `edgedb:"b"`
+			}
+			err := client.QuerySingle(ctx,
+				"SELECT (a := 1, b := 'x')", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual("x", result.B)
+		},
+	},
+	{
+		Name: "FreeObject",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result struct {
+				Name  string  `edgedb:"name"`
+				Items []int64 `edgedb:"items"`
+			}
+			err := client.QuerySingle(ctx,
+				"SELECT { name := 'x', items := {1, 2} }", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual([]int64{1, 2}, result.Items)
+		},
+	},
+	{
+		Name: "Dynamic",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			var result interface{}
+			err := client.QuerySingle(ctx, "SELECT [1, 2]", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual([]interface{}{int64(1), int64(2)}, result)
+		},
+	},
+	{
+		Name: "Tx",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			return client.Tx(ctx, func(
+				ctx context.Context,
+				tx *edgedb.Tx,
+			) error {
+				var result int64
+				return tx.QuerySingle(ctx, "SELECT 1", &result)
+			})
+		},
+	},
+	{
+		Name: "Config",
+		Run: func(ctx context.Context, client *edgedb.Client) error {
+			configured := client.WithConfig(map[string]interface{}{
+				"allow_user_specified_id": true,
+			})
+
+			var result bool
+			err := configured.QuerySingle(ctx,
+				"SELECT cfg::Config.allow_user_specified_id", &result)
+			if err != nil {
+				return err
+			}
+
+			return expectEqual(true, result)
+		},
+	},
+}
+
+// expectEqual returns an error if got is not equal to want.
+func expectEqual(want, got interface{}) error {
+	switch w := want.(type) {
+	case time.Time:
+		if g, ok := got.(time.Time); ok && w.Equal(g) {
+			return nil
+		}
+	case *big.Int:
+		if g, ok := got.(*big.Int); ok && w.Cmp(g) == 0 {
+			return nil
+		}
+	default:
+		if reflect.DeepEqual(want, got) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected %#v, got %#v", want, got)
+}
@@ -0,0 +1,61 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build conformance
+// +build conformance
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+func TestConformance(t *testing.T) {
+	dsns := strings.Fields(os.Getenv("EDGEDB_CONFORMANCE_DSNS"))
+	if len(dsns) == 0 {
+		t.Skip("EDGEDB_CONFORMANCE_DSNS is not set")
+	}
+
+	ctx := context.Background()
+	for i, dsn := range dsns {
+		dsn := dsn
+		t.Run(fmt.Sprintf("server%v", i), func(t *testing.T) {
+			client, err := edgedb.CreateClientDSN(ctx, dsn, edgedb.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close() // nolint:errcheck
+
+			Run(t, client)
+		})
+	}
+}
+
+func TestExpectEqual(t *testing.T) {
+	if err := expectEqual([]int64{1}, []int64{1}); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectEqual(int64(1), int32(1)); err == nil {
+		t.Error("values of different types should not be equal")
+	}
+}
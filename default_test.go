@@ -0,0 +1,44 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb_test
+
+import (
+	"context"
+	"log"
+
+	edgedb "github.com/sebastiean/edgedb-go"
+)
+
+// Small programs can use the package level query functions instead of
+// passing a client around. A client is created from the environment on first
+// use unless one is set with SetDefault.
+func ExampleSetDefault() {
+	ctx := context.Background()
+	client, err := edgedb.CreateClient(ctx, edgedb.Options{Concurrency: 4})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close() // nolint:errcheck
+
+	edgedb.SetDefault(client)
+
+	var names []string
+	err = edgedb.Query(ctx, "SELECT User.name", &names)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -68,6 +68,20 @@
 //
 //	client, err := edgedb.CreateClient(ctx, opts)
 //
+// Small programs and scripts can use the package level Query, QuerySingle,
+// QueryJSON, QuerySingleJSON and Execute functions instead. They use the
+// client set with SetDefault, or a client created from environment variables
+// on first use.
+//
+//	var names []string
+//	err := edgedb.Query(ctx, "SELECT User.name", &names)
+//
+// The generic QueryAs and QuerySingleAs functions return the result of a
+// query run with a client as a value of the type parameter instead of
+// decoding it into an out argument.
+//
+//	names, err := edgedb.QueryAs[string](ctx, client, "SELECT User.name")
+//
 // # Errors
 //
 // edgedb never returns underlying errors directly.
@@ -96,13 +110,14 @@
 //	---------                ---------
 //	Set                      []anytype
 //	array<anytype>           []anytype
-//	tuple                    struct
-//	named tuple              struct
-//	Object                   struct
+//	tuple                    struct, []interface{}
+//	named tuple              struct, map[string]interface{}
+//	Object                   struct, map[string]interface{}
 //	bool                     bool, edgedb.OptionalBool
 //	bytes                    []byte, edgedb.OptionalBytes
 //	str                      string, edgedb.OptionalStr
-//	anyenum                  string, edgedb.OptionalStr
+//	anyenum                  string, edgedb.OptionalStr,
+//	                         user defined string types
 //	datetime                 time.Time, edgedb.OptionalDateTime
 //	cal::local_datetime      edgedb.LocalDateTime,
 //	                         edgedb.OptionalLocalDateTime
@@ -111,16 +126,142 @@
 //	duration                 edgedb.Duration, edgedb.OptionalDuration
 //	cal::relative_duration   edgedb.RelativeDuration,
 //	                         edgedb.OptionalRelativeDuration
+//	cfg::memory              edgedb.Memory, edgedb.OptionalMemory
 //	float32                  float32, edgedb.OptionalFloat32
 //	float64                  float64, edgedb.OptionalFloat64
-//	int16                    int16, edgedb.OptionalFloat16
-//	int32                    int32, edgedb.OptionalInt16
+//	int16                    int16, edgedb.OptionalInt16
+//	int32                    int32, edgedb.OptionalInt32
 //	int64                    int64, edgedb.OptionalInt64
 //	uuid                     edgedb.UUID, edgedb.OptionalUUID
 //	json                     []byte, edgedb.OptionalBytes
 //	bigint                   *big.Int, edgedb.OptionalBigInt
+//	ext::postgis::geometry   []byte, edgedb.OptionalBytes, sql.Scanner
+//	ext::postgis::geography  []byte, edgedb.OptionalBytes, sql.Scanner
+//	ext::pgvector::vector    []float32, edgedb.OptionalVector
+//	ext::pgvector::halfvec   []float32, edgedb.OptionalVector
+//	ext::pgvector::sparsevec edgedb.SparseVector,
+//	                         edgedb.OptionalSparseVector
+//	range<int32>             edgedb.RangeInt32, edgedb.OptionalRangeInt32
+//	range<int64>             edgedb.RangeInt64, edgedb.OptionalRangeInt64
+//	range<float32>           edgedb.RangeFloat32,
+//	                         edgedb.OptionalRangeFloat32
+//	range<float64>           edgedb.RangeFloat64,
+//	                         edgedb.OptionalRangeFloat64
+//	range<datetime>          edgedb.RangeDateTime,
+//	                         edgedb.OptionalRangeDateTime
+//	range<cal::local_datetime>
+//	                         edgedb.RangeLocalDateTime,
+//	                         edgedb.OptionalRangeLocalDateTime
+//	range<cal::local_date>   edgedb.RangeLocalDate,
+//	                         edgedb.OptionalRangeLocalDate
+//
+//	decimal                  encoding.TextMarshaler,
+//	                         encoding.TextUnmarshaler,
+//	                         string (decoding only),
+//	                         user defined (see Custom Marshalers)
+//
+// The driver does not have its own decimal type. Decimals are exchanged with
+// any type that implements encoding.TextMarshaler and
+// encoding.TextUnmarshaler, like decimal.Decimal from
+// github.com/shopspring/decimal or *apd.Decimal from
+// github.com/cockroachdb/apd, without the driver depending on them.
+// Types that also implement the OptionalUnmarshaler and OptionalMarshaler
+// custom marshaler interfaces can be used for optional values, and types
+// that implement DecimalMarshaler and DecimalUnmarshaler use the wire format
+// directly instead.
+//
+//	var total decimal.Decimal
+//	err := client.QuerySingle(ctx, `SELECT sum(Order.total)`, &total)
+//
+// Enums can be decoded into user defined string types as well as string,
+// and those types can be used as enum arguments. Types that implement
+// EnumMarshaler and EnumUnmarshaler can convert enum values themselves.
+// Decoded values are checked against the enum's members.
+//
+//	type Color string
+//
+//	var color Color
+//	err := client.QuerySingle(ctx, `SELECT <Color>'Red'`, &color)
+//
+// PostGIS geometry values are exchanged in their EWKB encoding. Any type
+// implementing sql.Scanner can receive geometry values and any
+// driver.Valuer returning EWKB bytes can be used as a geometry argument,
+// so geometry libraries that support database/sql work without extra
+// parsing. For example with github.com/paulmach/orb:
+//
+//	type Geometry struct {
+//	    orb.Geometry
+//	}
+//
+//	func (g *Geometry) Scan(src interface{}) error {
+//	    return ewkb.Scanner(&g.Geometry).Scan(src)
+//	}
+//
+//	var places []struct {
+//	    Location Geometry `edgedb:"location"`
+//	}
+//	err := client.Query(ctx, `SELECT Place { location }`, &places)
+//
+// Memory values are a number of bytes. They are formatted in the largest
+// unit that divides them evenly, e.g. 1GiB, and can be created from the
+// unit constants, e.g. 512 * edgedb.MiB. Reading cfg::Config objects needs
+// no extra setup.
 //
-//	decimal                  user defined (see Custom Marshalers)
+//	var cfg struct {
+//	    SharedBuffers edgedb.OptionalMemory `edgedb:"shared_buffers"`
+//	}
+//	err := client.QuerySingle(ctx,
+//	    `SELECT cfg::Config { shared_buffers }`, &cfg)
+//
+// Vectors are decoded into and encoded from []float32 values. A missing
+// vector is decoded as a nil slice, or use edgedb.OptionalVector to tell a
+// missing vector apart from an empty one. Vector arguments must have between
+// 1 and 16,000 dimensions. Half vector elements are rounded to the nearest
+// half precision value when encoded. Sparse vectors store only their
+// non-zero elements, with zero based indices in ascending order.
+//
+// Each range element type has its own concrete Go type instead of a generic
+// Range[T] so that it can be exported by the edgedb package. Ranges are
+// created with the NewRange functions, a missing Optional bound is an
+// unbounded end.
+//
+//	r := edgedb.NewRangeInt64(
+//	    edgedb.NewOptionalInt64(1),
+//	    edgedb.OptionalInt64{},
+//	    true,
+//	    false,
+//	)
+//
+// Values can also be decoded into interface{}, map[string]interface{} and
+// []interface{} when their shape is not known ahead of time. Objects and
+// named tuples are decoded into maps keyed by field or element name, tuples,
+// arrays and sets into slices, and scalars into their go type from the list
+// above. Decimals are decoded into their text form as a string.
+//
+//	var row map[string]interface{}
+//	err := client.QuerySingle(ctx, `SELECT (name := 'x', count := 1)`, &row)
+//
+// Query results can be decoded into a *[]map[string]interface{} in the same
+// way. Named map types like `type Row map[string]interface{}` are also
+// accepted.
+//
+//	var rows []map[string]interface{}
+//	err := client.Query(ctx, `SELECT User { name, email }`, &rows)
+//
+// Free shapes and computed fields are decoded into structs like any other
+// object. Every selected field needs a matching struct field, fields that
+// are not required must use an Optional type and multi fields must be
+// slices. If the struct does not match, the error lists each mismatched
+// field.
+//
+//	var result struct {
+//	    Total int64              `edgedb:"total"`
+//	    Name  edgedb.OptionalStr `edgedb:"name"`
+//	}
+//	err := client.QuerySingle(ctx, `SELECT {
+//	    total := count(User),
+//	    name := (SELECT User.name LIMIT 1),
+//	}`, &result)
 //
 // Note that EdgeDB's std::duration type is represented in int64 microseconds
 // while go's time.Duration type is int64 nanoseconds. It is incorrect to cast
@@ -140,10 +281,41 @@
 //	fmt.Println(result.Missing())
 //	// Output: true
 //
+// Tuple and named tuple elements that can be missing are decoded the same
+// way. Decoding a missing element into a type that is not optional is an
+// error.
+//
+//	var result struct {
+//	    Opt edgedb.OptionalStr `edgedb:"opt"`
+//	}
+//	query := `SELECT (opt := <OPTIONAL str>{},)`
+//	err := client.QuerySingle(ctx, query, &result)
+//
 //	err := client.QuerySingle(ctx, `SELECT User { email } LIMIT 1`, $result)
 //	fmt.Println(result.Missing())
 //	// Output: false
 //
+// Existing structs without optional types can be used by setting
+// Options.MissingField. MissingFieldZero decodes missing values as the field's
+// zero value and MissingFieldNil allows pointer fields which are set to nil
+// when the value is missing.
+//
+//	type User struct {
+//	    Email *string `edgedb:"email"`
+//	}
+//
+//	opts := edgedb.Options{MissingField: edgedb.MissingFieldNil}
+//
+// Single links can always be decoded into pointers to structs. The pointer is
+// set to nil when the link is empty. Multi links can be decoded into slices of
+// pointers to structs, which avoids copying large nested objects.
+//
+//	type User struct {
+//	    Name    string  `edgedb:"name"`
+//	    Friend  *User   `edgedb:"friend"`
+//	    Friends []*User `edgedb:"friends"`
+//	}
+//
 // Not all types listed above are valid query parameters.  To pass a slice of
 // scalar values use array in your query. EdgeDB doesn't currently support
 // using sets as parameters.
@@ -152,7 +324,17 @@
 //	client.QuerySingle(ctx, query, $user, []edgedb.UUID{...})
 //
 // Nested structures are also not directly allowed but you can use [json]
-// instead.
+// instead. Maps, structs and pointers to structs passed as std::json
+// parameters are encoded with [encoding/json]. Named tuple parameters are
+// encoded from maps with string keys, for example map[string]interface{},
+// using the element names as keys.
+//
+//	query := `select <tuple<name: str, meta: json>>$0`
+//	user := map[string]interface{}{
+//	    "name": "bob",
+//	    "meta": map[string]bool{"admin": true},
+//	}
+//	err := client.QuerySingle(ctx, query, &result, user)
 //
 // By default EdgeDB will ignore embedded structs when marshaling/unmarshaling.
 // To treat an embedded struct's fields as part of the parent struct's fields,
@@ -167,6 +349,15 @@
 //	    Name string
 //	}
 //
+// Structs that are already tagged for another library can be used without
+// adding edgedb tags by setting Options.StructTag to that library's tag key.
+//
+//	type User struct {
+//	    Name string `db:"name"`
+//	}
+//
+//	client, err := edgedb.CreateClient(ctx, edgedb.Options{StructTag: "db"})
+//
 // # Custom Marshalers
 //
 // Interfaces for user defined marshaler/unmarshalers  are documented in the
@@ -0,0 +1,174 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package edgedbx adds Get and Select functions with the same call patterns
+// as github.com/jmoiron/sqlx to ease porting sqlx code bases to EdgeDB.
+//
+//	db, err := edgedbx.Connect(ctx, "")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	var users []User
+//	err = db.Select(&users, "SELECT User { name, email }")
+//
+//	var user User
+//	err = db.Get(&user, "SELECT User { name } FILTER .id = <uuid>$0", id)
+//	if err == sql.ErrNoRows {
+//	    ...
+//	}
+//
+// Clients created with Connect decode structs the way sqlx does: fields are
+// matched by their `db` tag or case insensitively by name, and missing
+// values are decoded into pointer fields as nil.
+package edgedbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+// Querier is implemented by *edgedb.Client and *edgedb.Tx.
+type Querier interface {
+	Query(context.Context, string, interface{}, ...interface{}) error
+	QuerySingle(context.Context, string, interface{}, ...interface{}) error
+}
+
+// DB wraps an edgedb.Client with sqlx style methods.
+type DB struct {
+	*edgedb.Client
+}
+
+// NewDB returns a DB using client.
+func NewDB(client *edgedb.Client) *DB {
+	return &DB{Client: client}
+}
+
+// Connect creates a client that decodes structs like sqlx. If dsn is empty
+// the connection is configured using environment variables or the current
+// project like edgedb.CreateClient.
+func Connect(ctx context.Context, dsn string) (*DB, error) {
+	opts := edgedb.Options{
+		StructTag:    "db",
+		FieldMatch:   edgedb.FieldMatchCaseInsensitive,
+		MissingField: edgedb.MissingFieldNil,
+	}
+
+	var (
+		client *edgedb.Client
+		err    error
+	)
+	if dsn == "" {
+		client, err = edgedb.CreateClient(ctx, opts)
+	} else {
+		client, err = edgedb.CreateClientDSN(ctx, dsn, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDB(client), nil
+}
+
+// Get runs a query that returns at most one result and decodes it into dest.
+// sql.ErrNoRows is returned if there is no result.
+func (db *DB) Get(dest interface{}, query string, args ...interface{}) error {
+	return GetContext(context.Background(), db.Client, dest, query, args...)
+}
+
+// GetContext is like Get but uses ctx.
+func (db *DB) GetContext(
+	ctx context.Context,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return GetContext(ctx, db.Client, dest, query, args...)
+}
+
+// Select runs a query and decodes its results into dest which must be a
+// pointer to a slice.
+func (db *DB) Select(
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return SelectContext(context.Background(), db.Client, dest, query, args...)
+}
+
+// SelectContext is like Select but uses ctx.
+func (db *DB) SelectContext(
+	ctx context.Context,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return SelectContext(ctx, db.Client, dest, query, args...)
+}
+
+// Get runs a query using q that returns at most one result and decodes it
+// into dest. sql.ErrNoRows is returned if there is no result.
+func Get(
+	q Querier,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return GetContext(context.Background(), q, dest, query, args...)
+}
+
+// GetContext is like Get but uses ctx.
+func GetContext(
+	ctx context.Context,
+	q Querier,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	err := q.QuerySingle(ctx, query, dest, args...)
+
+	var edbErr edgedb.Error
+	if errors.As(err, &edbErr) && edbErr.Category(edgedb.NoDataError) {
+		return sql.ErrNoRows
+	}
+
+	return err
+}
+
+// Select runs a query using q and decodes its results into dest which must be
+// a pointer to a slice.
+func Select(
+	q Querier,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return SelectContext(context.Background(), q, dest, query, args...)
+}
+
+// SelectContext is like Select but uses ctx.
+func SelectContext(
+	ctx context.Context,
+	q Querier,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	return q.Query(ctx, query, dest, args...)
+}
@@ -0,0 +1,72 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type noDataError struct{}
+
+func (noDataError) Error() string { return "no data" }
+
+func (noDataError) Unwrap() error { return nil }
+
+func (noDataError) Category(c edgedb.ErrorCategory) bool {
+	return c == edgedb.NoDataError
+}
+
+func (noDataError) HasTag(edgedb.ErrorTag) bool { return false }
+
+type querier struct{ err error }
+
+func (q querier) Query(
+	context.Context,
+	string,
+	interface{},
+	...interface{},
+) error {
+	return q.err
+}
+
+func (q querier) QuerySingle(
+	context.Context,
+	string,
+	interface{},
+	...interface{},
+) error {
+	return q.err
+}
+
+func TestGetNoRows(t *testing.T) {
+	var result string
+	err := Get(querier{noDataError{}}, &result, "SELECT <str>{}")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	other := errors.New("other")
+	err = Get(querier{other}, &result, "SELECT <str>{}")
+	assert.Equal(t, other, err)
+
+	err = Get(querier{}, &result, "SELECT 'a'")
+	assert.NoError(t, err)
+}
@@ -18,12 +18,33 @@ package buff
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 	"github.com/sebastiean/edgedb-go/internal/soc"
 )
 
+// ErrMessageTooLarge is wrapped by the error that is returned
+// when a message is larger than Reader.MaxMessageSize.
+var ErrMessageTooLarge = errors.New("message is too large")
+
+// MessageTooLargeError is the error that is returned
+// when a message is larger than Reader.MaxMessageSize.
+type MessageTooLargeError struct {
+	MsgType uint8
+	Size    int
+	Limit   int
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("%v: message type 0x%x is %v bytes, the limit is %v",
+		ErrMessageTooLarge, e.MsgType, e.Size, e.Limit)
+}
+
+func (e *MessageTooLargeError) Unwrap() error { return ErrMessageTooLarge }
+
 // Reader is a buffer reader.
 type Reader struct {
 	toBeDeserialized chan *soc.Data
@@ -32,6 +53,16 @@ type Reader struct {
 	Err     error
 	Buf     []byte
 	MsgType uint8
+
+	// MaxMessageSize is the largest message that Next buffers.
+	// Zero means no limit. Streamed messages are not limited.
+	MaxMessageSize int
+
+	// see StreamTo
+	streamType uint8
+	streamSkip int
+	streamDst  io.Writer
+	streamErr  error
 }
 
 // NewReader returns a new Reader.
@@ -96,6 +127,37 @@ func (r *Reader) Next(doneReadingSignal chan struct{}) bool {
 	r.MsgType = r.PopUint8()
 	msgLen := int(r.PopUint32()) - 4
 
+	if r.streamDst != nil &&
+		r.MsgType == r.streamType &&
+		msgLen > r.streamSkip {
+		r.Err = r.feed(r.streamSkip)
+		if r.Err != nil {
+			return false
+		}
+
+		// The streamed chunks may release the memory that holds the
+		// skipped bytes.
+		r.Buf = append([]byte(nil), r.Buf[:r.streamSkip]...)
+		r.Err = r.stream(r.streamDst, msgLen-r.streamSkip)
+		return r.Err == nil
+	}
+
+	if r.MaxMessageSize > 0 && msgLen > r.MaxMessageSize {
+		// Skip the message without buffering it so that the next message
+		// can still be read.
+		if e := r.stream(nil, msgLen); e != nil {
+			r.Err = e
+			return false
+		}
+
+		r.Err = &MessageTooLargeError{
+			MsgType: r.MsgType,
+			Size:    msgLen,
+			Limit:   r.MaxMessageSize,
+		}
+		return false
+	}
+
 	r.Err = r.feed(msgLen)
 	if r.Err != nil {
 		return false
@@ -105,6 +167,54 @@ func (r *Reader) Next(doneReadingSignal chan struct{}) bool {
 	return true
 }
 
+// StreamTo makes Next write the content of messages of type mType to dst in
+// chunks instead of buffering it. The first skip bytes of each message are
+// buffered as usual so that its header can be read. A nil dst stops
+// streaming.
+func (r *Reader) StreamTo(mType uint8, skip int, dst io.Writer) {
+	r.streamType = mType
+	r.streamSkip = skip
+	r.streamDst = dst
+	r.streamErr = nil
+}
+
+// StreamErr returns the first error returned by the destination of
+// StreamTo. Messages are read to the end even if writing them fails.
+func (r *Reader) StreamErr() error {
+	return r.streamErr
+}
+
+// stream writes the next n bytes to dst. A nil dst discards them.
+func (r *Reader) stream(dst io.Writer, n int) error {
+	for n > 0 {
+		if r.data != nil && len(r.data.Buf) == 0 {
+			r.data.Release()
+			r.data = nil
+		}
+
+		if r.data == nil {
+			r.data = <-r.toBeDeserialized
+
+			if r.data.Err != nil {
+				e := r.data.Err
+				r.data.Release()
+				r.data = nil
+				return e
+			}
+		}
+
+		m := min(n, len(r.data.Buf))
+		if dst != nil && r.streamErr == nil {
+			_, r.streamErr = dst.Write(r.data.Buf[:m])
+		}
+
+		r.data.Buf = r.data.Buf[m:]
+		n -= m
+	}
+
+	return nil
+}
+
 func min(x, y int) int {
 	if x < y {
 		return x
@@ -17,6 +17,7 @@
 package buff
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,6 +49,52 @@ func TestNext(t *testing.T) {
 	assert.Panics(t, func() { r.Discard(1) })
 }
 
+func TestStreamTo(t *testing.T) {
+	toBeDeserialized := make(chan *soc.Data, 3)
+	toBeDeserialized <- &soc.Data{Buf: []byte{0xa, 0, 0, 0, 10, 1, 2, 'a'}}
+	toBeDeserialized <- &soc.Data{Buf: []byte{'b', 'c', 'd'}}
+	toBeDeserialized <- &soc.Data{Buf: []byte{0xb, 0, 0, 0, 6, 1, 2}}
+	r := NewReader(toBeDeserialized)
+
+	var dst bytes.Buffer
+	r.StreamTo(0xa, 2, &dst)
+
+	require.True(t, r.Next(nil))
+	assert.Equal(t, uint8(0xa), r.MsgType)
+	assert.Equal(t, []byte{1, 2}, r.Buf)
+	assert.Equal(t, "abcd", dst.String())
+	r.Discard(2)
+
+	// other message types are buffered
+	require.True(t, r.Next(nil))
+	assert.Equal(t, uint8(0xb), r.MsgType)
+	assert.Equal(t, []byte{1, 2}, r.Buf)
+	assert.NoError(t, r.StreamErr())
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	toBeDeserialized := make(chan *soc.Data, 3)
+	toBeDeserialized <- &soc.Data{Buf: []byte{0xa, 0, 0, 0, 6, 1, 2}}
+	toBeDeserialized <- &soc.Data{Buf: []byte{0xb, 0, 0, 0, 7, 1, 2, 3}}
+	toBeDeserialized <- &soc.Data{Buf: []byte{0xc, 0, 0, 0, 5, 1}}
+	r := NewReader(toBeDeserialized)
+	r.MaxMessageSize = 2
+
+	require.True(t, r.Next(nil))
+	assert.Equal(t, []byte{1, 2}, r.Buf)
+	r.Discard(2)
+
+	require.False(t, r.Next(nil))
+	assert.ErrorIs(t, r.Err, ErrMessageTooLarge)
+	assert.EqualError(t, r.Err, "message is too large: "+
+		"message type 0xb is 3 bytes, the limit is 2")
+
+	// the message is skipped
+	require.True(t, r.Next(nil))
+	assert.Equal(t, uint8(0xc), r.MsgType)
+	assert.Equal(t, []byte{1}, r.Buf)
+}
+
 func TestDiscard(t *testing.T) {
 	r := SimpleReader([]byte{1, 2, 3, 4})
 	r.Discard(2)
@@ -18,6 +18,7 @@ package buff
 
 import (
 	"encoding/binary"
+	"io"
 
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 )
@@ -27,6 +28,21 @@ type Writer struct {
 	buf     []byte
 	msgPos  int
 	bytePos []int
+
+	// streams are written after the buffer's first stream.pos bytes.
+	// streamed is the total length of the streams. msgStreamed and
+	// byteStreamed are the value of streamed when the current message and
+	// bytes blocks began.
+	streams      []stream
+	streamed     int
+	msgStreamed  int
+	byteStreamed []int
+}
+
+type stream struct {
+	pos int
+	r   io.Reader
+	n   int
 }
 
 // NewWriter returns a new Writer.
@@ -44,11 +60,67 @@ func (w *Writer) Unwrap() []byte {
 		panic("cannot send: no data")
 	}
 
+	if len(w.streams) != 0 {
+		panic("cannot unwrap: the buffer has streams, use WriteTo")
+	}
+
 	buf := w.buf
 	w.buf = nil
 	return buf
 }
 
+// Streaming returns true if data was written with PushReader.
+func (w *Writer) Streaming() bool {
+	return len(w.streams) != 0
+}
+
+// Len returns the number of bytes written to the buffer,
+// including the bytes that will be streamed by WriteTo.
+func (w *Writer) Len() int {
+	return len(w.buf) + w.streamed
+}
+
+// WriteTo writes the buffer to dst, copying the data of each PushReader
+// call from its reader in chunks. WriteTo panics if the last message is not
+// finished.
+func (w *Writer) WriteTo(dst io.Writer) (int64, error) {
+	if w.msgPos != 0 {
+		panic("cannot send: the previous message is not finished")
+	}
+
+	var (
+		total int64
+		pos   int
+	)
+
+	write := func(end int) error {
+		n, err := dst.Write(w.buf[pos:end])
+		total += int64(n)
+		pos = end
+		return err
+	}
+
+	for _, s := range w.streams {
+		if err := write(s.pos); err != nil {
+			return total, err
+		}
+
+		n, err := io.CopyN(dst, s.r, int64(s.n))
+		total += n
+		if err == io.EOF {
+			return total, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return total, err
+		}
+	}
+
+	err := write(len(w.buf))
+	w.buf = nil
+	w.streams = nil
+	w.streamed = 0
+	return total, err
+}
+
 // PushUint8 writes a uint8 to the buffer.
 func (w *Writer) PushUint8(val uint8) {
 	w.buf = append(w.buf, val)
@@ -85,6 +157,13 @@ func (w *Writer) PushBytes(val []byte) {
 	w.buf = append(w.buf, val...)
 }
 
+// PushReader writes n bytes read from r. r is not read until WriteTo is
+// called, and the bytes are never held in the buffer.
+func (w *Writer) PushReader(r io.Reader, n int) {
+	w.streams = append(w.streams, stream{pos: len(w.buf), r: r, n: n})
+	w.streamed += n
+}
+
 // PushString writes a string to the buffer.
 func (w *Writer) PushString(val string) {
 	w.PushUint32(uint32(len(val)))
@@ -104,6 +183,7 @@ func (w *Writer) BeginBytes() {
 	n := len(w.buf)
 	w.buf = append(w.buf, 0, 0, 0, 0)
 	w.bytePos = append(w.bytePos, n)
+	w.byteStreamed = append(w.byteStreamed, w.streamed)
 }
 
 // EndBytes sets the `data_length` allocated by BeginBytes
@@ -116,9 +196,11 @@ func (w *Writer) EndBytes() {
 	}
 
 	pos := w.bytePos[n-1]
+	streamed := w.streamed - w.byteStreamed[n-1]
 	w.bytePos = w.bytePos[:n-1]
+	w.byteStreamed = w.byteStreamed[:n-1]
 
-	byteLen := uint32(len(w.buf) - pos - 4)
+	byteLen := uint32(len(w.buf) - pos - 4 + streamed)
 	binary.BigEndian.PutUint32(w.buf[pos:], byteLen)
 }
 
@@ -132,6 +214,7 @@ func (w *Writer) BeginMessage(mType uint8) {
 	}
 
 	w.msgPos = 1 + len(w.buf)
+	w.msgStreamed = w.streamed
 	w.buf = append(w.buf, mType, 0, 0, 0, 0)
 }
 
@@ -147,7 +230,7 @@ func (w *Writer) EndMessage() {
 		panic("cannot end message: bytes in progress")
 	}
 
-	msgLen := uint32(len(w.buf) - w.msgPos)
+	msgLen := uint32(len(w.buf) - w.msgPos + w.streamed - w.msgStreamed)
 	binary.BigEndian.PutUint32(w.buf[w.msgPos:], msgLen)
 	w.msgPos = 0
 }
@@ -0,0 +1,63 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buff
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteToStreams(t *testing.T) {
+	w := NewWriter(nil)
+	w.BeginMessage(0xa)
+	w.BeginBytes()
+	w.PushUint8(1)
+	w.PushReader(strings.NewReader("abc"), 3)
+	w.EndBytes()
+	w.PushUint8(2)
+	w.EndMessage()
+
+	assert.True(t, w.Streaming())
+	assert.Equal(t, 14, w.Len())
+	assert.Panics(t, func() { w.Unwrap() })
+
+	var dst bytes.Buffer
+	n, err := w.WriteTo(&dst)
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), n)
+	assert.Equal(t, []byte{
+		0xa, 0, 0, 0, 13, // message type and length
+		0, 0, 0, 4, // bytes length
+		1, 'a', 'b', 'c',
+		2,
+	}, dst.Bytes())
+}
+
+func TestWriteToShortStream(t *testing.T) {
+	w := NewWriter(nil)
+	w.BeginMessage(0xa)
+	w.PushReader(strings.NewReader("ab"), 3)
+	w.EndMessage()
+
+	_, err := w.WriteTo(io.Discard)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -36,8 +36,13 @@ type Cache struct {
 	root node
 }
 
-// New returns a new cache.
+// New returns a new cache. A cache with a capacity less than one
+// does not store any values.
 func New(cap int) *Cache {
+	if cap < 0 {
+		cap = 0
+	}
+
 	c := Cache{cap: cap, mp: make(map[interface{}]*node, cap)}
 	c.root.next = &c.root
 	c.root.prev = &c.root
@@ -75,6 +80,10 @@ func (c *Cache) Put(key interface{}, val interface{}) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cap < 1 {
+		return
+	}
+
 	if n, ok := c.mp[key]; ok {
 		n.val = val
 		c.moveToFront(n)
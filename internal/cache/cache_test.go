@@ -56,6 +56,17 @@ func TestCachePutMoreThanCapacity(t *testing.T) {
 	assert.Equal(t, "two", val)
 }
 
+func TestCacheWithoutCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		cache := New(capacity)
+
+		cache.Put("key", "val")
+		val, ok := cache.Get("key")
+		require.False(t, ok)
+		require.Nil(t, val)
+	}
+}
+
 func TestCachePutConcurencySafe(t *testing.T) {
 	// running this test with the race detector enabled
 	// is likely to expose race conditions.
@@ -0,0 +1,297 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RAGRequest is a retrieval augmented generation request
+// for the ext::ai extension.
+type RAGRequest struct {
+	// Model is the text generation model, e.g. gpt-4-turbo-preview.
+	Model string `json:"model"`
+
+	// Query is the question the model is asked.
+	Query string `json:"query"`
+
+	// Context selects the objects that are used to answer Query.
+	Context RAGContext `json:"context"`
+
+	// Prompt is the prompt used to generate the answer. If Prompt is nil
+	// the default prompt is used.
+	Prompt *RAGPrompt `json:"prompt,omitempty"`
+}
+
+// RAGContext selects the objects that are used as context for a RAGRequest.
+// The objects' type must have an ext::ai::index.
+type RAGContext struct {
+	// Query is an EdgeQL expression returning the objects, e.g. Movie or
+	// (SELECT Movie FILTER .year > <int64>$year).
+	Query string `json:"query"`
+
+	// Variables are the values of Query's parameters.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+
+	// Globals are the values of global variables used by Query. If Globals
+	// is nil the client's globals are used.
+	Globals map[string]interface{} `json:"globals,omitempty"`
+
+	// MaxObjectCount limits how many objects are used.
+	// Zero uses the server's default.
+	MaxObjectCount int `json:"max_object_count,omitempty"`
+}
+
+// RAGPrompt selects or defines the prompt for a RAGRequest. Set one of Name,
+// ID or Custom. Custom messages are appended if Name or ID is also set.
+type RAGPrompt struct {
+	// Name is the name of an ext::ai::ChatPrompt.
+	Name string `json:"name,omitempty"`
+
+	// ID is the id of an ext::ai::ChatPrompt.
+	ID string `json:"id,omitempty"`
+
+	// Custom are messages sent to the model.
+	Custom []RAGMessage `json:"custom,omitempty"`
+}
+
+// RAGMessage is a single chat message in a RAGPrompt.
+type RAGMessage struct {
+	// Role is one of system, user or assistant.
+	Role string `json:"role"`
+
+	// Content is the message text.
+	Content string `json:"content"`
+}
+
+type ragRequest struct {
+	RAGRequest
+	Stream bool `json:"stream"`
+}
+
+func (p *Client) ragRequest(req RAGRequest, stream bool) ragRequest {
+	if req.Context.Globals == nil {
+		req.Context.Globals = p.globals()
+	}
+
+	return ragRequest{RAGRequest: req, Stream: stream}
+}
+
+// QueryRAG asks the ext::ai extension to answer a question
+// using the objects selected by the request's context
+// and returns the generated answer.
+func (p *Client) QueryRAG(
+	ctx context.Context,
+	req RAGRequest,
+) (string, error) {
+	resp, err := p.postHTTP(ctx, "ext/ai/rag", p.ragRequest(req, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", httpError(resp)
+	}
+
+	var result struct {
+		Response string `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", &protocolError{err: fmt.Errorf(
+			"decoding ext::ai response: %w", err)}
+	}
+
+	return result.Response, nil
+}
+
+// StreamRAG is like QueryRAG but streams the answer as it is generated.
+// The returned stream must be closed.
+//
+//	stream, err := client.StreamRAG(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	defer stream.Close()
+//
+//	for {
+//	    text, err := stream.Next()
+//	    if err == io.EOF {
+//	        break
+//	    } else if err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(text)
+//	}
+func (p *Client) StreamRAG(
+	ctx context.Context,
+	req RAGRequest,
+) (*RAGStream, error) {
+	resp, err := p.postHTTP(ctx, "ext/ai/rag", p.ragRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close() // nolint:errcheck
+		return nil, httpError(resp)
+	}
+
+	return &RAGStream{body: resp.Body, r: bufio.NewReader(resp.Body)}, nil
+}
+
+// RAGStream is a streamed ext::ai answer. See Client.StreamRAG.
+type RAGStream struct {
+	body io.Closer
+	r    *bufio.Reader
+	done bool
+}
+
+type ragEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Next returns the next piece of the generated answer. io.EOF is returned
+// after the answer is complete.
+func (s *RAGStream) Next() (string, error) {
+	for !s.done {
+		data, err := s.nextEvent()
+		if err == io.EOF {
+			s.done = true
+			return "", &clientConnectionError{
+				msg: "ext::ai stream ended unexpectedly"}
+		} else if err != nil {
+			return "", &clientConnectionError{err: err}
+		}
+
+		var event ragEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return "", &protocolError{err: fmt.Errorf(
+				"decoding ext::ai event: %w", err)}
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				return event.Delta.Text, nil
+			}
+		case "message_stop":
+			s.done = true
+		case "error":
+			s.done = true
+			return "", &queryError{msg: event.Error.Message}
+		}
+	}
+
+	return "", io.EOF
+}
+
+// nextEvent returns the data of the next server sent event.
+func (s *RAGStream) nextEvent() ([]byte, error) {
+	var data []byte
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				return data, nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(line[5:], []byte(" "))...)
+		}
+
+		if err == io.EOF {
+			if len(data) > 0 {
+				return data, nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+// Close closes the stream.
+func (s *RAGStream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// GenerateEmbeddings returns an embedding vector for each of the inputs
+// using the ext::ai extension's embedding model.
+func (p *Client) GenerateEmbeddings(
+	ctx context.Context,
+	model string,
+	inputs []string,
+) ([][]float32, error) {
+	body := struct {
+		Model  string   `json:"model"`
+		Inputs []string `json:"inputs"`
+	}{model, inputs}
+
+	resp, err := p.postHTTP(ctx, "ext/ai/embeddings", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpError(resp)
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &protocolError{err: fmt.Errorf(
+			"decoding ext::ai response: %w", err)}
+	}
+
+	embeddings := make([][]float32, len(inputs))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(embeddings) {
+			return nil, &protocolError{msg: fmt.Sprintf(
+				"ext::ai returned an embedding for unknown input %v",
+				d.Index)}
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+
+	return embeddings, nil
+}
@@ -0,0 +1,123 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRAG(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/db/main/ext/ai/rag", r.URL.Path)
+
+			var req ragRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "gpt-4", req.Model)
+			assert.Equal(t, "Movie", req.Context.Query)
+			assert.Equal(t,
+				map[string]interface{}{"x": "y"}, req.Context.Globals)
+
+			if !req.Stream {
+				_, _ = w.Write([]byte(`{"response": "forty two"}`))
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message_start\n" +
+				`data: {"type": "message_start"}` + "\n\n" +
+				"event: content_block_delta\n" +
+				`data: {"type": "content_block_delta", ` +
+				`"delta": {"type": "text_delta", "text": "forty"}}` +
+				"\n\n" +
+				"event: content_block_delta\n" +
+				`data: {"type": "content_block_delta", ` +
+				`"delta": {"type": "text_delta", "text": " two"}}` +
+				"\n\n" +
+				"event: message_stop\n" +
+				`data: {"type": "message_stop"}` + "\n\n"))
+		},
+	))
+	defer srv.Close()
+
+	client := httpTestClient(srv).
+		WithGlobals(map[string]interface{}{"x": "y"})
+	req := RAGRequest{
+		Model:   "gpt-4",
+		Query:   "What is the answer?",
+		Context: RAGContext{Query: "Movie"},
+	}
+
+	ctx := context.Background()
+	answer, err := client.QueryRAG(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "forty two", answer)
+
+	stream, err := client.StreamRAG(ctx, req)
+	require.NoError(t, err)
+	defer stream.Close() // nolint:errcheck
+
+	var parts []string
+	for {
+		text, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		parts = append(parts, text)
+	}
+	assert.Equal(t, []string{"forty", " two"}, parts)
+}
+
+func TestGenerateEmbeddings(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/db/main/ext/ai/embeddings", r.URL.Path)
+
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(
+					`{"error": {"message": "unauthorized"}}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data": [` +
+				`{"index": 1, "embedding": [3, 4]}, ` +
+				`{"index": 0, "embedding": [1, 2]}]}`))
+		},
+	))
+	defer srv.Close()
+
+	client := httpTestClient(srv)
+	embeddings, err := client.GenerateEmbeddings(
+		context.Background(), "text-embedding-3-small", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 2}, {3, 4}}, embeddings)
+
+	client.cfg.user = ""
+	_, err = client.GenerateEmbeddings(
+		context.Background(), "text-embedding-3-small", []string{"a"})
+	assert.EqualError(t, err, "edgedb.QueryError: unauthorized")
+}
@@ -0,0 +1,116 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AuthProvider supplies credentials each time a connection is established.
+// It can be used to exchange a workload identity, for example a Kubernetes
+// service account token or a cloud IAM identity, for short lived EdgeDB
+// credentials. Implementations must be safe for concurrent use.
+type AuthProvider interface {
+	Credentials(ctx context.Context, req AuthRequest) (AuthCredentials, error)
+}
+
+// AuthProviderFunc is an AuthProvider implemented by a function.
+type AuthProviderFunc func(
+	ctx context.Context,
+	req AuthRequest,
+) (AuthCredentials, error)
+
+// Credentials calls f(ctx, req).
+func (f AuthProviderFunc) Credentials(
+	ctx context.Context,
+	req AuthRequest,
+) (AuthCredentials, error) {
+	return f(ctx, req)
+}
+
+// AuthRequest describes the connection credentials are requested for.
+type AuthRequest struct {
+	// Addr is the server's host and port.
+	Addr     string
+	User     string
+	Database string
+}
+
+// AuthCredentials are the credentials used to authenticate a connection.
+// Empty fields keep the value resolved from the client's options.
+type AuthCredentials struct {
+	User      string
+	Password  string
+	SecretKey string
+}
+
+// NewTokenFileAuthProvider returns an AuthProvider that reads a secret key
+// from path every time a connection is established. This supports tokens
+// that are rotated on disk by another process, for example a projected
+// volume or a sidecar that exchanges workload identities for EdgeDB secret
+// keys.
+func NewTokenFileAuthProvider(path string) AuthProvider {
+	return AuthProviderFunc(func(
+		_ context.Context,
+		_ AuthRequest,
+	) (AuthCredentials, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return AuthCredentials{}, err
+		}
+
+		return AuthCredentials{
+			SecretKey: strings.TrimSpace(string(data)),
+		}, nil
+	})
+}
+
+// resolveAuth returns a copy of cfg with the credentials from its auth
+// provider. cfg is returned unchanged if it has no auth provider.
+func (c *connConfig) resolveAuth(ctx context.Context) (*connConfig, error) {
+	if c.authProvider == nil {
+		return c, nil
+	}
+
+	creds, err := c.authProvider.Credentials(ctx, AuthRequest{
+		Addr:     c.addr.address,
+		User:     c.user,
+		Database: c.database,
+	})
+	if err != nil {
+		return nil, &authenticationError{
+			err: fmt.Errorf("auth provider: %w", err)}
+	}
+
+	cfg := *c
+	if creds.User != "" {
+		cfg.user = creds.User
+	}
+
+	if creds.Password != "" {
+		cfg.password = creds.Password
+	}
+
+	if creds.SecretKey != "" {
+		cfg.secretKey = creds.SecretKey
+	}
+
+	return &cfg, nil
+}
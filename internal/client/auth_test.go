@@ -0,0 +1,119 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAuth(t *testing.T) {
+	ctx := context.Background()
+	cfg := &connConfig{
+		addr:     dialArgs{"tcp", "localhost:5656"},
+		user:     "edgedb",
+		password: "configured",
+		database: "main",
+	}
+
+	resolved, err := cfg.resolveAuth(ctx)
+	require.NoError(t, err)
+	assert.Same(t, cfg, resolved)
+
+	var req AuthRequest
+	cfg.authProvider = AuthProviderFunc(func(
+		_ context.Context,
+		r AuthRequest,
+	) (AuthCredentials, error) {
+		req = r
+		return AuthCredentials{SecretKey: "token"}, nil
+	})
+
+	resolved, err = cfg.resolveAuth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, AuthRequest{
+		Addr:     "localhost:5656",
+		User:     "edgedb",
+		Database: "main",
+	}, req)
+	assert.Equal(t, "edgedb", resolved.user)
+	assert.Equal(t, "configured", resolved.password)
+	assert.Equal(t, "token", resolved.secretKey)
+	assert.Equal(t, "", cfg.secretKey, "cfg should not be modified")
+
+	cause := errors.New("token expired")
+	cfg.authProvider = AuthProviderFunc(func(
+		context.Context,
+		AuthRequest,
+	) (AuthCredentials, error) {
+		return AuthCredentials{}, cause
+	})
+
+	_, err = cfg.resolveAuth(ctx)
+	assert.EqualError(t, err,
+		"edgedb.AuthenticationError: auth provider: token expired")
+	assert.True(t, errors.Is(err, cause))
+
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr))
+	assert.True(t, edbErr.Category(AuthenticationError))
+}
+
+func TestTokenFileAuthProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		},
+	))
+	defer srv.Close()
+
+	client := httpTestClient(srv)
+	client.cfg.authProvider = NewTokenFileAuthProvider(path)
+
+	ctx := context.Background()
+	resp, err := client.postHTTP(ctx, "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer first", readBody(t, resp))
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	resp, err = client.postHTTP(ctx, "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer second", readBody(t, resp))
+
+	require.NoError(t, os.Remove(path))
+	_, err = client.postHTTP(ctx, "test", nil)
+	assert.True(t, errors.Is(err, os.ErrNotExist), err)
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close() // nolint:errcheck
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,164 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+)
+
+// BoundQuery is a PreparedQuery whose named arguments are read from the
+// fields of a struct. The fields are bound to the query's argument encoders
+// once, so the arguments are encoded straight from the struct without
+// reflecting on it for every call. Use PreparedQuery.Bind to create a
+// BoundQuery. A BoundQuery is safe for concurrent use.
+type BoundQuery struct {
+	pq      *PreparedQuery
+	typ     reflect.Type
+	binding *codecs.ArgBinding
+}
+
+// Bind returns a BoundQuery that reads the query's named arguments from
+// values of the same type as args. args must be a pointer to a struct.
+// Fields are matched to arguments by their edgedb tag, or by the tag set with
+// Options.StructTag. Fields without a tag are not arguments. Fields tagged
+// with edgedb:"$inline" are searched for arguments too. Every argument of the
+// query must have a field.
+//
+//	type movieArgs struct {
+//		Title string `edgedb:"title"`
+//		Year  int64  `edgedb:"year"`
+//	}
+//
+//	bq, err := pq.Bind(&movieArgs{})
+//	err = bq.QuerySingle(ctx, &movie, &movieArgs{Title: "Alien", Year: 1979})
+func (pq *PreparedQuery) Bind(args interface{}) (*BoundQuery, error) {
+	typ := reflect.TypeOf(args)
+	if typ == nil ||
+		typ.Kind() != reflect.Ptr ||
+		typ.Elem().Kind() != reflect.Struct {
+		return nil, &interfaceError{msg: fmt.Sprintf(
+			"the \"args\" argument must be a pointer to a struct, got %T",
+			args)}
+	}
+
+	tag := pq.client.decoderOptions.Tag()
+	fields := bindFields(typ.Elem(), tag, 0, nil)
+	if len(fields) == 0 {
+		return nil, &interfaceError{msg: fmt.Sprintf(
+			"%v has no fields with a %q tag", typ.Elem(), tag)}
+	}
+
+	bq := &BoundQuery{
+		pq:      pq,
+		typ:     typ,
+		binding: codecs.NewArgBinding(fields),
+	}
+
+	// The encoders are bound again if the query's arguments change.
+	if in, ok := pq.argEncoder(); ok {
+		if err := bq.binding.Bind(in); err != nil {
+			return nil, &invalidArgumentError{msg: err.Error()}
+		}
+	}
+
+	return bq, nil
+}
+
+func bindFields(
+	typ reflect.Type,
+	key string,
+	offset uintptr,
+	fields []codecs.ArgField,
+) []codecs.ArgField {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get(key)
+		switch {
+		case tag == "" || tag == "-" || field.PkgPath != "":
+		case tag == "$inline" && field.Type.Kind() == reflect.Struct:
+			fields = bindFields(
+				field.Type, key, offset+field.Offset, fields)
+		default:
+			fields = append(fields, codecs.ArgField{
+				Name:   tag,
+				Offset: offset + field.Offset,
+				Type:   field.Type,
+			})
+		}
+	}
+
+	return fields
+}
+
+// args returns the named arguments in args.
+func (bq *BoundQuery) args(args interface{}) ([]interface{}, error) {
+	if reflect.TypeOf(args) != bq.typ {
+		return nil, &interfaceError{msg: fmt.Sprintf(
+			"the \"args\" argument must be %v, got %T", bq.typ, args)}
+	}
+
+	p := reflect.ValueOf(args).UnsafePointer()
+	if p == nil {
+		return nil, &interfaceError{
+			msg: "the \"args\" argument must not be nil"}
+	}
+
+	return []interface{}{codecs.StructArgs{Binding: bq.binding, Ptr: p}}, nil
+}
+
+// Execute runs the bound query. See Client.Execute.
+func (bq *BoundQuery) Execute(ctx context.Context, args interface{}) error {
+	a, err := bq.args(args)
+	if err != nil {
+		return err
+	}
+
+	return bq.pq.Execute(ctx, a...)
+}
+
+// Query runs the bound query and returns the results. See Client.Query.
+func (bq *BoundQuery) Query(
+	ctx context.Context,
+	out interface{},
+	args interface{},
+) error {
+	a, err := bq.args(args)
+	if err != nil {
+		return err
+	}
+
+	return bq.pq.Query(ctx, out, a...)
+}
+
+// QuerySingle runs the bound query and returns at most one result.
+// See Client.QuerySingle.
+func (bq *BoundQuery) QuerySingle(
+	ctx context.Context,
+	out interface{},
+	args interface{},
+) error {
+	a, err := bq.args(args)
+	if err != nil {
+		return err
+	}
+
+	return bq.pq.QuerySingle(ctx, out, a...)
+}
@@ -0,0 +1,220 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindPage struct {
+	Limit int64 `edgedb:"limit"`
+}
+
+type bindArgs struct {
+	ID     types.UUID        `edgedb:"id"`
+	Title  string            `edgedb:"title"`
+	Note   types.OptionalStr `edgedb:"note"`
+	Page   bindPage          `edgedb:"$inline"`
+	Ignore string
+	hidden string `edgedb:"hidden"` // nolint:structcheck,unused
+}
+
+// newBindClient returns a client with empty caches.
+func newBindClient(opts codecs.DecoderOptions) *Client {
+	return &Client{cacheCollection: newCacheCollection(nil, 10, opts)}
+}
+
+func TestBindArgs(t *testing.T) {
+	pq := &PreparedQuery{client: newBindClient(codecs.DecoderOptions{})}
+	bq, err := pq.Bind(&bindArgs{})
+	require.NoError(t, err)
+
+	note := types.OptionalStr{}
+	note.Set("n")
+	val := &bindArgs{
+		ID:     types.UUID{1},
+		Title:  "t",
+		Note:   note,
+		Page:   bindPage{Limit: 7},
+		Ignore: "x",
+	}
+	args, err := bq.args(val)
+	require.NoError(t, err)
+
+	in := buildBindEncoder(t)
+	w := buff.NewWriter(nil)
+	w.BeginMessage(uint8(Execute1pX))
+	err = in.Encode(w, args, codecs.Path("args"), true)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	expected := buff.NewWriter(nil)
+	expected.BeginMessage(uint8(Execute1pX))
+	err = in.Encode(expected, []interface{}{map[string]interface{}{
+		"id":    types.UUID{1},
+		"title": "t",
+		"note":  note,
+		"limit": int64(7),
+	}}, codecs.Path("args"), true)
+	require.NoError(t, err)
+	expected.EndMessage()
+	assert.Equal(t, expected.Unwrap(), w.Unwrap(),
+		"struct arguments are encoded like a map")
+
+	_, err = bq.args(bindArgs{})
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`the "args" argument must be *edgedb.bindArgs, got edgedb.bindArgs`)
+
+	_, err = bq.args((*bindArgs)(nil))
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`the "args" argument must not be nil`)
+}
+
+func TestBindPrepared(t *testing.T) {
+	client := newBindClient(codecs.DecoderOptions{})
+	pq := &PreparedQuery{client: client, cmd: "SELECT <str>$title"}
+
+	in := buildBindEncoder(t)
+	q := &query{cmd: pq.cmd, fmt: Binary}
+	q.setOptions(client.queryOpts)
+	client.preparedCache.Put(makePreparedKey(q), prepared{
+		ids: idPair{in: in.DescriptorID()},
+	})
+	client.inCodecCache.Put(in.DescriptorID(), in)
+
+	_, err := pq.Bind(&bindArgs{})
+	require.NoError(t, err)
+
+	type missing struct {
+		Title string `edgedb:"title"`
+	}
+	_, err = pq.Bind(&missing{})
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		"no struct field for argument args.id")
+}
+
+func TestBindInvalid(t *testing.T) {
+	pq := &PreparedQuery{client: newBindClient(codecs.DecoderOptions{})}
+
+	_, err := pq.Bind(bindArgs{})
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`the "args" argument must be a pointer to a struct, `+
+		"got edgedb.bindArgs")
+
+	_, err = pq.Bind(nil)
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`the "args" argument must be a pointer to a struct, got <nil>`)
+
+	_, err = pq.Bind(&struct{ Title string }{})
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`struct { Title string } has no fields with a "edgedb" tag`)
+}
+
+func TestBindStructTag(t *testing.T) {
+	opts := codecs.DecoderOptions{StructTag: "db"}
+	pq := &PreparedQuery{client: newBindClient(opts)}
+
+	type args struct {
+		Title string `db:"title"`
+		Year  int64  `edgedb:"year"`
+	}
+	bq, err := pq.Bind(&args{})
+	require.NoError(t, err)
+	assert.Equal(t, codecs.NewArgBinding([]codecs.ArgField{{
+		Name: "title",
+		Type: reflect.TypeOf(""),
+	}}), bq.binding)
+
+	_, err = pq.Bind(&bindArgs{})
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		`edgedb.bindArgs has no fields with a "db" tag`)
+}
+
+func buildBindEncoder(t testing.TB) codecs.Encoder {
+	str := descriptor.V2{Type: descriptor.Scalar, ID: codecs.StrID}
+	in, err := codecs.BuildEncoderV2(&descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "id",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   codecs.UUIDID,
+				},
+				Required: true,
+			},
+			{Name: "title", Desc: str, Required: true},
+			{Name: "note", Desc: str},
+			{
+				Name: "limit",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   codecs.Int64ID,
+				},
+				Required: true,
+			},
+		},
+	}, protocolVersion2p0)
+	require.NoError(t, err)
+	return in
+}
+
+func BenchmarkEncodeBoundArgs(b *testing.B) {
+	in := buildBindEncoder(b)
+	pq := &PreparedQuery{client: newBindClient(codecs.DecoderOptions{})}
+	bq, err := pq.Bind(&bindArgs{})
+	require.NoError(b, err)
+
+	mem := make([]byte, 0, 1024)
+	val := &bindArgs{ID: types.UUID{1}, Title: "t", Page: bindPage{7}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := buff.NewWriter(mem)
+		w.BeginMessage(uint8(Execute1pX))
+		args, _ := bq.args(val)
+		_ = in.Encode(w, args, codecs.Path("args"), true)
+	}
+}
+
+func BenchmarkEncodeMapArgs(b *testing.B) {
+	in := buildBindEncoder(b)
+	mem := make([]byte, 0, 1024)
+	val := &bindArgs{ID: types.UUID{1}, Title: "t", Page: bindPage{7}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := buff.NewWriter(mem)
+		w.BeginMessage(uint8(Execute1pX))
+		args := []interface{}{map[string]interface{}{
+			"id":    val.ID,
+			"title": val.Title,
+			"note":  val.Note,
+			"limit": val.Page.Limit,
+		}}
+		_ = in.Encode(w, args, codecs.Path("args"), true)
+	}
+}
@@ -0,0 +1,422 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// OptionsBuilder builds connection options and validates them before a
+// client is created. Use NewOptionsBuilder to create an OptionsBuilder.
+//
+//	b := edgedb.NewOptionsBuilder().WithHost("db.internal").WithUser("app")
+//	cfg, err := b.Resolve()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Println(cfg) // prints where each setting came from
+//
+//	client, err := b.CreateClient(ctx)
+type OptionsBuilder struct {
+	dsn      string
+	instance string
+	opts     Options
+}
+
+// NewOptionsBuilder returns an empty OptionsBuilder.
+func NewOptionsBuilder() OptionsBuilder {
+	return OptionsBuilder{}
+}
+
+// WithOptions returns a copy of the builder with its options replaced by
+// opts.
+func (b OptionsBuilder) WithOptions( // nolint:gocritic
+	opts Options,
+) OptionsBuilder {
+	b.opts = opts
+	return b
+}
+
+// WithDSN returns a copy of the builder that connects using dsn.
+func (b OptionsBuilder) WithDSN( // nolint:gocritic
+	dsn string,
+) OptionsBuilder {
+	b.dsn = dsn
+	return b
+}
+
+// WithInstance returns a copy of the builder that connects to the named
+// instance.
+func (b OptionsBuilder) WithInstance( // nolint:gocritic
+	name string,
+) OptionsBuilder {
+	b.instance = name
+	return b
+}
+
+// WithHost returns a copy of the builder with the host set.
+func (b OptionsBuilder) WithHost( // nolint:gocritic
+	host string,
+) OptionsBuilder {
+	b.opts.Host = host
+	return b
+}
+
+// WithPort returns a copy of the builder with the port set.
+func (b OptionsBuilder) WithPort(port int) OptionsBuilder { // nolint:gocritic
+	b.opts.Port = port
+	return b
+}
+
+// WithCredentialsFile returns a copy of the builder that reads connection
+// credentials from path.
+func (b OptionsBuilder) WithCredentialsFile( // nolint:gocritic
+	path string,
+) OptionsBuilder {
+	b.opts.CredentialsFile = path
+	return b
+}
+
+// WithUser returns a copy of the builder with the user set.
+func (b OptionsBuilder) WithUser( // nolint:gocritic
+	user string,
+) OptionsBuilder {
+	b.opts.User = user
+	return b
+}
+
+// WithDatabase returns a copy of the builder with the database set.
+func (b OptionsBuilder) WithDatabase( // nolint:gocritic
+	database string,
+) OptionsBuilder {
+	b.opts.Database = database
+	return b
+}
+
+// WithPassword returns a copy of the builder with the password set.
+func (b OptionsBuilder) WithPassword( // nolint:gocritic
+	password string,
+) OptionsBuilder {
+	b.opts.Password = types.NewOptionalStr(password)
+	return b
+}
+
+// WithSecretKey returns a copy of the builder with the secret key set.
+func (b OptionsBuilder) WithSecretKey( // nolint:gocritic
+	secretKey string,
+) OptionsBuilder {
+	b.opts.SecretKey = secretKey
+	return b
+}
+
+// WithTLSOptions returns a copy of the builder with the TLS options set.
+func (b OptionsBuilder) WithTLSOptions( // nolint:gocritic
+	opts TLSOptions,
+) OptionsBuilder {
+	b.opts.TLSOptions = opts
+	return b
+}
+
+// WithConnectTimeout returns a copy of the builder with the connect timeout
+// set.
+func (b OptionsBuilder) WithConnectTimeout( // nolint:gocritic
+	timeout time.Duration,
+) OptionsBuilder {
+	b.opts.ConnectTimeout = timeout
+	return b
+}
+
+// WithWaitUntilAvailable returns a copy of the builder with the time to wait
+// for the server to become available set.
+func (b OptionsBuilder) WithWaitUntilAvailable( // nolint:gocritic
+	wait time.Duration,
+) OptionsBuilder {
+	b.opts.WaitUntilAvailable = wait
+	return b
+}
+
+// WithConcurrency returns a copy of the builder with the maximum number of
+// connections set.
+func (b OptionsBuilder) WithConcurrency( // nolint:gocritic
+	concurrency uint,
+) OptionsBuilder {
+	b.opts.Concurrency = concurrency
+	return b
+}
+
+// Build validates the builder and returns the dsn and options to pass to
+// CreateClientDSN.
+func (b OptionsBuilder) Build() (string, Options, error) { // nolint:gocritic
+	if err := b.validate(); err != nil {
+		return "", Options{}, &configurationError{err: err}
+	}
+
+	dsn := b.dsn
+	if b.instance != "" {
+		dsn = b.instance
+	}
+
+	return dsn, b.opts, nil
+}
+
+// CreateClient validates the builder and returns a new client.
+func (b OptionsBuilder) CreateClient( // nolint:gocritic
+	ctx context.Context,
+) (*Client, error) {
+	dsn, opts, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateClientDSN(ctx, dsn, opts)
+}
+
+// Resolve validates the builder and resolves the connection settings the
+// way a client would, including environment variables, credentials files
+// and the project link, without connecting to the server.
+func (b OptionsBuilder) Resolve() (*ResolvedConfig, error) { // nolint:gocritic,lll
+	dsn, opts, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newConfigResolver(dsn, &opts, newCfgPaths())
+	if err != nil {
+		return nil, &configurationError{err: err}
+	}
+
+	cfg, err := r.config(&opts)
+	if err != nil {
+		return nil, &configurationError{err: err}
+	}
+
+	return r.resolved(cfg), nil
+}
+
+func (b OptionsBuilder) validate() error { // nolint:gocritic
+	var (
+		errs  []string
+		names []string
+	)
+
+	if b.dsn != "" {
+		names = append(names, "DSN")
+	}
+	if b.instance != "" {
+		names = append(names, "instance")
+	}
+	if b.opts.Credentials != nil {
+		names = append(names, "credentials")
+	}
+	if b.opts.CredentialsFile != "" {
+		names = append(names, "credentials file")
+	}
+	if b.opts.Host != "" || b.opts.Port != 0 {
+		names = append(names, "host and port")
+	}
+	if len(names) > 1 {
+		errs = append(errs, fmt.Sprintf(
+			"%v are mutually exclusive", englishList(names, "and")))
+	}
+
+	if b.dsn != "" && !isDSNLike.MatchString(b.dsn) {
+		errs = append(errs, fmt.Sprintf(
+			"DSN %q does not start with edgedb://", b.dsn))
+	}
+
+	if b.opts.Port < 0 || b.opts.Port > 65535 {
+		errs = append(errs, fmt.Sprintf(
+			"port must be between 1 and 65535, got %v", b.opts.Port))
+	}
+
+	switch b.opts.TLSOptions.SecurityMode {
+	case "", TLSModeDefault, TLSModeInsecure, TLSModeNoHostVerification,
+		TLSModeStrict:
+	default:
+		errs = append(errs, fmt.Sprintf(
+			"invalid TLS security mode %q", b.opts.TLSOptions.SecurityMode))
+	}
+
+	if b.opts.TLSOptions.CA != nil && b.opts.TLSOptions.CAFile != "" {
+		errs = append(errs, "TLS CA and CA file are mutually exclusive")
+	}
+
+	if b.opts.ConnectTimeout < 0 {
+		errs = append(errs, "connect timeout must not be negative")
+	}
+
+	if b.opts.WaitUntilAvailable < 0 {
+		errs = append(errs, "wait until available must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid options: " + strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// ResolvedConfig is the effective connection configuration. Sources maps
+// each setting's name to where its value came from, e.g. "EDGEDB_HOST
+// environment variable" or "default".
+type ResolvedConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Database string
+
+	// Password and SecretKey are "<redacted>" if they are set. The secrets
+	// are not kept so printing or serializing the config can't leak them.
+	Password  string
+	SecretKey string
+
+	TLSSecurity        string
+	TLSCAData          []byte
+	WaitUntilAvailable time.Duration
+	ConnectTimeout     time.Duration
+	Sources            map[string]string
+}
+
+// String returns the configuration, with its secrets redacted, and the
+// source of each setting.
+func (c *ResolvedConfig) String() string {
+	var b strings.Builder
+
+	line := func(name string, val interface{}) {
+		fmt.Fprintf(&b, "%v: %v", name, val)
+		if source, ok := c.Sources[name]; ok {
+			fmt.Fprintf(&b, " (%v)", source)
+		}
+		b.WriteString("\n")
+	}
+
+	line("host", c.Host)
+	line("port", c.Port)
+	line("user", c.User)
+	line("database", c.Database)
+	line("password", redact(c.Password))
+	line("secret_key", redact(c.SecretKey))
+	line("tls_security", c.TLSSecurity)
+	if len(c.TLSCAData) > 0 {
+		line("tls_ca", fmt.Sprintf("%v bytes", len(c.TLSCAData)))
+	} else {
+		line("tls_ca", "<none>")
+	}
+	line("wait_until_available", c.WaitUntilAvailable)
+	line("connect_timeout", c.ConnectTimeout)
+
+	return b.String()
+}
+
+// DSN returns the configuration as a DSN with the password and secret key
+// replaced by xxxxx. It is safe to include in logs and error messages,
+// for example when a connection attempt fails.
+//
+//	client, err := b.CreateClient(ctx)
+//	if err != nil {
+//	    log.Printf("could not connect to %v: %v", cfg.DSN(), err)
+//	}
+func (c *ResolvedConfig) DSN() string {
+	u := url.URL{
+		Scheme: "edgedb",
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Database,
+	}
+
+	if c.Password != "" {
+		u.User = url.UserPassword(c.User, c.Password)
+	} else {
+		u.User = url.User(c.User)
+	}
+
+	query := url.Values{}
+	if c.SecretKey != "" {
+		query.Set("secret_key", "xxxxx")
+	}
+	if c.TLSSecurity != "" {
+		query.Set("tls_security", c.TLSSecurity)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.Redacted()
+}
+
+const redactedSecret = "<redacted>"
+
+func redact(secret string) string {
+	if secret == "" {
+		return "<none>"
+	}
+
+	return redactedSecret
+}
+
+// resolved returns the resolved configuration and its sources.
+func (r *configResolver) resolved(cfg *connConfig) *ResolvedConfig {
+	sources := make(map[string]string)
+	source := func(name string, val cfgVal) {
+		if val.val == nil {
+			sources[name] = "default"
+		} else {
+			sources[name] = val.source
+		}
+	}
+
+	source("host", r.host)
+	source("port", r.port)
+	source("user", r.user)
+	source("database", r.database)
+	source("password", r.password)
+	source("secret_key", r.secretKey)
+	source("tls_security", r.tlsSecurity)
+	source("tls_ca", r.tlsCAData)
+	source("wait_until_available", r.waitUntilAvailable)
+
+	host, portStr, _ := net.SplitHostPort(cfg.addr.address)
+	port, _ := strconv.Atoi(portStr)
+
+	password, secretKey := cfg.password, cfg.secretKey
+	if password != "" {
+		password = redactedSecret
+	}
+	if secretKey != "" {
+		secretKey = redactedSecret
+	}
+
+	return &ResolvedConfig{
+		Host:               host,
+		Port:               port,
+		User:               cfg.user,
+		Database:           cfg.database,
+		Password:           password,
+		SecretKey:          secretKey,
+		TLSSecurity:        cfg.tlsSecurity,
+		TLSCAData:          cfg.tlsCAData,
+		WaitUntilAvailable: cfg.waitUntilAvailable,
+		ConnectTimeout:     cfg.connectTimeout,
+		Sources:            sources,
+	}
+}
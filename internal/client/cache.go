@@ -26,7 +26,8 @@ codec cache (conn/pool specific) mapping:
 (Type ID, Go Type Ref) -> Codec
 
 type id cache (conn/pool specific) mapping:
-(Query, Expected Cardinality, IO Format, outType) -> (In Type ID, Out Type ID)
+(Query, Expected Cardinality, IO Format, outType) ->
+	(In Type ID, Out Type ID, Result Cardinality)
 
 capabilities cache (conn/pool specific) mapping:
 (Query, Expected Cardinality, IO Format, outType) -> capabilities
@@ -45,6 +46,7 @@ Optimistic execute flow:
 
 import (
 	"encoding/binary"
+	"errors"
 	"reflect"
 
 	"github.com/sebastiean/edgedb-go/internal/codecs"
@@ -63,8 +65,13 @@ type codecPair struct {
 }
 
 type idPair struct {
-	in  types.UUID
-	out types.UUID
+	in   types.UUID
+	out  types.UUID
+	card Cardinality
+
+	// warnings are the warnings the server reported
+	// when the query was parsed.
+	warnings []error
 }
 
 type queryKey struct {
@@ -72,6 +79,10 @@ type queryKey struct {
 	fmt     Format
 	expCard Cardinality
 	outType reflect.Type
+
+	// flags are the compilation flags
+	// which change the query's result type.
+	flags uint64
 }
 
 func makeKey(q *query) queryKey {
@@ -80,20 +91,49 @@ func makeKey(q *query) queryKey {
 		fmt:     q.fmt,
 		expCard: q.expCard,
 		outType: q.outType,
+		flags:   q.settings.compilationFlags(),
 	}
 }
 
 func (c *protocolConnection) getCachedTypeIDs(q *query) (*idPair, bool) {
+	if q.settings.noCache {
+		return nil, false
+	}
+
 	if val, ok := c.typeIDCache.Get(makeKey(q)); ok {
 		x := val.(idPair)
 		return &x, true
 	}
 
-	return nil, false
+	if ids, ok := c.getPreparedTypeIDs(q); ok {
+		return ids, true
+	}
+
+	return c.loadPersistedTypeIDs(q)
 }
 
 func (c *protocolConnection) cacheTypeIDs(q *query, ids idPair) {
-	c.typeIDCache.Put(makeKey(q), ids)
+	if !q.settings.noCache {
+		c.typeIDCache.Put(makeKey(q), ids)
+	}
+}
+
+// isStaleDescriptorError returns true if err indicates that the type
+// descriptors sent with a query no longer match the schema.
+func isStaleDescriptorError(err error) bool {
+	var edbErr Error
+	return errors.As(err, &edbErr) &&
+		edbErr.Category(ParameterTypeMismatchError)
+}
+
+// invalidateTypeIDs evicts the query type IDs and capabilities that are
+// shared by all of the pool's connections after the schema has changed.
+// Codecs are cached by descriptor ID and remain valid.
+func (c *protocolConnection) invalidateTypeIDs() {
+	c.typeIDCache.Invalidate()
+	c.capabilitiesCache.Invalidate()
+	c.preparedCache.Invalidate()
+	c.descriptors.invalidate()
 }
 
 func (c *protocolConnection) cacheCapabilities0pX(
@@ -107,8 +147,10 @@ func (c *protocolConnection) cacheCapabilities0pX(
 			c.inCodecCache.Invalidate()
 			c.outCodecCache.Invalidate()
 			c.capabilitiesCache.Invalidate()
+			c.preparedCache.Invalidate()
+			c.descriptors.invalidate()
 		}
-		c.capabilitiesCache.Put(makeKey(q), x)
+		c.putCapabilities(q, x)
 	}
 }
 
@@ -121,11 +163,25 @@ func (c *protocolConnection) cacheCapabilities1pX(
 		c.inCodecCache.Invalidate()
 		c.outCodecCache.Invalidate()
 		c.capabilitiesCache.Invalidate()
+		c.preparedCache.Invalidate()
+		c.descriptors.invalidate()
+	}
+	c.putCapabilities(q, capabilities)
+}
+
+func (c *protocolConnection) putCapabilities(q *query, capabilities uint64) {
+	q.reportedCapabilities = capabilities
+	q.capabilitiesReported = true
+	if !q.settings.noCache {
+		c.capabilitiesCache.Put(makeKey(q), capabilities)
 	}
-	c.capabilitiesCache.Put(makeKey(q), capabilities)
 }
 
 func (c *reconnectingConn) getCachedCapabilities(q *query) (uint64, bool) {
+	if q.capabilitiesReported {
+		return q.reportedCapabilities, true
+	}
+
 	if val, ok := c.capabilitiesCache.Get(makeKey(q)); ok {
 		x := val.(uint64)
 		return x, true
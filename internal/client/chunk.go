@@ -0,0 +1,253 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+)
+
+// WithArrayChunking returns a shallow copy of the client that splits a Query
+// with an array argument of more than size elements into queries with at
+// most size elements each and concatenates their results. This keeps
+// filters like
+//
+//	FILTER .id IN array_unpack(<array<uuid>>$ids)
+//
+// below the protocol's message size limits. Only arguments the query
+// declares as arrays are split, the query is described first to find them.
+// Only one argument may be longer than size. The queries are read only and
+// run one after another outside of a transaction, so their results can come
+// from different snapshots. Queries whose results depend on the whole array
+// at once, for example queries with an ORDER BY, LIMIT or aggregate over the
+// filtered set, must not be chunked.
+// A size of 0, the default, disables chunking.
+func (p Client) WithArrayChunking(size int) *Client { // nolint:gocritic
+	p.queryOpts.arrayChunkSize = size
+	return &p
+}
+
+// chunkedArg is an argument that is split into chunks.
+type chunkedArg struct {
+	args []interface{}
+	val  reflect.Value
+
+	// key is the argument's name if named is true,
+	// otherwise index is its position.
+	named bool
+	key   string
+	index int
+}
+
+// hasLongSlice returns true if one of args is a slice with more than size
+// elements. Only those arguments can be chunked, so queries without them
+// don't need to be described.
+func hasLongSlice(args []interface{}, size int) bool {
+	if named, isNamed := namedArgs(args); isNamed {
+		args = make([]interface{}, 0, len(named))
+		for _, val := range named {
+			args = append(args, val)
+		}
+	}
+
+	for _, val := range args {
+		v := reflect.ValueOf(val)
+		if v.Kind() == reflect.Slice && v.Len() > size {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findChunkedArg returns the argument in args that has more than size
+// elements. Only arguments that in describes as arrays are chunked, other
+// slices like vectors are a single value. ok is false if there is no such
+// argument.
+func findChunkedArg(
+	args []interface{},
+	size int,
+	in ResultType,
+) (arg *chunkedArg, ok bool, err error) {
+	arrays := make(map[string]bool, len(in.Fields))
+	for _, field := range in.Fields {
+		arrays[field.Name] = field.Type.Kind == "array"
+	}
+
+	var names []string
+	check := func(field, name string, val interface{}, c chunkedArg) {
+		v := reflect.ValueOf(val)
+		if !arrays[field] || v.Kind() != reflect.Slice || v.Len() <= size {
+			return
+		}
+
+		names = append(names, name)
+		c.args = args
+		c.val = v
+		arg = &c
+	}
+
+	if named, isNamed := namedArgs(args); isNamed {
+		for key, val := range named {
+			check(key, key, val, chunkedArg{named: true, key: key})
+		}
+	} else {
+		for i, val := range args {
+			name := fmt.Sprintf("args[%v]", i)
+			check(strconv.Itoa(i), name, val, chunkedArg{index: i})
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return arg, true, nil
+	default:
+		return nil, false, &interfaceError{msg: fmt.Sprintf(
+			"cannot split %v into chunks: only one argument may be longer "+
+				"than the array chunk size %v",
+			englishList(names, "and"), size)}
+	}
+}
+
+func namedArgs(args []interface{}) (map[string]interface{}, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+
+	named, ok := args[0].(map[string]interface{})
+	return named, ok
+}
+
+// chunk returns the arguments with the chunked argument
+// replaced by its elements from start to end.
+func (a *chunkedArg) chunk(start, end int) []interface{} {
+	val := a.val.Slice(start, end).Interface()
+
+	if a.named {
+		named := a.args[0].(map[string]interface{})
+		args := make(map[string]interface{}, len(named))
+		for k, v := range named {
+			args[k] = v
+		}
+		args[a.key] = val
+		return []interface{}{args}
+	}
+
+	args := make([]interface{}, len(a.args))
+	copy(args, a.args)
+	args[a.index] = val
+	return args
+}
+
+// queryChunked runs a Query as several queries if one of its arguments is
+// longer than the client's array chunk size. ok is false if the query does
+// not need to be split. If meta is not nil it is filled with the result
+// metadata of the queries.
+func (p *Client) queryChunked(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args []interface{},
+	meta *ResultMeta,
+) (ok bool, err error) {
+	size := p.queryOpts.arrayChunkSize
+	if !hasLongSlice(args, size) {
+		return false, nil
+	}
+
+	in, err := p.describeArgs(ctx, cmd)
+	if err != nil {
+		return true, err
+	}
+
+	arg, ok, err := findChunkedArg(args, size, in)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	result, err := introspect.ValueOfSlice(out)
+	if err != nil {
+		return true, &interfaceError{err: err}
+	}
+
+	c := *p
+	c.queryOpts.arrayChunkSize = 0
+	c.queryOpts.settings = c.queryOpts.settings.WithReadOnly(true)
+
+	rows := reflect.MakeSlice(result.Type(), 0, 0)
+	for start := 0; start < arg.val.Len(); start += size {
+		end := start + size
+		if end > arg.val.Len() {
+			end = arg.val.Len()
+		}
+
+		// Results are not decoded into the previous chunk's rows
+		// because the rows share memory with the rows already collected.
+		chunk := reflect.New(result.Type())
+		err = c.query(
+			ctx, cmd, chunk.Interface(), arg.chunk(start, end), meta)
+		if err != nil {
+			if p.queryOpts.partialResults {
+				rows = reflect.AppendSlice(rows, chunk.Elem())
+				result.Set(rows)
+			} else {
+				result.SetLen(0)
+			}
+			return true, err
+		}
+
+		rows = reflect.AppendSlice(rows, chunk.Elem())
+	}
+
+	result.Set(rows)
+	return true, nil
+}
+
+// describeArgs returns the type of cmd's arguments. The description is
+// cached so the chunked queries don't describe cmd again.
+func (p *Client) describeArgs(
+	ctx context.Context,
+	cmd string,
+) (ResultType, error) {
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return ResultType{}, err
+	}
+
+	q := &query{
+		method:       "Query",
+		cmd:          cmd,
+		fmt:          Binary,
+		expCard:      Many,
+		capabilities: conn.capabilities1pX(),
+		state:        copyState(p.state),
+	}
+	q.setOptions(p.queryOpts)
+
+	desc, err := conn.prepareFlow(ctx, q)
+	if e := firstError(err, p.release(conn, err)); e != nil {
+		return ResultType{}, e
+	}
+
+	return desc.In, nil
+}
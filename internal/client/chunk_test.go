@@ -0,0 +1,84 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func argTypes(kinds ...string) ResultType {
+	in := ResultType{Kind: "object"}
+	for i := 0; i < len(kinds); i += 2 {
+		in.Fields = append(in.Fields, ResultField{
+			Name: kinds[i],
+			Type: ResultType{Kind: kinds[i+1]},
+		})
+	}
+	return in
+}
+
+func TestFindChunkedArg(t *testing.T) {
+	in := argTypes("0", "array", "1", "scalar")
+	_, ok, err := findChunkedArg([]interface{}{[]int64{1, 2}, "a"}, 2, in)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	in = argTypes("0", "scalar")
+	_, ok, err = findChunkedArg([]interface{}{[]byte("abc")}, 2, in)
+	require.NoError(t, err)
+	assert.False(t, ok, "bytes are not an array")
+
+	vector := []float32{1, 2, 3}
+	_, ok, err = findChunkedArg([]interface{}{vector}, 2, in)
+	require.NoError(t, err)
+	assert.False(t, ok, "vectors are not an array")
+
+	in = argTypes("0", "scalar", "1", "array")
+	arg, ok, err := findChunkedArg(
+		[]interface{}{"a", []int64{1, 2, 3}}, 2, in)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"a", []int64{1, 2}}, arg.chunk(0, 2))
+	assert.Equal(t, []interface{}{"a", []int64{3}}, arg.chunk(2, 3))
+
+	in = argTypes("ids", "array", "name", "scalar")
+	named := map[string]interface{}{"ids": []int64{1, 2, 3}, "name": "a"}
+	arg, ok, err = findChunkedArg([]interface{}{named}, 2, in)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t,
+		[]interface{}{map[string]interface{}{"ids": []int64{3}, "name": "a"}},
+		arg.chunk(2, 3))
+	assert.Equal(t, []int64{1, 2, 3}, named["ids"], "args are not modified")
+
+	in = argTypes("0", "array", "1", "array")
+	_, _, err = findChunkedArg(
+		[]interface{}{[]int64{1, 2, 3}, []string{"a", "b", "c"}}, 2, in)
+	assert.EqualError(t, err, "edgedb.InterfaceError: "+
+		"cannot split args[0] and args[1] into chunks: "+
+		"only one argument may be longer than the array chunk size 2")
+}
+
+func TestHasLongSlice(t *testing.T) {
+	assert.False(t, hasLongSlice([]interface{}{[]int64{1, 2}, "abc"}, 2))
+	assert.True(t, hasLongSlice([]interface{}{[]int64{1, 2, 3}}, 2))
+	named := map[string]interface{}{"ids": []int64{1, 2, 3}}
+	assert.True(t, hasLongSlice([]interface{}{named}, 2))
+}
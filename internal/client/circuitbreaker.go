@@ -0,0 +1,139 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultCircuitOpenTimeout = 10 * time.Second
+
+// ErrCircuitOpen is wrapped by the ClientConnectionFailedError that is
+// returned instead of connecting while a client's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerOptions configures a client's circuit breaker. The circuit
+// opens after FailureThreshold consecutive connection attempts fail. While it
+// is open connection attempts fail immediately with an error wrapping
+// ErrCircuitOpen, and are not retried. After OpenTimeout a single probe
+// connection is allowed: the circuit closes if the probe succeeds and opens
+// again if it fails.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failed connection
+	// attempts that open the circuit. Zero disables the circuit breaker.
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before a probe
+	// connection is allowed. If OpenTimeout is zero, 10 seconds is used.
+	OpenTimeout time.Duration
+}
+
+// circuitBreaker counts connection failures. A nil *circuitBreaker is
+// always closed.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	timeout   time.Duration
+	clock     Clock
+
+	failures int
+	openedAt time.Time
+
+	// probing is true while the half open circuit's probe is connecting.
+	probing bool
+}
+
+func newCircuitBreaker(
+	opts CircuitBreakerOptions,
+	clock Clock,
+) *circuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		return nil
+	}
+
+	timeout := opts.OpenTimeout
+	if timeout <= 0 {
+		timeout = defaultCircuitOpenTimeout
+	}
+
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &circuitBreaker{
+		threshold: opts.FailureThreshold,
+		timeout:   timeout,
+		clock:     clock,
+	}
+}
+
+// allow returns an error if a connection attempt is not allowed.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	if b.probing || b.clock.Now().Sub(b.openedAt) < b.timeout {
+		return &clientConnectionFailedError{err: ErrCircuitOpen}
+	}
+
+	b.probing = true
+	return nil
+}
+
+// record records the result of an allowed connection attempt that was made
+// with ctx.
+func (b *circuitBreaker) record(ctx context.Context, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+
+	switch {
+	case abandoned(ctx, err):
+		// It says nothing about the server.
+	case isClientConnectionError(err):
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openedAt = b.clock.Now()
+		}
+	default:
+		// The server answered, even if it was with an error.
+		b.failures = 0
+	}
+}
+
+// abandoned returns true if a connection attempt made with ctx failed because
+// its caller gave up on it. Attempts that time out after the connect timeout
+// wrap context.DeadlineExceeded too, but they did fail.
+func abandoned(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
@@ -0,0 +1,104 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	ctx := context.Background()
+	b := newCircuitBreaker(CircuitBreakerOptions{}, nil)
+	require.Nil(t, b)
+
+	b.record(ctx, &clientConnectionFailedError{msg: "failed"})
+	assert.NoError(t, b.allow())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	b := newCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      10 * time.Millisecond,
+	}, clock)
+	failed := &clientConnectionFailedError{msg: "failed"}
+
+	require.NoError(t, b.allow())
+	b.record(ctx, failed)
+	require.NoError(t, b.allow())
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.record(canceled, &clientConnectionTimeoutError{err: canceled.Err()})
+	require.NoError(t, b.allow())
+	// The connect timeout expired but the caller didn't give up.
+	b.record(ctx, &clientConnectionTimeoutError{err: context.DeadlineExceeded})
+
+	// open
+	err := b.allow()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.EqualError(t, err,
+		"edgedb.ClientConnectionFailedError: circuit breaker is open")
+
+	// half open, only one probe is allowed
+	clock.Advance(20 * time.Millisecond)
+	require.NoError(t, b.allow())
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+	b.record(ctx, failed)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// a successful probe closes the circuit
+	clock.Advance(20 * time.Millisecond)
+	require.NoError(t, b.allow())
+	b.record(ctx, nil)
+	require.NoError(t, b.allow())
+	b.record(ctx, failed)
+	assert.NoError(t, b.allow())
+}
+
+func TestCircuitBreakerStopsReconnecting(t *testing.T) {
+	ctx := context.Background()
+	p, err := CreateClient(ctx, Options{
+		Host:               "127.0.0.1",
+		Port:               1,
+		WaitUntilAvailable: time.Nanosecond,
+		TLSOptions:         TLSOptions{SecurityMode: TLSModeInsecure},
+		CircuitBreaker:     CircuitBreakerOptions{FailureThreshold: 2},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = p.EnsureConnected(ctx)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrCircuitOpen), err)
+	}
+
+	err = p.EnsureConnected(ctx)
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr))
+	assert.True(t, edbErr.Category(ClientConnectionFailedError))
+	assert.False(t, edbErr.HasTag(ShouldReconnect))
+	assert.False(t, edbErr.HasTag(ShouldRetry))
+}
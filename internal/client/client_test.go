@@ -19,8 +19,12 @@ package edgedb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
@@ -105,6 +109,180 @@ func TestConnectClientZeroConcurrency(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDerivedClientsSharePool(t *testing.T) {
+	o := opts
+	o.Concurrency = 1
+
+	ctx := context.Background()
+	p, err := CreateClient(ctx, o)
+	require.NoError(t, err)
+
+	// Derive before connecting so that the pool is created lazily
+	// by the derived client.
+	derived := p.
+		WithTxOptions(NewTxOptions()).
+		WithRetryOptions(NewRetryOptions()).
+		WithGlobals(map[string]interface{}{"x": "y"})
+	require.NoError(t, derived.EnsureConnected(ctx))
+
+	assert.Same(t, p.pool, derived.pool)
+	assert.NotNil(t, p.potentialConns)
+	assert.Empty(t, p.state, "deriving must not modify the parent client")
+
+	var result int64
+	err = p.QuerySingle(ctx, "SELECT 1", &result)
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Close())
+	assert.Error(t, derived.Close(), "derived clients share closed state")
+}
+
+func TestWithDatabase(t *testing.T) {
+	ctx := context.Background()
+	dbName := fmt.Sprintf("test%v", rand.Intn(10_000))
+	err := client.Execute(ctx, "CREATE DATABASE "+dbName)
+	require.NoError(t, err)
+
+	p, err := CreateClient(ctx, opts)
+	require.NoError(t, err)
+
+	other, err := p.WithDatabase(dbName)
+	require.NoError(t, err)
+	assert.NotSame(t, p.pool, other.pool)
+
+	var result string
+	err = other.QuerySingle(ctx, "SELECT sys::get_current_database()", &result)
+	require.NoError(t, err)
+	assert.Equal(t, dbName, result)
+
+	err = p.QuerySingle(ctx, "SELECT sys::get_current_database()", &result)
+	require.NoError(t, err)
+	assert.Equal(t, opts.Database, result)
+
+	// Closing the derived client does not close the original client.
+	require.NoError(t, other.Close())
+	err = p.QuerySingle(ctx, "SELECT sys::get_current_database()", &result)
+	assert.NoError(t, err)
+
+	require.NoError(t, p.Close())
+	_, err = p.WithDatabase(dbName)
+	assert.EqualError(t, err, "edgedb.InterfaceError: client closed")
+
+	_, err = client.WithDatabase("")
+	assert.EqualError(t, err,
+		`edgedb.ConfigurationError: invalid database name: ""`)
+}
+
+func TestEagerConnect(t *testing.T) {
+	ctx := context.Background()
+	o := Options{
+		Host:               "127.0.0.1",
+		Port:               1,
+		WaitUntilAvailable: time.Nanosecond,
+		TLSOptions:         TLSOptions{SecurityMode: TLSModeInsecure},
+	}
+
+	// Clients connect lazily by default.
+	p, err := CreateClient(ctx, o)
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+
+	o.EagerConnect = true
+	_, err = CreateClient(ctx, o)
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr), err)
+	assert.True(t, edbErr.Category(ClientConnectionError), err)
+
+	o.Host = opts.Host
+	o.Port = opts.Port
+	o.User = opts.User
+	o.Password = opts.Password
+	o.Database = opts.Database
+	o.TLSOptions = opts.TLSOptions
+	p, err = CreateClient(ctx, o)
+	require.NoError(t, err)
+	assert.NotNil(t, p.potentialConns)
+	require.NoError(t, p.Close())
+}
+
+func TestAcquireIsFIFO(t *testing.T) {
+	False := false
+	p := &Client{
+		isClosed:      &False,
+		isClosedMutex: &sync.RWMutex{},
+		pool:          newPool(1),
+	}
+	// All of the pool's capacity is in use.
+	p.potentialConns = make(chan struct{}, 1)
+
+	ctx := context.Background()
+	results := make(chan *transactableConn, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			conn, err := p.acquire(ctx)
+			assert.NoError(t, err)
+			results <- conn
+		}()
+
+		// Wait for the caller to queue before starting the next one.
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&p.waiters) == int64(i+1)
+		}, time.Second, time.Millisecond)
+	}
+
+	conns := make([]*transactableConn, 3)
+	for i := range conns {
+		conns[i] = &transactableConn{reconnectingConn: &reconnectingConn{}}
+	}
+	for _, conn := range conns {
+		conn := conn
+		p.freeConns <- func() *transactableConn { return conn }
+		assert.Same(t, conn, <-results)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := p.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Regexp(t, `^edgedb: context deadline exceeded after waiting `+
+		`\d+ms for a connection \(concurrency: 1, waiting: 1\)$`, err)
+}
+
+func TestWaitErrorUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	p := &Client{pool: newPool(1), cfg: &connConfig{clock: clock}}
+
+	start := clock.Now()
+	clock.Advance(1500 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualError(t, p.waitError(ctx, start), "edgedb: context canceled "+
+		"after waiting 1.5s for a connection (concurrency: 1, waiting: 0)")
+}
+
+func TestCreateClientInvalidFieldMatch(t *testing.T) {
+	o := opts
+	o.FieldMatch = FieldMatch(-1)
+
+	_, err := CreateClient(context.Background(), o)
+	require.EqualError(t, err,
+		"edgedb.ConfigurationError: invalid FieldMatch: -1")
+
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr))
+	assert.True(t, edbErr.Category(ConfigurationError))
+}
+
+func TestCreateClientInvalidMissingField(t *testing.T) {
+	o := opts
+	o.MissingField = MissingFieldPolicy(-1)
+
+	_, err := CreateClient(context.Background(), o)
+	require.EqualError(t, err,
+		"edgedb.ConfigurationError: invalid MissingFieldPolicy: -1")
+}
+
 func TestCloseClientConcurently(t *testing.T) {
 	ctx := context.Background()
 	p, err := CreateClient(ctx, opts)
@@ -0,0 +1,90 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import "time"
+
+// Clock is the source of time that a client uses to schedule retry backoff,
+// reconnect attempts, keepalive checks, idle timeouts and its circuit
+// breaker. It must be safe for concurrent use. Tests and simulations can
+// provide a Clock that they advance manually.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep pauses the current goroutine for at least d.
+	Sleep(d time.Duration)
+
+	// NewTimer returns a Timer that sends the current time on its
+	// channel after at least d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event returned by Clock.NewTimer.
+type Timer interface {
+	// C returns the channel that the time is sent on when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// has already fired or been stopped.
+	Stop() bool
+}
+
+// Rand is the source of randomness that a client uses to add jitter to
+// retry backoff and reconnect attempts. It must be safe for concurrent use.
+type Rand interface {
+	// Intn returns a random int in [0, n).
+	Intn(n int) int
+
+	// Float64 returns a random float64 in [0.0, 1.0).
+	Float64() float64
+}
+
+// systemClock is the Clock used if Options.Clock is nil.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct{ timer *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.timer.C }
+
+func (t systemTimer) Stop() bool { return t.timer.Stop() }
+
+// timeSource returns the configured Clock or the system clock.
+func (c *connConfig) timeSource() Clock {
+	if c == nil || c.clock == nil {
+		return systemClock{}
+	}
+
+	return c.clock
+}
+
+// randSource returns the configured Rand or the default Rand.
+func (c *connConfig) randSource() Rand {
+	if c == nil || c.rand == nil {
+		return rnd
+	}
+
+	return c.rand
+}
@@ -0,0 +1,139 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock that only moves when it is advanced.
+type fakeClock struct {
+	mx     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) { c.Advance(d) }
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and fires the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+
+		if t.fire() {
+			t.c <- c.now
+		}
+	}
+	c.timers = pending
+}
+
+type fakeTimer struct {
+	mx      sync.Mutex
+	at      time.Time
+	c       chan time.Time
+	stopped bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// fire marks the timer as done and returns false if it was stopped.
+func (t *fakeTimer) fire() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	active := !t.stopped
+	t.stopped = true
+	return active
+}
+
+func (t *fakeTimer) Stop() bool { return t.fire() }
+
+type fixedRand float64
+
+func (r fixedRand) Intn(n int) int { return int(float64(r) * float64(n)) }
+
+func (r fixedRand) Float64() float64 { return float64(r) }
+
+func TestRetryRuleDelay(t *testing.T) {
+	rule := NewRetryRule()
+	assert.Equal(t, 100*time.Millisecond, rule.delay(0, fixedRand(0)))
+	assert.Equal(t, 250*time.Millisecond, rule.delay(1, fixedRand(0.5)))
+	assert.Equal(t, 400*time.Millisecond, rule.delay(2, fixedRand(0)))
+
+	rule = rule.WithBackoff(func(n int) time.Duration {
+		return time.Duration(n) * time.Second
+	})
+	assert.Equal(t, 2*time.Second, rule.delay(2, fixedRand(0.5)))
+}
+
+func TestIdleKeepAliveUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	p := &Client{pool: newPool(1), cfg: &connConfig{clock: clock}}
+	p.potentialConns = make(chan struct{}, 1)
+
+	// The connection is not connected so the keepalive check fails.
+	conn := &transactableConn{reconnectingConn: &reconnectingConn{}}
+	cancel := make(chan struct{}, 1)
+	connChan := make(chan *transactableConn, 1)
+	go p.idle(conn, 0, time.Hour, cancel, connChan)
+
+	require.Eventually(t, func() bool {
+		clock.mx.Lock()
+		defer clock.mx.Unlock()
+		return len(clock.timers) == 1
+	}, time.Second, time.Millisecond)
+
+	select {
+	case <-p.potentialConns:
+		require.FailNow(t, "the connection was checked before it was due")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	assert.Nil(t, <-connChan)
+	<-p.potentialConns
+}
@@ -0,0 +1,230 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"encoding/json"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// Conn is a single connection that is not part of a connection pool. It
+// gives tools like REPLs, migration tools and protocol debuggers control over
+// each step of running a query: Parse describes a query without running it
+// and returns the type descriptors the server sent, the query methods run it.
+// Most programs should use a Client instead.
+//
+// Unlike a Client a Conn does not reconnect and does not retry queries. If
+// the connection is lost its methods return errors and a new Conn must be
+// created. Transaction commands like START TRANSACTION can be run with
+// Execute. A Conn is not safe for concurrent use.
+type Conn struct {
+	conn      *protocolConnection
+	queryOpts queryOptions
+	state     map[string]interface{}
+}
+
+// ConnectOne opens a single connection using opts.
+// See CreateClient for how opts are resolved and ParseDSN for connecting with
+// a DSN. Options that only apply to a connection pool are ignored.
+func ConnectOne(ctx context.Context, opts Options) (*Conn, error) { // nolint:gocritic,lll
+	cfg, caches, err := resolveOptions("", &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := reconnectingConn{cfg: cfg, cacheCollection: caches}
+	if err := c.reconnect(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return &Conn{
+		conn: c.conn,
+		queryOpts: queryOptions{
+			warningHandler: LogWarnings,
+			settings:       NewQueryOptions(),
+			trace:          opts.Trace,
+		},
+		state: make(map[string]interface{}),
+	}, nil
+}
+
+// ProtocolVersion returns the protocol version
+// that was negotiated with the server.
+func (c *Conn) ProtocolVersion() (major, minor uint16) {
+	v := c.conn.protocolVersion
+	return v.Major, v.Minor
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.close()
+}
+
+func (c *Conn) capabilities1pX() uint64 {
+	return txCapabilities
+}
+
+func (c *Conn) granularFlow(ctx context.Context, q *query) error {
+	return c.conn.granularFlow(ctx, q)
+}
+
+// Description is a query's description returned by Conn.Parse.
+type Description struct {
+	// Cardinality is the result cardinality reported by the server,
+	// one of NoResult, AtMostOne, One, Many or AtLeastOne.
+	Cardinality string
+
+	// Capabilities are the capabilities the query requires. Servers using
+	// protocol versions before 1.0 do not report them.
+	Capabilities Capability
+
+	// In describes the query's arguments and Out describes its results.
+	In  ResultType
+	Out ResultType
+
+	// InID and OutID are the IDs of the argument and result type
+	// descriptors.
+	InID  types.UUID
+	OutID types.UUID
+
+	// InDescriptor and OutDescriptor are the argument and result type
+	// descriptors encoded as they were sent by the server. Their format
+	// depends on the protocol version, see Conn.ProtocolVersion.
+	InDescriptor  []byte
+	OutDescriptor []byte
+}
+
+func newDescription(q *query, desc *CommandDescription) *Description {
+	return &Description{
+		Cardinality:   q.descIDs.card.String(),
+		Capabilities:  Capability(q.reportedCapabilities),
+		In:            resultType(desc.In),
+		Out:           resultType(desc.Out),
+		InID:          desc.In.ID,
+		OutID:         desc.Out.ID,
+		InDescriptor:  desc.inData,
+		OutDescriptor: desc.outData,
+	}
+}
+
+func newDescriptionV2(q *query, desc *CommandDescriptionV2) *Description {
+	return &Description{
+		Cardinality:   q.descIDs.card.String(),
+		Capabilities:  Capability(q.reportedCapabilities),
+		In:            resultTypeV2(desc.In),
+		Out:           resultTypeV2(desc.Out),
+		InID:          desc.In.ID,
+		OutID:         desc.Out.ID,
+		InDescriptor:  desc.inData,
+		OutDescriptor: desc.outData,
+	}
+}
+
+// Parse asks the server to describe cmd without running it. The returned
+// descriptors are cached, so running cmd with one of the query methods
+// afterwards does not describe it again.
+func (c *Conn) Parse(ctx context.Context, cmd string) (*Description, error) {
+	q := &query{
+		method:       "Parse",
+		cmd:          cmd,
+		fmt:          Binary,
+		expCard:      Many,
+		capabilities: c.capabilities1pX(),
+		state:        c.state,
+	}
+	q.setOptions(c.queryOpts)
+
+	return c.conn.parseFlow(ctx, q)
+}
+
+// Execute an EdgeQL command (or commands).
+func (c *Conn) Execute(
+	ctx context.Context,
+	cmd string,
+	args ...interface{},
+) error {
+	q, err := newQuery("Execute", cmd, args, c.capabilities1pX(), c.state, nil)
+	if err != nil {
+		return err
+	}
+	q.setOptions(c.queryOpts)
+
+	return q.handleWarnings(c.conn.scriptFlow(ctx, q))
+}
+
+// Query runs a query and returns the results.
+func (c *Conn) Query(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, c, "Query", cmd, out, args, c.state, c.queryOpts)
+}
+
+// QuerySingle runs a singleton-returning query and returns its element.
+// If the query executes successfully but doesn't return a result
+// a NoDataError is returned. If the out argument is an optional type the out
+// argument will be set to missing instead of returning a NoDataError.
+func (c *Conn) QuerySingle(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, c, "QuerySingle", cmd, out, args, c.state, c.queryOpts)
+}
+
+// QueryJSON runs a query and return the results as JSON.
+func (c *Conn) QueryJSON(
+	ctx context.Context,
+	cmd string,
+	out *[]byte,
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, c, "QueryJSON", cmd, out, args, c.state, c.queryOpts)
+}
+
+// QuerySingleJSON runs a singleton-returning query.
+// If the query executes successfully but doesn't have a result
+// a NoDataError is returned.
+func (c *Conn) QuerySingleJSON(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, c, "QuerySingleJSON", cmd, out, args, c.state, c.queryOpts)
+}
+
+// QueryJSONElements runs a query and returns each result element as a
+// separate JSON document.
+func (c *Conn) QueryJSONElements(
+	ctx context.Context,
+	cmd string,
+	out *[]json.RawMessage,
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, c, "QueryJSONElements", cmd, out, args, c.state, c.queryOpts)
+}
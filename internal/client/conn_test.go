@@ -0,0 +1,55 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectOne(t *testing.T) {
+	ctx := context.Background()
+	conn, err := ConnectOne(ctx, opts)
+	require.NoError(t, err)
+
+	major, _ := conn.ProtocolVersion()
+	assert.Equal(t, TestClientProtocolVersion().Major, major)
+
+	desc, err := conn.Parse(ctx, "SELECT <int64>$0 + 1")
+	require.NoError(t, err)
+	assert.Equal(t, "One", desc.Cardinality)
+	assert.Equal(t, "std::int64", desc.Out.Name)
+	assert.Equal(t, codecs.Int64ID, desc.OutID)
+	assert.NotEmpty(t, desc.InDescriptor)
+	assert.NotEmpty(t, desc.OutDescriptor)
+
+	var result int64
+	err = conn.QuerySingle(ctx, "SELECT <int64>$0 + 1", &result, int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result)
+
+	require.NoError(t, conn.Execute(ctx, "START TRANSACTION"))
+	require.NoError(t, conn.Execute(ctx, "ROLLBACK"))
+
+	require.NoError(t, conn.Close())
+	err = conn.Execute(ctx, "SELECT 1")
+	assert.Error(t, err, "the connection is closed")
+}
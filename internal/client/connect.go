@@ -23,7 +23,6 @@ import (
 
 	"github.com/sebastiean/edgedb-go/internal"
 	"github.com/sebastiean/edgedb-go/internal/buff"
-	"github.com/xdg/scram"
 	"golang.org/x/exp/slices"
 )
 
@@ -116,13 +115,13 @@ func (c *protocolConnection) connect(r *buff.Reader, cfg *connConfig) error {
 				continue
 			}
 
-			// skip supported SASL methods
 			n := int(r.PopUint32()) // method count
+			methods := make([]string, n)
 			for i := 0; i < n; i++ {
-				r.PopBytes()
+				methods[i] = r.PopString()
 			}
 
-			if e := c.authenticate(r, cfg); e != nil {
+			if e := c.authenticate(r, cfg, methods); e != nil {
 				return e
 			}
 
@@ -149,97 +148,82 @@ func (c *protocolConnection) connect(r *buff.Reader, cfg *connConfig) error {
 	return err
 }
 
+// authentication statuses
+const (
+	authOK           = 0
+	authSASLContinue = 0xb
+	authSASLFinal    = 0xc
+)
+
 func (c *protocolConnection) authenticate(
 	r *buff.Reader,
 	cfg *connConfig,
+	methods []string,
 ) error {
-	client, err := scram.SHA256.NewClient(cfg.user, cfg.password, "")
+	method, mechanism, err := newSASLMechanism(methods, cfg)
 	if err != nil {
-		return &authenticationError{msg: err.Error()}
+		return err
 	}
 
-	conv := client.NewConversation()
-	scramMsg, err := conv.Step("")
+	data, err := mechanism.Start()
 	if err != nil {
 		return &authenticationError{msg: err.Error()}
 	}
 
 	w := buff.NewWriter(c.writeMemory[:0])
 	w.BeginMessage(uint8(AuthenticationSASLInitialResponse))
-	w.PushString("SCRAM-SHA-256")
-	w.PushString(scramMsg)
+	w.PushString(method)
+	w.PushBytes(data)
 	w.EndMessage()
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
-		return e
-	}
-
-	done := buff.NewSignal()
-
-	for r.Next(done.Chan) {
-		switch Message(r.MsgType) {
-		case Authentication:
-			authStatus := r.PopUint32()
-			if authStatus != 0xb {
-				// the connection will not be usable after this x_x
-				return &authenticationError{msg: fmt.Sprintf(
-					"unexpected authentication status: 0x%x", authStatus,
-				)}
-			}
-
-			scramRcv := r.PopString()
-			scramMsg, err = conv.Step(scramRcv)
-			if err != nil {
-				// the connection will not be usable after this x_x
-				return &authenticationError{msg: err.Error()}
-			}
-
-			done.Signal()
-		case ErrorResponse:
-			err = decodeErrorResponseMsg(r, "")
-		default:
-			if e := c.fallThrough(r); e != nil {
-				// the connection will not be usable after this x_x
-				return e
-			}
+	for {
+		if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+			return e
 		}
-	}
-
-	if r.Err != nil {
-		return r.Err
-	}
 
-	if err != nil {
-		return err
-	}
+		challenge, ready, err := c.authenticationRound(r, mechanism)
+		if err != nil || ready {
+			return err
+		}
 
-	w = buff.NewWriter(c.writeMemory[:0])
-	w.BeginMessage(uint8(AuthenticationSASLResponse))
-	w.PushString(scramMsg)
-	w.EndMessage()
+		data, err = mechanism.Next(challenge)
+		if err != nil {
+			// the connection will not be usable after this x_x
+			return &authenticationError{msg: err.Error()}
+		}
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
-		return e
+		w = buff.NewWriter(c.writeMemory[:0])
+		w.BeginMessage(uint8(AuthenticationSASLResponse))
+		w.PushBytes(data)
+		w.EndMessage()
 	}
+}
 
-	done = buff.NewSignal()
+// authenticationRound reads the server's messages until it either sends a
+// SASL challenge or is ready for commands.
+func (c *protocolConnection) authenticationRound(
+	r *buff.Reader,
+	mechanism SASLMechanism,
+) (challenge []byte, ready bool, err error) {
+	done := buff.NewSignal()
 
 	for r.Next(done.Chan) {
 		switch Message(r.MsgType) {
 		case Authentication:
 			authStatus := r.PopUint32()
 			switch authStatus {
-			case 0:
-			case 0xc:
-				scramRcv := r.PopString()
-				_, e := conv.Step(scramRcv)
-				if e != nil {
+			case authOK:
+			case authSASLContinue:
+				challenge = r.PopBytes()
+				done.Signal()
+			case authSASLFinal:
+				if e := mechanism.Final(r.PopBytes()); e != nil {
 					// the connection will not be usable after this x_x
-					return &authenticationError{msg: e.Error()}
+					return nil, false, &authenticationError{msg: e.Error()}
 				}
 			default:
 				// the connection will not be usable after this x_x
-				return &authenticationError{msg: fmt.Sprintf(
+				return nil, false, &authenticationError{msg: fmt.Sprintf(
 					"unexpected authentication status: 0x%x", authStatus,
 				)}
 			}
@@ -248,26 +232,28 @@ func (c *protocolConnection) authenticate(
 		case ReadyForCommand:
 			ignoreHeaders(r)
 			r.Discard(1) // transaction state
+			ready = true
 			done.Signal()
 		case StateDataDescription:
 			if e := c.decodeStateDataDescription(r); e != nil {
 				err = wrapAll(err, e)
 			}
 		case ErrorResponse:
-			err = wrapAll(decodeErrorResponseMsg(r, ""))
+			err = wrapAll(err, decodeErrorResponseMsg(r, ""))
+			done.Signal()
 		default:
 			if e := c.fallThrough(r); e != nil {
 				// the connection will not be usable after this x_x
-				return e
+				return nil, false, e
 			}
 		}
 	}
 
 	if r.Err != nil {
-		return r.Err
+		return nil, false, r.Err
 	}
 
-	return err
+	return challenge, ready, err
 }
 
 func (c *protocolConnection) terminate() error {
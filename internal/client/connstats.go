@@ -0,0 +1,326 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// ConnStats are protocol statistics for one of a client's connections. They
+// help to diagnose how queries use the network, for example why queries are
+// parsed before they are executed instead of being executed optimistically.
+// A connection that is reestablished after a network error keeps its
+// statistics.
+type ConnStats struct {
+	// Addr is the address the connection is connected to.
+	Addr string
+
+	// MessagesSent is the number of protocol messages sent to the server
+	// by message name, e.g. Parse or Execute.
+	MessagesSent map[string]uint64
+
+	// MessagesReceived is the number of protocol messages received from
+	// the server by message name, e.g. CommandDataDescription or Data.
+	MessagesReceived map[string]uint64
+
+	// BytesSent is the number of bytes sent to the server,
+	// not including TLS framing.
+	BytesSent uint64
+
+	// BytesReceived is the number of bytes received from the server,
+	// not including TLS framing.
+	BytesReceived uint64
+
+	// Prepares is the number of Parse messages sent.
+	Prepares uint64
+
+	// Queries is the number of queries run on the connection.
+	Queries uint64
+
+	// OptimisticExecutes is the number of queries that were executed
+	// without being parsed first because their type descriptors were
+	// cached.
+	OptimisticExecutes uint64
+
+	// OptimisticRetries is the number of optimistic executes that had to
+	// be parsed and executed again because the server's type descriptors
+	// did not match the cached ones.
+	OptimisticRetries uint64
+}
+
+// OptimisticHitRate returns the fraction of queries that were executed
+// optimistically without being parsed. It is 0 if no queries were run.
+func (s ConnStats) OptimisticHitRate() float64 {
+	if s.Queries == 0 {
+		return 0
+	}
+
+	hits := s.OptimisticExecutes - s.OptimisticRetries
+	return float64(hits) / float64(s.Queries)
+}
+
+// ConnStats returns the statistics of each open connection in the client's
+// pool.
+func (p *Client) ConnStats() []ConnStats {
+	return p.stats.snapshot()
+}
+
+// statsRegistry holds the statistics of a pool's connections.
+// A nil *statsRegistry records nothing.
+type statsRegistry struct {
+	mu    sync.Mutex
+	conns map[*connStats]struct{}
+}
+
+// add returns new statistics for a connection in the pool.
+func (r *statsRegistry) add() *connStats {
+	stats := &connStats{
+		sent:     msgCounter{counts: map[Message]uint64{}},
+		received: msgCounter{counts: map[Message]uint64{}},
+	}
+
+	if r == nil {
+		return stats
+	}
+
+	stats.registry = r
+	r.mu.Lock()
+	if r.conns == nil {
+		r.conns = map[*connStats]struct{}{}
+	}
+	r.conns[stats] = struct{}{}
+	r.mu.Unlock()
+
+	return stats
+}
+
+// remove removes the statistics of a closed connection.
+func (r *statsRegistry) remove(stats *connStats) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.conns, stats)
+	r.mu.Unlock()
+}
+
+// snapshot returns the statistics of the connections that are not closed.
+func (r *statsRegistry) snapshot() []ConnStats {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]ConnStats, 0, len(r.conns))
+	for stats := range r.conns {
+		result = append(result, stats.snapshot())
+	}
+
+	return result
+}
+
+// connStats records the statistics of a connection.
+// A nil *connStats records nothing.
+type connStats struct {
+	mu         sync.Mutex
+	registry   *statsRegistry
+	addr       string
+	sent       msgCounter
+	received   msgCounter
+	queries    uint64
+	optimistic uint64
+	retries    uint64
+}
+
+func (s *connStats) update(fn func()) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	fn()
+	s.mu.Unlock()
+}
+
+// connected records the address that the connection connected to. Only the
+// totals are kept from an earlier connection, a message that was cut off
+// when it was lost is not continued on the new one.
+func (s *connStats) connected(addr string) {
+	s.update(func() {
+		s.addr = addr
+		s.sent.reset()
+		s.received.reset()
+	})
+}
+
+// wrote records data written to the server.
+func (s *connStats) wrote(p []byte) {
+	s.update(func() { s.sent.scan(p) })
+}
+
+// read records data read from the server.
+func (s *connStats) read(p []byte) {
+	s.update(func() { s.received.scan(p) })
+}
+
+// query records that a query was run,
+// optimistic is true if it was executed without being parsed.
+func (s *connStats) query(optimistic bool) {
+	s.update(func() {
+		s.queries++
+		if optimistic {
+			s.optimistic++
+		}
+	})
+}
+
+// retry records that an optimistic execute was parsed and executed again.
+func (s *connStats) retry() {
+	s.update(func() { s.retries++ })
+}
+
+// close removes the statistics of a closed connection from its registry.
+func (s *connStats) close() {
+	if s == nil {
+		return
+	}
+
+	s.registry.remove(s)
+}
+
+func (s *connStats) snapshot() ConnStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return ConnStats{
+		Addr:               s.addr,
+		MessagesSent:       s.sent.named(clientMessageNames),
+		MessagesReceived:   s.received.named(serverMessageNames),
+		BytesSent:          s.sent.bytes,
+		BytesReceived:      s.received.bytes,
+		Prepares:           s.sent.counts[Parse],
+		Queries:            s.queries,
+		OptimisticExecutes: s.optimistic,
+		OptimisticRetries:  s.retries,
+	}
+}
+
+// msgCounter counts the protocol messages in a stream of bytes.
+// Messages may be split across calls to scan.
+type msgCounter struct {
+	counts map[Message]uint64
+	bytes  uint64
+
+	// header holds the type and length of the next message
+	// until all five bytes have been scanned.
+	header    [5]byte
+	headerLen int
+
+	// remaining is the number of bytes left in the current message.
+	remaining int
+}
+
+func (c *msgCounter) scan(p []byte) {
+	c.bytes += uint64(len(p))
+
+	for len(p) > 0 {
+		if c.remaining > 0 {
+			n := c.remaining
+			if n > len(p) {
+				n = len(p)
+			}
+			c.remaining -= n
+			p = p[n:]
+			continue
+		}
+
+		n := copy(c.header[c.headerLen:], p)
+		c.headerLen += n
+		p = p[n:]
+		if c.headerLen < len(c.header) {
+			return
+		}
+
+		c.counts[Message(c.header[0])]++
+		// The message length includes itself but not the message type.
+		c.remaining = int(binary.BigEndian.Uint32(c.header[1:])) - 4
+		c.headerLen = 0
+	}
+}
+
+// reset discards a partially scanned message.
+func (c *msgCounter) reset() {
+	c.headerLen = 0
+	c.remaining = 0
+}
+
+func (c *msgCounter) named(names map[Message]string) map[string]uint64 {
+	result := make(map[string]uint64, len(c.counts))
+	for typ, count := range c.counts {
+		name, ok := names[typ]
+		if !ok {
+			name = fmt.Sprintf("0x%02x", uint8(typ))
+		}
+
+		result[name] += count
+	}
+
+	return result
+}
+
+var (
+	clientMessageNames = map[Message]string{
+		AuthenticationSASLInitialResponse: "AuthenticationSASLInitialResponse",
+		AuthenticationSASLResponse:        "AuthenticationSASLResponse",
+		ClientHandshake:                   "ClientHandshake",
+		DescribeStatement:                 "DescribeStatement",
+		Dump:                              "Dump",
+		Execute0pX:                        "Execute",
+		ExecuteScript:                     "ExecuteScript",
+		Flush:                             "Flush",
+		Execute1pX:                        "Execute",
+		Parse:                             "Parse",
+		Restore:                           "Restore",
+		RestoreBlock:                      "RestoreBlock",
+		RestoreEOF:                        "RestoreEOF",
+		Sync:                              "Sync",
+		Terminate:                         "Terminate",
+	}
+
+	serverMessageNames = map[Message]string{
+		Authentication:         "Authentication",
+		CommandComplete:        "CommandComplete",
+		CommandDataDescription: "CommandDataDescription",
+		Data:                   "Data",
+		DumpBlock:              "DumpBlock",
+		DumpHeader:             "DumpHeader",
+		ErrorResponse:          "ErrorResponse",
+		LogMessage:             "LogMessage",
+		ParameterStatus:        "ParameterStatus",
+		ParseComplete:          "ParseComplete",
+		ReadyForCommand:        "ReadyForCommand",
+		RestoreReady:           "RestoreReady",
+		ServerHandshake:        "ServerHandshake",
+		ServerKeyData:          "ServerKeyData",
+		StateDataDescription:   "StateDataDescription",
+	}
+)
@@ -0,0 +1,144 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnStats(t *testing.T) {
+	registry := &statsRegistry{}
+	stats := registry.add()
+	stats.connected("localhost:5656")
+
+	client, server := net.Pipe()
+	defer server.Close() // nolint:errcheck
+	s := &autoClosingSocket{conn: client, stats: stats}
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(uint8(Parse))
+	w.PushString("SELECT 1")
+	w.EndMessage()
+	w.BeginMessage(uint8(Sync))
+	w.EndMessage()
+	request := w.Unwrap()
+
+	w = buff.NewWriter(nil)
+	w.BeginMessage(uint8(CommandDataDescription))
+	w.PushUint16(0)
+	w.EndMessage()
+	w.BeginMessage(uint8(ReadyForCommand))
+	w.EndMessage()
+	reply := w.Unwrap()
+
+	go func() { _, _ = server.Read(make([]byte, len(request))) }()
+	require.NoError(t, s.WriteAll(request))
+
+	go func() {
+		// Messages are counted even if their headers are split
+		// across reads.
+		_, _ = server.Write(reply[:3])
+		_, _ = server.Write(reply[3:])
+	}()
+
+	received := make([]byte, len(reply))
+	for n := 0; n < len(received); {
+		i, err := s.Read(received[n:])
+		require.NoError(t, err)
+		n += i
+	}
+
+	stats.query(false)
+	stats.query(true)
+	stats.query(true)
+	stats.retry()
+
+	snapshot := registry.snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, ConnStats{
+		Addr:         "localhost:5656",
+		MessagesSent: map[string]uint64{"Parse": 1, "Sync": 1},
+		MessagesReceived: map[string]uint64{
+			"CommandDataDescription": 1,
+			"ReadyForCommand":        1,
+		},
+		BytesSent:          uint64(len(request)),
+		BytesReceived:      uint64(len(reply)),
+		Prepares:           1,
+		Queries:            3,
+		OptimisticExecutes: 2,
+		OptimisticRetries:  1,
+	}, snapshot[0])
+	assert.InDelta(t, 1.0/3, snapshot[0].OptimisticHitRate(), 0.0001)
+
+	stats.close()
+	assert.Empty(t, registry.conns, "closed connections are removed")
+	assert.Empty(t, registry.snapshot())
+}
+
+func TestMsgCounterSkipsMessageBodies(t *testing.T) {
+	c := msgCounter{counts: map[Message]uint64{}}
+
+	// A Data message whose body looks like message headers.
+	c.scan([]byte{byte(Data), 0, 0, 0, 9})
+	c.scan([]byte{byte(Data), 0, 0})
+	c.scan([]byte{0, 4, byte(ReadyForCommand), 0, 0, 0, 4})
+
+	assert.Equal(t, map[Message]uint64{Data: 1, ReadyForCommand: 1}, c.counts)
+	assert.Equal(t, uint64(15), c.bytes)
+}
+
+func TestConnStatsReconnect(t *testing.T) {
+	stats := (&statsRegistry{}).add()
+	stats.connected("localhost:5656")
+
+	// The connection is lost in the middle of a Data message.
+	stats.read([]byte{byte(Data), 0, 0, 0, 9, 1})
+	stats.read([]byte{byte(Data), 0})
+
+	stats.connected("localhost:5657")
+	stats.read([]byte{byte(ReadyForCommand), 0, 0, 0, 4})
+
+	snapshot := stats.snapshot()
+	assert.Equal(t, "localhost:5657", snapshot.Addr)
+	assert.Equal(t, map[string]uint64{
+		"Data":            1,
+		"ReadyForCommand": 1,
+	}, snapshot.MessagesReceived)
+	assert.Equal(t, uint64(13), snapshot.BytesReceived)
+}
+
+func TestNilConnStats(t *testing.T) {
+	var stats *connStats
+	stats.connected("localhost:5656")
+	stats.wrote([]byte{byte(Sync), 0, 0, 0, 4})
+	stats.query(true)
+	stats.close()
+
+	var registry *statsRegistry
+	assert.NotNil(t, registry.add())
+	assert.Nil(t, registry.snapshot())
+}
+
+func TestOptimisticHitRateWithoutQueries(t *testing.T) {
+	assert.Equal(t, 0.0, ConnStats{}.OptimisticHitRate())
+}
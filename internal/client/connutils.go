@@ -65,18 +65,38 @@ type connConfig struct {
 	waitUntilAvailable time.Duration
 	tlsCAData          []byte
 	tlsSecurity        string
+	tlsBaseConfig      *tls.Config
 	serverSettings     *snc.ServerSettings
 	secretKey          string
+	authProvider       AuthProvider
+	breaker            *circuitBreaker
+	maxMessageSize     int
+	keepAliveInterval  time.Duration
+	clock              Clock
+	rand               Rand
+
+	// hosts chooses the address of new connections if there is more than
+	// one host. addr is the first host.
+	hosts *hostBalancer
+
+	// replicas chooses the address of read only connections.
+	// See Client.WithReadOnly.
+	replicas *hostBalancer
 }
 
 func (c *connConfig) tlsConfig() (*tls.Config, error) {
-	var roots *x509.CertPool
+	tlsConfig := &tls.Config{}
+	if c.tlsBaseConfig != nil {
+		tlsConfig = c.tlsBaseConfig.Clone()
+	}
+
+	roots := tlsConfig.RootCAs
 	if len(c.tlsCAData) != 0 {
 		roots = x509.NewCertPool()
 		if ok := roots.AppendCertsFromPEM(c.tlsCAData); !ok {
 			return nil, errors.New("invalid certificate data")
 		}
-	} else {
+	} else if roots == nil {
 		var err error
 		roots, err = getSystemCertPool()
 		if err != nil {
@@ -84,10 +104,8 @@ func (c *connConfig) tlsConfig() (*tls.Config, error) {
 		}
 	}
 
-	tlsConfig := &tls.Config{
-		RootCAs:    roots,
-		NextProtos: []string{"edgedb-binary"},
-	}
+	tlsConfig.RootCAs = roots
+	tlsConfig.NextProtos = []string{"edgedb-binary"}
 
 	switch c.tlsSecurity {
 	case "insecure_dev_mode", "insecure":
@@ -97,6 +115,7 @@ func (c *connConfig) tlsConfig() (*tls.Config, error) {
 		// replacing. This will not disable VerifyConnection.
 		tlsConfig.InsecureSkipVerify = true
 
+		verify := tlsConfig.VerifyConnection
 		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
 			opts := x509.VerifyOptions{
 				Intermediates: x509.NewCertPool(),
@@ -106,8 +125,16 @@ func (c *connConfig) tlsConfig() (*tls.Config, error) {
 				opts.Intermediates.AddCert(cert)
 			}
 			_, err := cs.PeerCertificates[0].Verify(opts)
-			return err
+			if err != nil || verify == nil {
+				return err
+			}
+
+			return verify(cs)
 		}
+	default:
+		// Certificates are always verified in strict and default mode,
+		// even if the base config skips verification.
+		tlsConfig.InsecureSkipVerify = false
 	}
 
 	return tlsConfig, nil
@@ -137,6 +164,9 @@ type configResolver struct {
 	profile            cfgVal // string
 	instance           cfgVal // string
 	org                cfgVal // string
+
+	// secrets looks up secrets that are not otherwise resolved.
+	secrets SecretProvider
 }
 
 func (r *configResolver) setInstance(val, source string) error {
@@ -923,13 +953,44 @@ func (r *configResolver) config(opts *Options) (*connConfig, error) {
 		}
 	}
 
+	target := r.secretTarget()
+	target.Host = host
+	target.Port = port
+	target.User = user
+	target.Database = database
+	if e := r.resolvePassword(target); e != nil {
+		return nil, e
+	}
+
 	password := ""
 	if r.password.val != nil {
 		password = r.password.val.(string)
 	}
 
+	addr := dialArgs{"tcp", fmt.Sprintf("%v:%v", host, port)}
+	var hosts *hostBalancer
+	if len(opts.Hosts) > 0 {
+		others, e := parseHosts(opts.Hosts, port)
+		if e != nil {
+			return nil, e
+		}
+		hosts = newHostBalancer(addr, others, opts.LoadBalancer)
+	}
+
+	var replicas *hostBalancer
+	if len(opts.ReadReplicas) > 0 {
+		addrs, e := parseHosts(opts.ReadReplicas, port)
+		if e != nil {
+			return nil, e
+		}
+		replicas = newHostBalancer(addrs[0], addrs[1:], opts.LoadBalancer)
+		replicas.fallback = &hostState{addr: addr, balancer: replicas}
+	}
+
 	return &connConfig{
-		addr:               dialArgs{"tcp", fmt.Sprintf("%v:%v", host, port)},
+		addr:               addr,
+		hosts:              hosts,
+		replicas:           replicas,
 		user:               user,
 		password:           password,
 		database:           database,
@@ -938,7 +999,14 @@ func (r *configResolver) config(opts *Options) (*connConfig, error) {
 		serverSettings:     r.serverSettings,
 		tlsCAData:          certData,
 		tlsSecurity:        tlsSecurity,
+		tlsBaseConfig:      opts.TLSOptions.Config,
 		secretKey:          secretKey,
+		authProvider:       opts.AuthProvider,
+		breaker:            newCircuitBreaker(opts.CircuitBreaker, opts.Clock),
+		maxMessageSize:     opts.MaxMessageSize,
+		keepAliveInterval:  opts.KeepAliveInterval,
+		clock:              opts.Clock,
+		rand:               opts.Rand,
 	}, nil
 }
 
@@ -979,7 +1047,10 @@ func newConfigResolver(
 	opts *Options,
 	paths *cfgPaths,
 ) (*configResolver, error) {
-	cfg := &configResolver{serverSettings: snc.NewServerSettings()}
+	cfg := &configResolver{
+		serverSettings: snc.NewServerSettings(),
+		secrets:        opts.SecretProvider,
+	}
 
 	var instance string
 	if !isDSNLike.MatchString(dsn) {
@@ -1433,6 +1504,10 @@ func (r *configResolver) parseCloudInstanceNameIntoConfig(
 		)
 	}
 
+	if err := r.resolveSecretKey(); err != nil {
+		return err
+	}
+
 	var secretKey string
 	if r.secretKey.val != nil {
 		secretKey = r.secretKey.val.(string)
@@ -19,6 +19,8 @@ package edgedb
 import (
 	"context"
 	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -807,3 +809,33 @@ func TestConnectRefusedUnixSocket(t *testing.T) {
 		err,
 	)
 }
+
+func TestTLSConfigUsesBaseConfig(t *testing.T) {
+	roots := x509.NewCertPool()
+	base := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS13}
+	cfg := connConfig{tlsBaseConfig: base, tlsSecurity: "strict"}
+
+	tlsConfig, err := cfg.tlsConfig()
+	require.NoError(t, err)
+	assert.NotSame(t, base, tlsConfig)
+	assert.Same(t, roots, tlsConfig.RootCAs)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.Equal(t, []string{"edgedb-binary"}, tlsConfig.NextProtos)
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, base.NextProtos, "the base config should not be modified")
+
+	cfg.tlsSecurity = "insecure"
+	tlsConfig, err = cfg.tlsConfig()
+	require.NoError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.False(t, base.InsecureSkipVerify)
+
+	base.InsecureSkipVerify = true
+	for _, mode := range []string{"strict", "default"} {
+		cfg.tlsSecurity = mode
+		tlsConfig, err = cfg.tlsConfig()
+		require.NoError(t, err)
+		assert.False(t, tlsConfig.InsecureSkipVerify,
+			"%v mode must verify certificates", mode)
+	}
+}
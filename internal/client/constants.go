@@ -29,6 +29,7 @@ var (
 	rnd       = snc.NewRand()
 
 	defaultConcurrency = max(4, runtime.NumCPU())
+	defaultCacheSize   = 1_000
 
 	protocolVersionMin  = protocolVersion0p13
 	protocolVersionMax  = protocolVersion2p0
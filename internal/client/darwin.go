@@ -21,8 +21,12 @@ package edgedb
 
 import (
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path"
+	"strings"
 	"syscall"
 )
 
@@ -47,3 +51,27 @@ func device(dir string) (int, error) {
 
 	return int(stat.Sys().(*syscall.Stat_t).Dev), nil
 }
+
+// errSecItemNotFound is the exit status of the security command
+// when the keychain item does not exist.
+const errSecItemNotFound = 44
+
+func keyringLookup(service, account string) (string, bool, error) {
+	out, err := exec.Command(
+		"security", "find-generic-password",
+		"-s", service,
+		"-a", account,
+		"-w",
+	).Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("keychain lookup failed: %w", err)
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), true, nil
+}
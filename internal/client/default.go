@@ -0,0 +1,132 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	defaultClient      *Client
+	defaultClientMutex sync.Mutex
+)
+
+// SetDefault sets the client used by the package level query functions.
+// Setting it to nil causes the next call to Default() to create a new client.
+func SetDefault(client *Client) {
+	defaultClientMutex.Lock()
+	defer defaultClientMutex.Unlock()
+
+	defaultClient = client
+}
+
+// Default returns the client used by the package level query functions.
+// If no client was set with SetDefault() a client is created on first use
+// with connection options from the environment or the current project,
+// see CreateClient(). Use Default() to access client methods that have no
+// package level equivalent, e.g. Default().Tx().
+func Default() (*Client, error) {
+	defaultClientMutex.Lock()
+	defer defaultClientMutex.Unlock()
+
+	if defaultClient != nil {
+		return defaultClient, nil
+	}
+
+	client, err := CreateClient(context.Background(), Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	defaultClient = client
+	return client, nil
+}
+
+// Execute an EdgeQL command (or commands) using the default client.
+// See Default().
+func Execute(ctx context.Context, cmd string, args ...interface{}) error {
+	client, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return client.Execute(ctx, cmd, args...)
+}
+
+// Query runs a query using the default client. See Default().
+func Query(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	client, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return client.Query(ctx, cmd, out, args...)
+}
+
+// QuerySingle runs a singleton-returning query using the default client.
+// See Default().
+func QuerySingle(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	client, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return client.QuerySingle(ctx, cmd, out, args...)
+}
+
+// QueryJSON runs a query using the default client and returns the results as
+// JSON. See Default().
+func QueryJSON(
+	ctx context.Context,
+	cmd string,
+	out *[]byte,
+	args ...interface{},
+) error {
+	client, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return client.QueryJSON(ctx, cmd, out, args...)
+}
+
+// QuerySingleJSON runs a singleton-returning query using the default client
+// and returns the result as JSON. See Default().
+func QuerySingleJSON(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) error {
+	client, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return client.QuerySingleJSON(ctx, cmd, out, args...)
+}
@@ -0,0 +1,277 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+)
+
+// persistKey identifies a query in a descriptor cache file. Unlike queryKey
+// it does not include the out type because the server's descriptors do not
+// depend on it.
+type persistKey struct {
+	Cmd     string      `json:"cmd"`
+	Fmt     Format      `json:"fmt"`
+	ExpCard Cardinality `json:"exp_card"`
+	Flags   uint64      `json:"flags"`
+}
+
+// persistedQuery is a query's type descriptors as they were sent by the
+// server.
+type persistedQuery struct {
+	persistKey
+	Card         Cardinality `json:"card"`
+	Capabilities uint64      `json:"capabilities"`
+	In           []byte      `json:"in"`
+	Out          []byte      `json:"out"`
+}
+
+type descriptorFile struct {
+	ProtocolVersion internal.ProtocolVersion `json:"protocol_version"`
+	Queries         []*persistedQuery        `json:"queries"`
+}
+
+// descriptorStore keeps the descriptors of the queries a client has run so
+// that they can be written to a file and loaded when the client is created
+// again. A nil *descriptorStore stores nothing.
+type descriptorStore struct {
+	path    string
+	size    int
+	mutex   sync.Mutex
+	version internal.ProtocolVersion
+	queries map[persistKey]*persistedQuery
+}
+
+// newDescriptorStore returns a store that is loaded from path. A missing or
+// invalid file results in an empty store.
+func newDescriptorStore(path string, size int) (*descriptorStore, error) {
+	s := &descriptorStore{
+		path:    path,
+		size:    size,
+		queries: make(map[persistKey]*persistedQuery),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file descriptorFile
+	if e := json.Unmarshal(data, &file); e != nil {
+		log.Printf("ignoring invalid descriptor cache file %q: %v", path, e)
+		return s, nil
+	}
+
+	s.version = file.ProtocolVersion
+	for _, q := range file.Queries {
+		if len(s.queries) >= size {
+			break
+		}
+
+		s.queries[q.persistKey] = q
+	}
+
+	return s, nil
+}
+
+func makePersistKey(q *query) persistKey {
+	return persistKey{
+		Cmd:     q.cmd,
+		Fmt:     q.fmt,
+		ExpCard: q.expCard,
+		Flags:   q.settings.compilationFlags(),
+	}
+}
+
+// record stores the raw in and out descriptors of q.
+func (s *descriptorStore) record(
+	version internal.ProtocolVersion,
+	q *query,
+	ids idPair,
+	in, out []byte,
+) {
+	// Warnings are not stored, so queries with warnings are not persisted
+	// to make sure their warnings are always reported.
+	if s == nil || q.settings.noCache || len(ids.warnings) > 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.version != version {
+		s.version = version
+		s.queries = make(map[persistKey]*persistedQuery)
+	}
+
+	key := makePersistKey(q)
+	if _, ok := s.queries[key]; !ok && len(s.queries) >= s.size {
+		return
+	}
+
+	s.queries[key] = &persistedQuery{
+		persistKey:   key,
+		Card:         ids.card,
+		Capabilities: q.reportedCapabilities,
+		In:           append([]byte(nil), in...),
+		Out:          append([]byte(nil), out...),
+	}
+}
+
+// get returns the stored descriptors for q.
+func (s *descriptorStore) get(
+	version internal.ProtocolVersion,
+	q *query,
+) (*persistedQuery, bool) {
+	if s == nil || q.settings.noCache {
+		return nil, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.version != version {
+		return nil, false
+	}
+
+	pq, ok := s.queries[makePersistKey(q)]
+	return pq, ok
+}
+
+// invalidate removes all stored descriptors.
+func (s *descriptorStore) invalidate() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.queries = make(map[persistKey]*persistedQuery)
+}
+
+// save writes the store to its file.
+func (s *descriptorStore) save() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	file := descriptorFile{ProtocolVersion: s.version}
+	for _, q := range s.queries {
+		file.Queries = append(file.Queries, q)
+	}
+	s.mutex.Unlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a partially written file is
+	// never loaded.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".edgedb-descriptors-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	err = firstError(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("could not save descriptor cache: %w", err)
+	}
+
+	return nil
+}
+
+// loadPersistedTypeIDs adds the stored descriptors for q to the connection's
+// caches and returns its type IDs.
+func (c *protocolConnection) loadPersistedTypeIDs(q *query) (*idPair, bool) {
+	pq, ok := c.descriptors.get(c.protocolVersion, q)
+	if !ok {
+		return nil, false
+	}
+
+	var (
+		ids     = idPair{card: pq.Card}
+		in, out interface{}
+	)
+
+	if c.protocolVersion.GTE(protocolVersion2p0) {
+		inDesc, err := descriptor.PopV2(
+			buff.SimpleReader(pq.In), c.protocolVersion)
+		if err != nil {
+			return nil, false
+		}
+
+		outDesc, err := descriptor.PopV2(
+			buff.SimpleReader(pq.Out), c.protocolVersion)
+		if err != nil {
+			return nil, false
+		}
+
+		ids.in, ids.out = inDesc.ID, outDesc.ID
+		in, out = inDesc, outDesc
+	} else if c.protocolVersion.GTE(protocolVersion1p0) {
+		inDesc, err := descriptor.Pop(
+			buff.SimpleReader(pq.In), c.protocolVersion)
+		if err != nil {
+			return nil, false
+		}
+
+		outDesc, err := descriptor.Pop(
+			buff.SimpleReader(pq.Out), c.protocolVersion)
+		if err != nil {
+			return nil, false
+		}
+
+		ids.in, ids.out = inDesc.ID, outDesc.ID
+		in, out = inDesc, outDesc
+	} else {
+		return nil, false
+	}
+
+	descCache.Put(ids.in, in)
+	descCache.Put(ids.out, out)
+	c.typeIDCache.Put(makeKey(q), ids)
+	c.capabilitiesCache.Put(makeKey(q), pq.Capabilities)
+	return &ids, true
+}
+
+// SaveDescriptorCache writes the type descriptors of the queries the client
+// has run to Options.DescriptorCacheFile. It is also called by Close. It
+// does nothing if DescriptorCacheFile is not set.
+func (p *Client) SaveDescriptorCache() error {
+	return p.descriptors.save()
+}
@@ -0,0 +1,112 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scalarDescriptorV2 returns an encoded protocol 2.0 scalar type descriptor.
+func scalarDescriptorV2(id types.UUID, name string) []byte {
+	n := make([]byte, 4)
+	binary.BigEndian.PutUint32(n, uint32(len(name)))
+
+	body := []byte{byte(descriptor.Scalar)}
+	body = append(body, id[:]...)
+	body = append(body, n...)
+	body = append(body, name...)
+	body = append(body, 1)    // schema_defined
+	body = append(body, 0, 0) // no ancestors
+
+	data := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(data, uint32(len(body)))
+	return append(data, body...)
+}
+
+func TestDescriptorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "descriptors.json")
+	store, err := newDescriptorStore(path, 10)
+	require.NoError(t, err)
+
+	id := types.UUID{1, 2, 3}
+	out := scalarDescriptorV2(id, "std::int64")
+	q := &query{cmd: "SELECT 1", fmt: Binary, expCard: One}
+	store.record(protocolVersion2p0, q, idPair{card: One}, nil, out)
+
+	// Queries with warnings are not stored.
+	warned := &query{cmd: "SELECT 2", fmt: Binary, expCard: One}
+	store.record(
+		protocolVersion2p0,
+		warned,
+		idPair{card: One, warnings: []error{&clientError{msg: "warning"}}},
+		nil,
+		out,
+	)
+
+	require.NoError(t, store.save())
+
+	store, err = newDescriptorStore(path, 10)
+	require.NoError(t, err)
+
+	_, ok := store.get(protocolVersion2p0, warned)
+	assert.False(t, ok)
+
+	_, ok = store.get(protocolVersion1p0, q)
+	assert.False(t, ok, "descriptors depend on the protocol version")
+
+	pq, ok := store.get(protocolVersion2p0, q)
+	require.True(t, ok)
+	assert.Equal(t, out, pq.Out)
+
+	conn := &protocolConnection{
+		protocolVersion: protocolVersion2p0,
+		cacheCollection: newCacheCollection(nil, 10, codecs.DecoderOptions{}),
+	}
+	conn.descriptors = store
+
+	ids, ok := conn.getCachedTypeIDs(q)
+	require.True(t, ok)
+	assert.Equal(t, descriptor.IDZero, ids.in)
+	assert.Equal(t, id, ids.out)
+	assert.Equal(t, One, ids.card)
+
+	desc, ok := descCache.Get(id)
+	require.True(t, ok)
+	assert.Equal(t, "std::int64", desc.(descriptor.V2).Name)
+
+	conn.invalidateTypeIDs()
+	_, ok = conn.getCachedTypeIDs(q)
+	assert.False(t, ok)
+}
+
+func TestDescriptorStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "descriptors.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	store, err := newDescriptorStore(path, 10)
+	require.NoError(t, err)
+	assert.Empty(t, store.queries)
+}
@@ -0,0 +1,122 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// queryDigest returns a digest of the normalized query text and the ID of
+// the query's result type descriptor. See ResultMeta.Digest.
+func queryDigest(cmd string, out types.UUID) string {
+	h := sha256.New()
+	h.Write([]byte(normalizeQuery(cmd)))
+	h.Write([]byte{0})
+	h.Write(out[:])
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// normalizeQuery removes comments and trailing semicolons from cmd and
+// replaces each run of whitespace with a single space. String literals,
+// quoted identifiers and dollar quoted strings are not changed.
+func normalizeQuery(cmd string) string {
+	var b strings.Builder
+	space := false
+
+	emit := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(cmd); i++ {
+		switch c := cmd[i]; c {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			space = true
+		case '#':
+			for i < len(cmd) && cmd[i] != '\n' {
+				i++
+			}
+			space = true
+		case '\'', '"', '`':
+			end := quoteEnd(cmd, i+1, c)
+			emit(cmd[i:end])
+			i = end - 1
+		case '$':
+			end := dollarQuoteEnd(cmd, i)
+			emit(cmd[i:end])
+			i = end - 1
+		default:
+			emit(cmd[i : i+1])
+		}
+	}
+
+	return strings.TrimRight(b.String(), "; ")
+}
+
+// quoteEnd returns the index after the quote character q that ends the
+// literal starting at start. Backslash escapes are skipped except in quoted
+// identifiers.
+func quoteEnd(cmd string, start int, q byte) int {
+	for i := start; i < len(cmd); i++ {
+		switch cmd[i] {
+		case '\\':
+			if q != '`' {
+				i++
+			}
+		case q:
+			return i + 1
+		}
+	}
+
+	return len(cmd)
+}
+
+// dollarQuoteEnd returns the index after the dollar quoted string starting
+// at start, or start+1 if the $ does not start a dollar quoted string, for
+// example in query parameters like $0 or $name.
+func dollarQuoteEnd(cmd string, start int) int {
+	i := start + 1
+	for i < len(cmd) && isIdentChar(cmd[i]) {
+		i++
+	}
+
+	if i == len(cmd) || cmd[i] != '$' {
+		return start + 1
+	}
+
+	tag := cmd[start : i+1]
+	end := strings.Index(cmd[i+1:], tag)
+	if end < 0 {
+		return len(cmd)
+	}
+
+	return i + 1 + end + len(tag)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' ||
+		('a' <= c && c <= 'z') ||
+		('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
@@ -0,0 +1,57 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	samples := []struct {
+		cmd      string
+		expected string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"  SELECT\n\t1 ;\n", "SELECT 1"},
+		{"SELECT 1; # comment\n", "SELECT 1"},
+		{"SELECT User {\n  name\n} FILTER .id = <uuid>$0",
+			"SELECT User { name } FILTER .id = <uuid>$0"},
+		{"SELECT 'a  b' ++ \"c\\\"  d\"", "SELECT 'a  b' ++ \"c\\\"  d\""},
+		{"SELECT `my  name`", "SELECT `my  name`"},
+		{"SELECT $$a  #b$$", "SELECT $$a  #b$$"},
+		{"SELECT $x$ $$  $x$ ++ <str>$y", "SELECT $x$ $$  $x$ ++ <str>$y"},
+		{"SELECT '# not a comment'", "SELECT '# not a comment'"},
+	}
+
+	for _, s := range samples {
+		t.Run(s.cmd, func(t *testing.T) {
+			assert.Equal(t, s.expected, normalizeQuery(s.cmd))
+		})
+	}
+}
+
+func TestQueryDigest(t *testing.T) {
+	id := types.UUID{1}
+	digest := queryDigest("SELECT 1", id)
+	assert.Len(t, digest, 32)
+	assert.Equal(t, digest, queryDigest("\nSELECT  1;", id))
+	assert.NotEqual(t, digest, queryDigest("SELECT 2", id))
+	assert.NotEqual(t, digest, queryDigest("SELECT 1", types.UUID{2}))
+}
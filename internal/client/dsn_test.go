@@ -0,0 +1,58 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+	"time"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	t.Setenv("TEST_DSN_PASSWORD", "s3cret")
+
+	opts, err := ParseDSN("edgedb://admin@example.com:5757/main" +
+		"?password_env=TEST_DSN_PASSWORD&secret_key=key" +
+		"&tls_security=no_host_verification&wait_until_available=5s")
+	require.NoError(t, err)
+	assert.Equal(t, Options{
+		Host:               "example.com",
+		Port:               5757,
+		User:               "admin",
+		Database:           "main",
+		Password:           types.NewOptionalStr("s3cret"),
+		SecretKey:          "key",
+		WaitUntilAvailable: 5 * time.Second,
+		TLSOptions: TLSOptions{
+			SecurityMode: TLSModeNoHostVerification,
+		},
+	}, opts)
+
+	opts, err = ParseDSN("edgedb://localhost")
+	require.NoError(t, err)
+	assert.Equal(t, Options{Host: "localhost"}, opts)
+
+	_, err = ParseDSN("my_instance")
+	assert.EqualError(t, err, "edgedb.ConfigurationError: "+
+		`invalid DSN "my_instance": it must start with edgedb://`)
+
+	_, err = ParseDSN("edgedb://localhost:5656?port=5757")
+	assert.Error(t, err)
+}
@@ -18,7 +18,9 @@ package edgedb
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/sebastiean/edgedb-go/internal"
@@ -35,6 +37,35 @@ type cacheCollection struct {
 	inCodecCache      *cache.Cache
 	outCodecCache     *cache.Cache
 	capabilitiesCache *cache.Cache // nolint:structcheck
+
+	// preparedCache maps prepared queries to their type IDs.
+	preparedCache *cache.Cache
+
+	// decoderOptions are used to build the codecs in outCodecCache.
+	decoderOptions codecs.DecoderOptions
+
+	// cacheSize is the capacity of each cache.
+	cacheSize int
+
+	// descriptors persists the type IDs in typeIDCache across restarts.
+	descriptors *descriptorStore
+}
+
+func newCacheCollection(
+	serverSettings *snc.ServerSettings,
+	cacheSize int,
+	decoderOptions codecs.DecoderOptions,
+) cacheCollection {
+	return cacheCollection{
+		serverSettings:    serverSettings,
+		typeIDCache:       cache.New(cacheSize),
+		inCodecCache:      cache.New(cacheSize),
+		outCodecCache:     cache.New(cacheSize),
+		capabilitiesCache: cache.New(cacheSize),
+		preparedCache:     cache.New(cacheSize),
+		decoderOptions:    decoderOptions,
+		cacheSize:         cacheSize,
+	}
 }
 
 type protocolConnection struct {
@@ -48,6 +79,19 @@ type protocolConnection struct {
 
 	systemConfig systemConfig
 	stateCodec   codecs.Encoder
+
+	// host is the host the connection is connected to
+	// if the client has more than one host.
+	host *hostState
+
+	// maxMessageSize limits the size of the arguments that are sent.
+	// See Options.MaxMessageSize.
+	maxMessageSize int
+
+	// inUse is 1 while a query is running. See enter.
+	inUse int32
+
+	stats *connStats
 }
 
 // connectWithTimeout makes a single attempt to connect to `addr`.
@@ -55,11 +99,28 @@ func connectWithTimeout(
 	ctx context.Context,
 	cfg *connConfig,
 	caches cacheCollection,
+	stats *connStats,
 ) (*protocolConnection, error) {
+	host := cfg.hosts.pick()
+	if host != nil {
+		c := *cfg
+		c.addr = host.addr
+		cfg = &c
+	}
+
+	cfg, err := cfg.resolveAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
 	socket, err := connectAutoClosingSocket(ctx, cfg)
+	host.record(ctx, err, time.Since(start))
 	if err != nil {
 		return nil, err
 	}
+	socket.stats = stats
+	stats.connected(cfg.addr.address)
 
 	deadline, _ := ctx.Deadline()
 	err = socket.SetDeadline(deadline)
@@ -72,13 +133,21 @@ func connectWithTimeout(
 		acquireReaderSignal: make(chan struct{}, 1),
 		readerChan:          make(chan *buff.Reader, 1),
 		cacheCollection:     caches,
+		host:                host,
+		maxMessageSize:      cfg.maxMessageSize,
+		stats:               stats,
 	}
 
 	toBeDeserialized := make(chan *soc.Data, 2)
 	go soc.Read(socket, soc.NewMemPool(4, 256*1024), toBeDeserialized)
 	r := buff.NewReader(toBeDeserialized)
+	r.MaxMessageSize = cfg.maxMessageSize
 
+	canceled := socket.closeOnCancel(ctx)
 	err = conn.connect(r, cfg)
+	if canceled() {
+		err = wrapNetError(ctx.Err())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +171,8 @@ func (c *protocolConnection) acquireReader(
 	select {
 	case r := <-c.readerChan:
 		if r.Err != nil {
-			return nil, &clientConnectionClosedError{err: r.Err}
+			return nil, wrapMessageTooLarge(
+				&clientConnectionClosedError{err: r.Err})
 		}
 		if c.soc.Closed() {
 			return nil, &clientConnectionClosedError{}
@@ -123,16 +193,19 @@ func (c *protocolConnection) releaseReader(r *buff.Reader) error {
 	}
 
 	go func() {
+		// errors sent by the server are kept separately because r.Err is
+		// overwritten when the server closes the connection.
+		var serverErr error
 		for r.Next(c.acquireReaderSignal) {
 			switch Message(r.MsgType) {
 			case ErrorResponse:
 				err := decodeErrorResponseMsg(r, "")
 				log.Println("from background:", err)
-				r.Err = wrapAll(r.Err, err)
+				serverErr = wrapAll(serverErr, err)
 			default:
 				if e := c.fallThrough(r); e != nil {
 					log.Println("fall through in background:", e)
-					r.Err = wrapAll(r.Err, e)
+					r.Err = wrapAll(serverErr, r.Err, e)
 					err := c.soc.Close()
 					if err != nil {
 						log.Println("error closing socket:", err)
@@ -143,6 +216,7 @@ func (c *protocolConnection) releaseReader(r *buff.Reader) error {
 			}
 		}
 
+		r.Err = wrapAll(serverErr, r.Err)
 		if r.Err != nil {
 			err := c.soc.Close()
 			if err != nil {
@@ -182,7 +256,33 @@ func (c *protocolConnection) isClosed() bool {
 	return false
 }
 
+// enter marks the connection as running a query. It returns an error
+// instead of interleaving the query with another one if the connection is
+// already running a query, which happens if a Tx is used by more than one
+// goroutine at a time. exit must be called when the query is done.
+func (c *protocolConnection) enter(method string) error {
+	if !atomic.CompareAndSwapInt32(&c.inUse, 0, 1) {
+		return &interfaceError{msg: fmt.Sprintf(
+			"%v called while the connection is running another query. "+
+				"A transaction must not be used "+
+				"by more than one goroutine at a time.",
+			method,
+		)}
+	}
+
+	return nil
+}
+
+func (c *protocolConnection) exit() {
+	atomic.StoreInt32(&c.inUse, 0)
+}
+
 func (c *protocolConnection) scriptFlow(ctx context.Context, q *query) error {
+	if e := c.enter(q.method); e != nil {
+		return e
+	}
+	defer c.exit()
+
 	r, err := c.acquireReader(ctx)
 	if err != nil {
 		return err
@@ -194,6 +294,9 @@ func (c *protocolConnection) scriptFlow(ctx context.Context, q *query) error {
 		return err
 	}
 
+	q.annotate(ctx)
+	canceled := c.soc.closeOnCancel(ctx)
+
 	switch {
 	case c.protocolVersion.GTE(protocolVersion2p0):
 		err = c.execGranularFlow2pX(r, q)
@@ -203,13 +306,22 @@ func (c *protocolConnection) scriptFlow(ctx context.Context, q *query) error {
 		err = c.execScriptFlow(r, q)
 	}
 
-	return firstError(err, c.releaseReader(r))
+	if canceled() {
+		err = wrapNetError(ctx.Err())
+	}
+
+	return firstError(wrapMessageTooLarge(err), c.releaseReader(r))
 }
 
 func (c *protocolConnection) granularFlow(
 	ctx context.Context,
 	q *query,
 ) error {
+	if e := c.enter(q.method); e != nil {
+		return e
+	}
+	defer c.exit()
+
 	r, err := c.acquireReader(ctx)
 	if err != nil {
 		return err
@@ -221,6 +333,9 @@ func (c *protocolConnection) granularFlow(
 		return err
 	}
 
+	q.annotate(ctx)
+	canceled := c.soc.closeOnCancel(ctx)
+
 	switch {
 	case c.protocolVersion.GTE(protocolVersion2p0):
 		err = c.execGranularFlow2pX(r, q)
@@ -230,5 +345,9 @@ func (c *protocolConnection) granularFlow(
 		err = c.execGranularFlow0pX(r, q)
 	}
 
-	return firstError(err, c.releaseReader(r))
+	if canceled() {
+		err = wrapNetError(ctx.Err())
+	}
+
+	return firstError(wrapMessageTooLarge(err), c.releaseReader(r))
 }
@@ -0,0 +1,41 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentQueriesAreRejected(t *testing.T) {
+	c := &protocolConnection{}
+	require.NoError(t, c.enter("Query"))
+
+	err := c.enter("QuerySingle")
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(InterfaceError))
+	assert.EqualError(t, err, "edgedb.InterfaceError: QuerySingle called "+
+		"while the connection is running another query. A transaction "+
+		"must not be used by more than one goroutine at a time.")
+
+	c.exit()
+	assert.NoError(t, c.enter("QuerySingle"))
+}
@@ -28,8 +28,15 @@ import (
 	"unicode/utf8"
 
 	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
 )
 
+// DecodeError is returned when a value in a query result can not be decoded.
+// Its Location method returns where the value is in the result, for example
+// [10532].tags[3].name is the name of the fourth tag of the 10,533rd row.
+// Use errors.As to get it from the returned error.
+type DecodeError = codecs.DecodeError
+
 var (
 	errNoTOMLFound             = errors.New("no edgedb.toml found")
 	errZeroResults       error = &noDataError{msg: "zero results"}
@@ -224,6 +231,28 @@ func isClientConnectionError(err error) bool {
 	return errors.As(err, &edbErr) && edbErr.Category(ClientConnectionError)
 }
 
+// isIdleSessionTimeout returns true if err was caused by the server closing
+// the connection because it was idle for longer than session_idle_timeout.
+func isIdleSessionTimeout(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *wrappedManyError:
+		for _, err := range e.errs {
+			if isIdleSessionTimeout(err) {
+				return true
+			}
+		}
+		return false
+	case Error:
+		if e.Category(IdleSessionTimeoutError) {
+			return true
+		}
+	}
+
+	return isIdleSessionTimeout(errors.Unwrap(err))
+}
+
 func wrapNetError(err error) error {
 	var errEDB Error
 	var errNetOp *net.OpError
@@ -19,8 +19,11 @@ package edgedb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 
+	"github.com/sebastiean/edgedb-go/internal/codecs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -155,3 +158,29 @@ func TestWrapAllIs(t *testing.T) {
 	assert.True(t, errors.Is(err, errA))
 	assert.True(t, errors.Is(err, errB))
 }
+
+func TestDecodeErrorAs(t *testing.T) {
+	decodeErr := codecs.WrapIndex(errors.New("bad value"), 3)
+	err := wrapAll(decodeErr, &invalidValueError{msg: "other"})
+
+	var de *DecodeError
+	require.True(t, errors.As(err, &de))
+	assert.Equal(t, "[3]", de.Location())
+}
+
+func TestIsIdleSessionTimeout(t *testing.T) {
+	idle := &idleSessionTimeoutError{msg: "closing the connection"}
+
+	assert.True(t, isIdleSessionTimeout(idle))
+	assert.True(t, isIdleSessionTimeout(&clientConnectionClosedError{
+		err: wrapAll(idle, io.EOF),
+	}))
+	assert.True(t, isIdleSessionTimeout(fmt.Errorf("query: %w", idle)))
+
+	assert.False(t, isIdleSessionTimeout(nil))
+	assert.False(t, isIdleSessionTimeout(&clientConnectionClosedError{
+		err: io.EOF,
+	}))
+	assert.False(t, isIdleSessionTimeout(
+		&idleTransactionTimeoutError{msg: "transaction timed out"}))
+}
@@ -73,6 +73,7 @@ func (c *protocolConnection) fallThrough(r *buff.Reader) error {
 				desc,
 				reflect.TypeOf(cfg),
 				codecs.Path("system_config"),
+				codecs.DecoderOptions{},
 			)
 			if err != nil {
 				return &binaryProtocolError{err: fmt.Errorf(
@@ -140,6 +141,7 @@ func (c *protocolConnection) fallThrough2pX(r *buff.Reader) error {
 				&desc,
 				reflect.TypeOf(cfg),
 				codecs.Path("system_config"),
+				codecs.DecoderOptions{},
 			)
 			if err != nil {
 				return &binaryProtocolError{err: fmt.Errorf(
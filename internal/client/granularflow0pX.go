@@ -17,6 +17,7 @@
 package edgedb
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"unsafe"
@@ -37,22 +38,27 @@ func (c *protocolConnection) execGranularFlow0pX(
 
 	ids, ok := c.getCachedTypeIDs(q)
 	if !ok {
+		c.stats.query(false)
 		return c.pesimistic0pX(r, q)
 	}
+	q.descIDs = *ids
 
 	cdcs, err := c.codecsFromIDs(ids, q)
 	if err != nil {
 		return err
 	} else if cdcs == nil {
+		c.stats.query(false)
 		return c.pesimistic0pX(r, q)
 	}
 
 	// When descriptors are returned the codec ids sent didn't match the
 	// server's.  The codecs should be rebuilt with the new descriptors and the
 	// execution retried.
+	c.stats.query(true)
 	descs, err := c.optimistic0pX(r, q, cdcs)
 	switch {
 	case err == errZeroResults && descs != nil:
+		c.stats.retry()
 		goto Retry
 	case err != nil:
 		return err
@@ -112,6 +118,20 @@ func (c *protocolConnection) codecsFromIDs(
 		}
 	}
 
+	if q.meta != nil {
+		typ, OK := cachedResultType(ids.out)
+		if !OK {
+			// Describe the query to get its result type.
+			return nil, nil
+		}
+		q.meta.Type = typ
+	}
+
+	if q.fmt == JSON || q.fmt == JSONElements {
+		// JSON results are passed through without being decoded.
+		return &codecPair{in: in.(codecs.Encoder), out: codecs.JSONBytes}, nil
+	}
+
 	out, ok := c.outCodecCache.Get(codecKey{ID: ids.out, Type: q.outType})
 	if !ok {
 		desc, OK := descCache.Get(ids.out)
@@ -121,13 +141,22 @@ func (c *protocolConnection) codecsFromIDs(
 
 		d := desc.(descriptor.Descriptor)
 		path := codecs.Path(q.outType.String())
-		out, err = codecs.BuildDecoder(d, q.outType, path)
+		out, err = codecs.BuildDecoder(
+			d,
+			q.outType,
+			path,
+			c.decoderOptions,
+		)
 		if err != nil {
 			return nil, &invalidArgumentError{msg: fmt.Sprintf(
 				"the \"out\" argument does not match query schema: %v", err)}
 		}
 	}
 
+	if e := checkStreamable(q, out.(codecs.Decoder)); e != nil {
+		return nil, e
+	}
+
 	return &codecPair{in: in.(codecs.Encoder), out: out.(codecs.Decoder)}, nil
 }
 
@@ -142,11 +171,16 @@ func (c *protocolConnection) codecsFromDescriptors0pX(
 		return nil, &invalidArgumentError{msg: err.Error()}
 	}
 
-	if q.fmt == JSON {
+	if q.fmt == JSON || q.fmt == JSONElements {
 		cdcs.out = codecs.JSONBytes
 	} else {
 		path := codecs.Path(q.outType.String())
-		cdcs.out, err = codecs.BuildDecoder(descs.Out, q.outType, path)
+		cdcs.out, err = codecs.BuildDecoder(
+			descs.Out,
+			q.outType,
+			path,
+			c.decoderOptions,
+		)
 		if err != nil {
 			err = fmt.Errorf(
 				"the \"out\" argument does not match query schema: %v",
@@ -156,6 +190,14 @@ func (c *protocolConnection) codecsFromDescriptors0pX(
 		}
 	}
 
+	if e := checkStreamable(q, cdcs.out); e != nil {
+		return nil, e
+	}
+
+	if q.meta != nil {
+		q.meta.Type = resultType(descs.Out)
+	}
+
 	c.inCodecCache.Put(cdcs.in.DescriptorID(), cdcs.in)
 	c.outCodecCache.Put(
 		codecKey{ID: cdcs.out.DescriptorID(), Type: q.outType},
@@ -195,8 +237,12 @@ func (c *protocolConnection) prepare0pX(r *buff.Reader, q *query) error {
 		switch Message(r.MsgType) {
 		case ParseComplete:
 			c.cacheCapabilities0pX(q, decodeHeaders(r))
-			r.Discard(1) // cardinality
-			ids := idPair{in: r.PopUUID(), out: r.PopUUID()}
+			ids := idPair{
+				card: Cardinality(r.PopUint8()),
+				in:   r.PopUUID(),
+				out:  r.PopUUID(),
+			}
+			q.descIDs = ids
 			c.cacheTypeIDs(q, ids)
 		case ReadyForCommand:
 			decodeReadyForCommandMsg(r)
@@ -274,19 +320,21 @@ func (c *protocolConnection) execute0pX(
 	w.BeginMessage(uint8(Execute0pX))
 	writeHeaders(w, q.headers0pX())
 	w.PushUint32(0) // no statement name
-	if e := cdcs.in.Encode(w, q.args, codecs.Path("args"), true); e != nil {
-		return &invalidArgumentError{msg: e.Error()}
+	if e := c.encodeArgs(w, q, cdcs.in); e != nil {
+		return e
 	}
 	w.EndMessage()
 
 	w.BeginMessage(uint8(Sync))
 	w.EndMessage()
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+	q.streamed = w.Streaming()
+	if e := c.soc.WriteBuffer(w); e != nil {
 		return &clientConnectionClosedError{err: e}
 	}
 
 	tmp := q.out
+	defer streamResult(r, q)()
 	err := error(nil)
 	if q.expCard == AtMostOne {
 		err = errZeroResults
@@ -296,7 +344,14 @@ func (c *protocolConnection) execute0pX(
 	for r.Next(done.Chan) {
 		switch Message(r.MsgType) {
 		case Data:
-			val, ok, e := decodeDataMsg(r, q, cdcs)
+			if err != nil && err != errZeroResults {
+				// Only keep the rows decoded before the first error.
+				r.Discard(len(r.Buf))
+				break
+			}
+
+			var e error
+			tmp, e = decodeDataMsg(r, q, cdcs, tmp)
 			if e != nil {
 				if err == errZeroResults {
 					err = e
@@ -304,9 +359,6 @@ func (c *protocolConnection) execute0pX(
 					err = wrapAll(err, e)
 				}
 			}
-			if ok {
-				tmp = reflect.Append(tmp, val)
-			}
 
 			if err == errZeroResults {
 				err = nil
@@ -331,12 +383,11 @@ func (c *protocolConnection) execute0pX(
 	}
 
 	if r.Err != nil {
+		q.setResult(tmp, r.Err)
 		return r.Err
 	}
 
-	if !q.flat() {
-		q.out.Set(tmp)
-	}
+	q.setResult(tmp, err)
 
 	return err
 }
@@ -350,26 +401,28 @@ func (c *protocolConnection) optimistic0pX(
 	headers[header.ExplicitObjectIDs] = []byte("true")
 
 	w := buff.NewWriter(c.writeMemory[:0])
-	w.BeginMessage(uint8(Execute))
+	w.BeginMessage(uint8(Execute1pX))
 	writeHeaders(w, headers)
 	w.PushUint8(uint8(q.fmt))
 	w.PushUint8(uint8(q.expCard))
 	w.PushString(q.cmd)
 	w.PushUUID(cdcs.in.DescriptorID())
 	w.PushUUID(cdcs.out.DescriptorID())
-	if e := cdcs.in.Encode(w, q.args, codecs.Path("args"), true); e != nil {
-		return nil, &invalidArgumentError{msg: e.Error()}
+	if e := c.encodeArgs(w, q, cdcs.in); e != nil {
+		return nil, e
 	}
 	w.EndMessage()
 
 	w.BeginMessage(uint8(Sync))
 	w.EndMessage()
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+	q.streamed = w.Streaming()
+	if e := c.soc.WriteBuffer(w); e != nil {
 		return nil, &clientConnectionClosedError{err: e}
 	}
 
 	tmp := q.out
+	defer streamResult(r, q)()
 	err := error(nil)
 	if q.expCard == AtMostOne {
 		err = errZeroResults
@@ -380,7 +433,14 @@ func (c *protocolConnection) optimistic0pX(
 	for r.Next(done.Chan) {
 		switch Message(r.MsgType) {
 		case Data:
-			val, ok, e := decodeDataMsg(r, q, cdcs)
+			if err != nil && err != errZeroResults {
+				// Only keep the rows decoded before the first error.
+				r.Discard(len(r.Buf))
+				break
+			}
+
+			var e error
+			tmp, e = decodeDataMsg(r, q, cdcs, tmp)
 			if e != nil {
 				if err == errZeroResults {
 					err = e
@@ -388,9 +448,6 @@ func (c *protocolConnection) optimistic0pX(
 					err = wrapAll(err, e)
 				}
 			}
-			if ok {
-				tmp = reflect.Append(tmp, val)
-			}
 
 			if err == errZeroResults {
 				err = nil
@@ -427,12 +484,11 @@ func (c *protocolConnection) optimistic0pX(
 	}
 
 	if r.Err != nil {
+		q.setResult(tmp, r.Err)
 		return nil, r.Err
 	}
 
-	if !q.flat() {
-		q.out.Set(tmp)
-	}
+	q.setResult(tmp, err)
 
 	return descs, err
 }
@@ -447,39 +503,156 @@ func decodeReadyForCommandMsg(r *buff.Reader) {
 	r.Discard(1) // transaction state
 }
 
+// checkStreamable returns an error if the result of q is streamed to
+// q.writer but out doesn't decode std::bytes. Other binary encodings, like
+// the format byte of std::json, would otherwise be written to q.writer.
+func checkStreamable(q *query, out codecs.Decoder) error {
+	if q.writer == nil {
+		return nil
+	}
+
+	if _, ok := out.(*codecs.BytesCodec); ok {
+		return nil
+	}
+
+	return &invalidArgumentError{msg: "the \"out\" argument is an io.Writer " +
+		"but the query does not return std::bytes"}
+}
+
+// streamResult makes r write the result of q to q.writer if q has a writer.
+// The returned function stops streaming.
+func streamResult(r *buff.Reader, q *query) func() {
+	if q.writer == nil {
+		return func() {}
+	}
+
+	// Data messages start with the element count and the element length.
+	r.StreamTo(uint8(Data), 6, &streamWriter{q: q})
+	return func() { r.StreamTo(0, 0, nil) }
+}
+
+// streamWriter marks q as streamed before the first byte is written to
+// q.writer, because a query that wrote part of its result can't be retried.
+type streamWriter struct {
+	q *query
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.q.streamed = true
+	return w.q.writer.Write(p)
+}
+
+// decodeDataMsg decodes a Data message. Results for non flat queries are
+// appended to out and the extended slice is returned. If out has spare
+// capacity the next element is decoded in place instead of allocating a new
+// value, so running the same query repeatedly with the same out slice does not
+// reallocate its rows.
 func decodeDataMsg(
 	r *buff.Reader,
 	q *query,
 	cdcs *codecPair,
-) (reflect.Value, bool, error) {
+	out reflect.Value,
+) (reflect.Value, error) {
 	elmCount := r.PopUint16()
 	if elmCount != 1 {
-		return reflect.Value{}, false, fmt.Errorf(
+		return out, fmt.Errorf(
 			"unexpected number of elements: expected 1, got %v", elmCount)
 	}
-	elmLen := r.PopUint32()
 
+	if q.writer != nil {
+		// The reader wrote the element to q.writer.
+		r.Discard(4)
+		return out, r.StreamErr()
+	}
+
+	data := r.PopSlice(r.PopUint32())
+	if q.recordResults {
+		// The reader's buffer is reused for later messages.
+		q.results = append(q.results, append([]byte(nil), data.Buf...))
+	}
+
+	if q.elementFunc != nil {
+		// The callback acts on the element so it can't be retried.
+		q.streamed = true
+		return out, q.elementFunc(append(json.RawMessage(nil), data.Buf...))
+	}
+
+	if q.iter != nil {
+		return out, q.iter.yield(data, q, cdcs.out)
+	}
+
+	return decodeElement(data, q, cdcs.out, out)
+}
+
+// decodeElement decodes a single result element. See decodeDataMsg.
+func decodeElement(
+	r *buff.Reader,
+	q *query,
+	decoder codecs.Decoder,
+	out reflect.Value,
+) (reflect.Value, error) {
 	if !q.flat() {
-		val := reflect.New(q.outType).Elem()
-		err := cdcs.out.Decode(
-			r.PopSlice(elmLen),
-			unsafe.Pointer(val.UnsafeAddr()),
+		var extended reflect.Value
+		n := out.Len()
+		if n < out.Cap() {
+			extended = out.Slice(0, n+1)
+			// Don't keep values or nested slices from the previous use
+			// of the slice's spare capacity.
+			extended.Index(n).Set(reflect.Zero(q.outType))
+		} else {
+			extended = reflect.Append(out, reflect.Zero(q.outType))
+		}
+
+		err := decode(
+			r,
+			q,
+			decoder,
+			unsafe.Pointer(extended.Index(n).UnsafeAddr()),
 		)
 		if err != nil {
-			return reflect.Value{}, false, err
+			return out, codecs.WrapIndex(err, n)
 		}
-		return val, true, nil
+		return extended, nil
 	}
 
-	err := cdcs.out.Decode(
-		r.PopSlice(elmLen),
-		unsafe.Pointer(q.out.UnsafeAddr()),
-	)
+	err := decode(r, q, decoder, unsafe.Pointer(q.out.UnsafeAddr()))
 	if err != nil {
-		return reflect.Value{}, false, err
+		return out, err
 	}
 
-	return reflect.Value{}, false, nil
+	return out, nil
+}
+
+// decode decodes a result element into out. A panic in the decoder, for
+// example in a user implemented unmarshaler, is returned as an error.
+func decode(
+	r *buff.Reader,
+	q *query,
+	decoder codecs.Decoder,
+	out unsafe.Pointer,
+) (err error) {
+	path := codecs.Path(q.outType.String())
+	defer func() {
+		if val := recover(); val != nil {
+			err = codecs.PanicError("decoding", path, val)
+		}
+	}()
+
+	if err := codecs.CheckDataLength(decoder, r, path); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(r, out); err != nil {
+		return err
+	}
+
+	// Iterators don't keep their elements, remembering their linked
+	// objects would only grow q.links for as long as the iterator runs.
+	if q.linkIDs != nil && q.iter == nil {
+		q.dedupLinks(reflect.NewAt(q.outType, out).Elem())
+	}
+
+	return nil
 }
 
 func (c *protocolConnection) decodeCommandDataDescriptionMsg0pX(
@@ -494,10 +667,9 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg0pX(
 		err   error
 	)
 	id := r.PopUUID() // in descriptor id
-	descs.In, err = descriptor.Pop(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	in := r.PopSlice(r.PopUint32())
+	descs.inData = append([]byte(nil), in.Buf...)
+	descs.In, err = descriptor.Pop(in, c.protocolVersion)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -507,10 +679,9 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg0pX(
 	}
 
 	id = r.PopUUID() // output descriptor ID
-	descs.Out, err = descriptor.Pop(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	out := r.PopSlice(r.PopUint32())
+	descs.outData = append([]byte(nil), out.Buf...)
+	descs.Out, err = descriptor.Pop(out, c.protocolVersion)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -530,6 +701,11 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg0pX(
 			q.expCard)}
 	}
 
+	q.descIDs = idPair{
+		in:   descs.In.ID,
+		out:  descs.Out.ID,
+		card: Cardinality(card),
+	}
 	descCache.Put(descs.In.ID, descs.In)
 	descCache.Put(descs.Out.ID, descs.Out)
 	return &descs, headers, nil
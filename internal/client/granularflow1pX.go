@@ -18,7 +18,6 @@ package edgedb
 
 import (
 	"fmt"
-	"reflect"
 
 	"github.com/sebastiean/edgedb-go/internal/buff"
 	"github.com/sebastiean/edgedb-go/internal/codecs"
@@ -32,17 +31,31 @@ func (c *protocolConnection) execGranularFlow1pX(
 ) error {
 	ids, ok := c.getCachedTypeIDs(q)
 	if !ok {
+		c.stats.query(false)
 		return c.pesimistic1pX(r, q)
 	}
+	q.descIDs = *ids
 
 	cdcs, err := c.codecsFromIDs(ids, q)
 	if err != nil {
 		return err
 	} else if cdcs == nil {
+		c.stats.query(false)
 		return c.pesimistic1pX(r, q)
 	}
 
-	return c.execute1pX(r, q, cdcs)
+	c.stats.query(true)
+	err = c.execute1pX(r, q, cdcs)
+	if isStaleDescriptorError(err) && !q.streamed {
+		c.stats.retry()
+		// The schema changed after the query's type descriptors were
+		// cached. Describe the query again and retry it once.
+		c.invalidateTypeIDs()
+		q.resetAttempt()
+		return c.pesimistic1pX(r, q)
+	}
+
+	return err
 }
 
 func (c *protocolConnection) pesimistic1pX(r *buff.Reader, q *query) error {
@@ -67,8 +80,8 @@ func (c *protocolConnection) parse1pX(
 	w.BeginMessage(uint8(Parse))
 	w.PushUint16(0) // no headers
 	w.PushUint64(q.capabilities)
-	w.PushUint64(0) // no compilation_flags
-	w.PushUint64(0) // no implicit limit
+	w.PushUint64(q.settings.compilationFlags())
+	w.PushUint64(q.settings.implicitLimit)
 	w.PushUint8(uint8(q.fmt))
 	w.PushUint8(uint8(q.expCard))
 	w.PushString(q.cmd)
@@ -135,10 +148,9 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg1pX(
 
 	descs.Card = Cardinality(r.PopUint8())
 	id := r.PopUUID()
-	descs.In, err = descriptor.Pop(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	in := r.PopSlice(r.PopUint32())
+	inData := in.Buf
+	descs.In, err = descriptor.Pop(in, c.protocolVersion)
 	if err != nil {
 		return nil, err
 	} else if descs.In.ID != id {
@@ -149,10 +161,9 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg1pX(
 	}
 
 	id = r.PopUUID()
-	descs.Out, err = descriptor.Pop(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	out := r.PopSlice(r.PopUint32())
+	outData := out.Buf
+	descs.Out, err = descriptor.Pop(out, c.protocolVersion)
 	if err != nil {
 		return nil, err
 	} else if descs.Out.ID != id {
@@ -171,7 +182,12 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg1pX(
 			q.expCard)}
 	}
 
-	c.cacheTypeIDs(q, idPair{in: descs.In.ID, out: descs.Out.ID})
+	ids := idPair{in: descs.In.ID, out: descs.Out.ID, card: descs.Card}
+	q.descIDs = ids
+	c.cacheTypeIDs(q, ids)
+	c.descriptors.record(c.protocolVersion, q, ids, inData, outData)
+	descs.inData = append([]byte(nil), inData...)
+	descs.outData = append([]byte(nil), outData...)
 	descCache.Put(descs.In.ID, descs.In)
 	descCache.Put(descs.Out.ID, descs.Out)
 	return &descs, nil
@@ -183,11 +199,11 @@ func (c *protocolConnection) execute1pX(
 	cdcs *codecPair,
 ) error {
 	w := buff.NewWriter(c.writeMemory[:0])
-	w.BeginMessage(uint8(Execute))
+	w.BeginMessage(uint8(Execute1pX))
 	w.PushUint16(0) // no headers
 	w.PushUint64(q.capabilities)
-	w.PushUint64(0) // no compilation_flags
-	w.PushUint64(0) // no implicit limit
+	w.PushUint64(q.settings.compilationFlags())
+	w.PushUint64(q.settings.implicitLimit)
 	w.PushUint8(uint8(q.fmt))
 	w.PushUint8(uint8(q.expCard))
 	w.PushString(q.cmd)
@@ -201,19 +217,21 @@ func (c *protocolConnection) execute1pX(
 
 	w.PushUUID(cdcs.in.DescriptorID())
 	w.PushUUID(cdcs.out.DescriptorID())
-	if e := cdcs.in.Encode(w, q.args, codecs.Path("args"), true); e != nil {
-		return &invalidArgumentError{msg: e.Error()}
+	if e := c.encodeArgs(w, q, cdcs.in); e != nil {
+		return e
 	}
 	w.EndMessage()
 
 	w.BeginMessage(uint8(Sync))
 	w.EndMessage()
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+	q.streamed = w.Streaming()
+	if e := c.soc.WriteBuffer(w); e != nil {
 		return &clientConnectionClosedError{err: e}
 	}
 
 	tmp := q.out
+	defer streamResult(r, q)()
 	if q.expCard == AtMostOne {
 		err = errZeroResults
 	}
@@ -226,12 +244,25 @@ func (c *protocolConnection) execute1pX(
 				err = wrapAll(err, e)
 			}
 		case CommandDataDescription:
+			// The result type changed after it was cached.
+			c.invalidateTypeIDs()
 			descs, e := c.decodeCommandDataDescriptionMsg1pX(r, q)
 			err = wrapAll(err, e)
 			cdcs, e = c.codecsFromDescriptors1pX(q, descs)
+			if e != nil {
+				// Don't stream a result that doesn't match q.writer.
+				r.StreamTo(0, 0, nil)
+			}
 			err = wrapAll(err, e)
 		case Data:
-			val, ok, e := decodeDataMsg(r, q, cdcs)
+			if err != nil && err != errZeroResults {
+				// Only keep the rows decoded before the first error.
+				r.Discard(len(r.Buf))
+				break
+			}
+
+			var e error
+			tmp, e = decodeDataMsg(r, q, cdcs, tmp)
 			if e != nil {
 				if err == errZeroResults {
 					err = e
@@ -239,9 +270,6 @@ func (c *protocolConnection) execute1pX(
 					err = wrapAll(err, e)
 				}
 			}
-			if ok {
-				tmp = reflect.Append(tmp, val)
-			}
 
 			if err == errZeroResults {
 				err = nil
@@ -268,12 +296,11 @@ func (c *protocolConnection) execute1pX(
 	}
 
 	if r.Err != nil {
+		q.setResult(tmp, r.Err)
 		return r.Err
 	}
 
-	if !q.flat() && q.fmt != Null {
-		q.out.Set(tmp)
-	}
+	q.setResult(tmp, err)
 
 	return err
 }
@@ -289,7 +316,7 @@ func (c *protocolConnection) codecsFromDescriptors1pX(
 		return nil, &invalidArgumentError{msg: err.Error()}
 	}
 
-	if q.fmt == JSON {
+	if q.fmt == JSON || q.fmt == JSONElements {
 		cdcs.out = codecs.JSONBytes
 	} else {
 		var path codecs.Path
@@ -300,7 +327,12 @@ func (c *protocolConnection) codecsFromDescriptors1pX(
 			path = codecs.Path(q.outType.String())
 		}
 
-		cdcs.out, err = codecs.BuildDecoder(descs.Out, q.outType, path)
+		cdcs.out, err = codecs.BuildDecoder(
+			descs.Out,
+			q.outType,
+			path,
+			c.decoderOptions,
+		)
 		if err != nil {
 			err = fmt.Errorf(
 				"the \"out\" argument does not match query schema: %v",
@@ -310,6 +342,14 @@ func (c *protocolConnection) codecsFromDescriptors1pX(
 		}
 	}
 
+	if e := checkStreamable(q, cdcs.out); e != nil {
+		return nil, e
+	}
+
+	if q.meta != nil {
+		q.meta.Type = resultType(descs.Out)
+	}
+
 	c.inCodecCache.Put(cdcs.in.DescriptorID(), cdcs.in)
 	c.outCodecCache.Put(
 		codecKey{ID: cdcs.out.DescriptorID(), Type: q.outType},
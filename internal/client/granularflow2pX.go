@@ -18,7 +18,6 @@ package edgedb
 
 import (
 	"fmt"
-	"reflect"
 
 	"github.com/sebastiean/edgedb-go/internal/buff"
 	"github.com/sebastiean/edgedb-go/internal/codecs"
@@ -32,17 +31,31 @@ func (c *protocolConnection) execGranularFlow2pX(
 ) error {
 	ids, ok := c.getCachedTypeIDs(q)
 	if !ok {
+		c.stats.query(false)
 		return c.pesimistic2pX(r, q)
 	}
+	q.descIDs = *ids
 
 	cdcs, err := c.codecsFromIDsV2(ids, q)
 	if err != nil {
 		return err
 	} else if cdcs == nil {
+		c.stats.query(false)
 		return c.pesimistic2pX(r, q)
 	}
 
-	return c.execute2pX(r, q, cdcs)
+	c.stats.query(true)
+	err = c.execute2pX(r, q, cdcs)
+	if isStaleDescriptorError(err) && !q.streamed {
+		c.stats.retry()
+		// The schema changed after the query's type descriptors were
+		// cached. Describe the query again and retry it once.
+		c.invalidateTypeIDs()
+		q.resetAttempt()
+		return c.pesimistic2pX(r, q)
+	}
+
+	return err
 }
 
 func (c *protocolConnection) pesimistic2pX(r *buff.Reader, q *query) error {
@@ -65,10 +78,10 @@ func (c *protocolConnection) parse2pX(
 ) (*CommandDescriptionV2, error) {
 	w := buff.NewWriter(c.writeMemory[:0])
 	w.BeginMessage(uint8(Parse))
-	w.PushUint16(0) // no headers
+	writeAnnotations(w, q.annotations)
 	w.PushUint64(q.capabilities)
-	w.PushUint64(0) // no compilation_flags
-	w.PushUint64(0) // no implicit limit
+	w.PushUint64(q.settings.compilationFlags())
+	w.PushUint64(q.settings.implicitLimit)
 	w.PushUint8(uint8(q.fmt))
 	w.PushUint8(uint8(q.expCard))
 	w.PushString(q.cmd)
@@ -125,20 +138,19 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg2pX(
 	r *buff.Reader,
 	q *query,
 ) (*CommandDescriptionV2, error) {
-	discardHeaders(r)
+	warnings, err := decodeAnnotations(r)
+	if err != nil {
+		return nil, err
+	}
 	c.cacheCapabilities1pX(q, r.PopUint64())
 
-	var (
-		err   error
-		descs CommandDescriptionV2
-	)
+	var descs CommandDescriptionV2
 
 	descs.Card = Cardinality(r.PopUint8())
 	id := r.PopUUID()
-	descs.In, err = descriptor.PopV2(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	in := r.PopSlice(r.PopUint32())
+	inData := in.Buf
+	descs.In, err = descriptor.PopV2(in, c.protocolVersion)
 	if err != nil {
 		return nil, err
 	} else if descs.In.ID != id {
@@ -149,10 +161,9 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg2pX(
 	}
 
 	id = r.PopUUID()
-	descs.Out, err = descriptor.PopV2(
-		r.PopSlice(r.PopUint32()),
-		c.protocolVersion,
-	)
+	out := r.PopSlice(r.PopUint32())
+	outData := out.Buf
+	descs.Out, err = descriptor.PopV2(out, c.protocolVersion)
 	if err != nil {
 		return nil, err
 	} else if descs.Out.ID != id {
@@ -171,7 +182,17 @@ func (c *protocolConnection) decodeCommandDataDescriptionMsg2pX(
 			q.expCard)}
 	}
 
-	c.cacheTypeIDs(q, idPair{in: descs.In.ID, out: descs.Out.ID})
+	ids := idPair{
+		in:       descs.In.ID,
+		out:      descs.Out.ID,
+		card:     descs.Card,
+		warnings: warnings,
+	}
+	q.descIDs = ids
+	c.cacheTypeIDs(q, ids)
+	c.descriptors.record(c.protocolVersion, q, ids, inData, outData)
+	descs.inData = append([]byte(nil), inData...)
+	descs.outData = append([]byte(nil), outData...)
 	descCache.Put(descs.In.ID, descs.In)
 	descCache.Put(descs.Out.ID, descs.Out)
 	return &descs, nil
@@ -183,11 +204,11 @@ func (c *protocolConnection) execute2pX(
 	cdcs *codecPair,
 ) error {
 	w := buff.NewWriter(c.writeMemory[:0])
-	w.BeginMessage(uint8(Execute))
-	w.PushUint16(0) // no headers
+	w.BeginMessage(uint8(Execute1pX))
+	writeAnnotations(w, q.annotations)
 	w.PushUint64(q.capabilities)
-	w.PushUint64(0) // no compilation_flags
-	w.PushUint64(0) // no implicit limit
+	w.PushUint64(q.settings.compilationFlags())
+	w.PushUint64(q.settings.implicitLimit)
 	w.PushUint8(uint8(q.fmt))
 	w.PushUint8(uint8(q.expCard))
 	w.PushString(q.cmd)
@@ -200,19 +221,21 @@ func (c *protocolConnection) execute2pX(
 
 	w.PushUUID(cdcs.in.DescriptorID())
 	w.PushUUID(cdcs.out.DescriptorID())
-	if e := cdcs.in.Encode(w, q.args, codecs.Path("args"), true); e != nil {
-		return &invalidArgumentError{msg: e.Error()}
+	if e := c.encodeArgs(w, q, cdcs.in); e != nil {
+		return e
 	}
 	w.EndMessage()
 
 	w.BeginMessage(uint8(Sync))
 	w.EndMessage()
 
-	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+	q.streamed = w.Streaming()
+	if e := c.soc.WriteBuffer(w); e != nil {
 		return &clientConnectionClosedError{err: e}
 	}
 
 	tmp := q.out
+	defer streamResult(r, q)()
 	if q.expCard == AtMostOne {
 		err = errZeroResults
 	}
@@ -225,12 +248,25 @@ func (c *protocolConnection) execute2pX(
 				err = wrapAll(err, e)
 			}
 		case CommandDataDescription:
-			descs, e := c.decodeCommandDataDescriptionMsg1pX(r, q)
+			// The result type changed after it was cached.
+			c.invalidateTypeIDs()
+			descs, e := c.decodeCommandDataDescriptionMsg2pX(r, q)
 			err = wrapAll(err, e)
-			cdcs, e = c.codecsFromDescriptors1pX(q, descs)
+			cdcs, e = c.codecsFromDescriptors2pX(q, descs)
+			if e != nil {
+				// Don't stream a result that doesn't match q.writer.
+				r.StreamTo(0, 0, nil)
+			}
 			err = wrapAll(err, e)
 		case Data:
-			val, ok, e := decodeDataMsg(r, q, cdcs)
+			if err != nil && err != errZeroResults {
+				// Only keep the rows decoded before the first error.
+				r.Discard(len(r.Buf))
+				break
+			}
+
+			var e error
+			tmp, e = decodeDataMsg(r, q, cdcs, tmp)
 			if e != nil {
 				if err == errZeroResults {
 					err = e
@@ -238,9 +274,6 @@ func (c *protocolConnection) execute2pX(
 					err = wrapAll(err, e)
 				}
 			}
-			if ok {
-				tmp = reflect.Append(tmp, val)
-			}
 
 			if err == errZeroResults {
 				err = nil
@@ -267,12 +300,11 @@ func (c *protocolConnection) execute2pX(
 	}
 
 	if r.Err != nil {
+		q.setResult(tmp, r.Err)
 		return r.Err
 	}
 
-	if !q.flat() && q.fmt != Null {
-		q.out.Set(tmp)
-	}
+	q.setResult(tmp, err)
 
 	return err
 }
@@ -297,6 +329,20 @@ func (c *protocolConnection) codecsFromIDsV2(
 		}
 	}
 
+	if q.meta != nil {
+		typ, OK := cachedResultType(ids.out)
+		if !OK {
+			// Describe the query to get its result type.
+			return nil, nil
+		}
+		q.meta.Type = typ
+	}
+
+	if q.fmt == JSON || q.fmt == JSONElements {
+		// JSON results are passed through without being decoded.
+		return &codecPair{in: in.(codecs.Encoder), out: codecs.JSONBytes}, nil
+	}
+
 	out, ok := c.outCodecCache.Get(codecKey{ID: ids.out, Type: q.outType})
 	if !ok {
 		desc, OK := descCache.Get(ids.out)
@@ -306,13 +352,22 @@ func (c *protocolConnection) codecsFromIDsV2(
 
 		d := desc.(descriptor.V2)
 		path := codecs.Path(q.outType.String())
-		out, err = codecs.BuildDecoderV2(&d, q.outType, path)
+		out, err = codecs.BuildDecoderV2(
+			&d,
+			q.outType,
+			path,
+			c.decoderOptions,
+		)
 		if err != nil {
 			return nil, &invalidArgumentError{msg: fmt.Sprintf(
 				"the \"out\" argument does not match query schema: %v", err)}
 		}
 	}
 
+	if e := checkStreamable(q, out.(codecs.Decoder)); e != nil {
+		return nil, e
+	}
+
 	return &codecPair{in: in.(codecs.Encoder), out: out.(codecs.Decoder)}, nil
 }
 
@@ -327,7 +382,7 @@ func (c *protocolConnection) codecsFromDescriptors2pX(
 		return nil, &invalidArgumentError{msg: err.Error()}
 	}
 
-	if q.fmt == JSON {
+	if q.fmt == JSON || q.fmt == JSONElements {
 		cdcs.out = codecs.JSONBytes
 	} else {
 		var path codecs.Path
@@ -338,7 +393,12 @@ func (c *protocolConnection) codecsFromDescriptors2pX(
 			path = codecs.Path(q.outType.String())
 		}
 
-		cdcs.out, err = codecs.BuildDecoderV2(&descs.Out, q.outType, path)
+		cdcs.out, err = codecs.BuildDecoderV2(
+			&descs.Out,
+			q.outType,
+			path,
+			c.decoderOptions,
+		)
 		if err != nil {
 			err = fmt.Errorf(
 				"the \"out\" argument does not match query schema: %v",
@@ -348,6 +408,14 @@ func (c *protocolConnection) codecsFromDescriptors2pX(
 		}
 	}
 
+	if e := checkStreamable(q, cdcs.out); e != nil {
+		return nil, e
+	}
+
+	if q.meta != nil {
+		q.meta.Type = resultTypeV2(descs.Out)
+	}
+
 	c.inCodecCache.Put(cdcs.in.DescriptorID(), cdcs.in)
 	c.outCodecCache.Put(
 		codecKey{ID: cdcs.out.DescriptorID(), Type: q.outType},
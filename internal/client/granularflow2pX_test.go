@@ -0,0 +1,113 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/snc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecsFromIDsDoesNotDecodeJSON(t *testing.T) {
+	c := &protocolConnection{
+		cacheCollection: newCacheCollection(
+			snc.NewServerSettings(), 10, codecs.DecoderOptions{}),
+		protocolVersion: protocolVersion2p0,
+	}
+
+	// The out codec is not cached, e.g. because it was evicted.
+	descCache.Put(descriptor.IDZero, descriptor.V2{})
+	descCache.Put(codecs.StrID, descriptor.V2{
+		Type: descriptor.BaseScalar,
+		ID:   codecs.StrID,
+	})
+	ids := idPair{in: descriptor.IDZero, out: codecs.StrID}
+
+	for _, format := range []Format{JSON, JSONElements} {
+		q := &query{fmt: format, outType: bytesType}
+		cdcs, err := c.codecsFromIDsV2(&ids, q)
+		require.NoError(t, err)
+		require.NotNil(t, cdcs)
+		assert.Same(t, codecs.JSONBytes, cdcs.out)
+	}
+}
+
+func TestCodecsFromIDsRejectsStreamingNonBytes(t *testing.T) {
+	c := &protocolConnection{
+		cacheCollection: newCacheCollection(
+			snc.NewServerSettings(), 10, codecs.DecoderOptions{}),
+		protocolVersion: protocolVersion2p0,
+	}
+
+	descCache.Put(descriptor.IDZero, descriptor.V2{})
+	for _, id := range []types.UUID{codecs.BytesID, codecs.JSONID} {
+		descCache.Put(id, descriptor.V2{Type: descriptor.Scalar, ID: id})
+	}
+
+	var out bytes.Buffer
+	q := &query{fmt: Binary, outType: bytesType, writer: &out}
+
+	ids := idPair{in: descriptor.IDZero, out: codecs.BytesID}
+	cdcs, err := c.codecsFromIDsV2(&ids, q)
+	require.NoError(t, err)
+	require.NotNil(t, cdcs)
+
+	ids = idPair{in: descriptor.IDZero, out: codecs.JSONID}
+	_, err = c.codecsFromIDsV2(&ids, q)
+	var edbErr Error
+	require.ErrorAs(t, err, &edbErr)
+	assert.True(t, edbErr.Category(InvalidArgumentError), err)
+}
+
+func TestCodecsFromIDsSetsResultType(t *testing.T) {
+	c := &protocolConnection{
+		cacheCollection: newCacheCollection(
+			snc.NewServerSettings(), 10, codecs.DecoderOptions{}),
+		protocolVersion: protocolVersion2p0,
+	}
+
+	descCache.Put(descriptor.IDZero, descriptor.V2{})
+	descCache.Put(codecs.StrID, descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   codecs.StrID,
+		Name: "std::str",
+	})
+	q := &query{
+		fmt:     Binary,
+		outType: reflect.TypeOf(""),
+		meta:    &ResultMeta{},
+	}
+
+	ids := idPair{in: descriptor.IDZero, out: codecs.StrID}
+	cdcs, err := c.codecsFromIDsV2(&ids, q)
+	require.NoError(t, err)
+	require.NotNil(t, cdcs)
+	assert.Equal(t, ResultType{Name: "std::str", Kind: "scalar"}, q.meta.Type)
+
+	// The out descriptor was evicted, so the query must be described again.
+	ids.out = types.UUID{0xff}
+	cdcs, err = c.codecsFromIDsV2(&ids, q)
+	require.NoError(t, err)
+	assert.Nil(t, cdcs)
+}
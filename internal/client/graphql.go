@@ -0,0 +1,98 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Globals   map[string]interface{} `json:"globals,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// QueryGraphQL runs a GraphQL query using the server's GraphQL extension and
+// decodes the response's data into out using encoding/json. The request is
+// sent to the same instance and database as the client's EdgeDB connections
+// using the same credentials and TLS settings. The client's globals are sent
+// with the request. The GraphQL extension must be enabled in the schema.
+//
+//	var data struct {
+//	    User []struct {
+//	        Name string `json:"name"`
+//	    } `json:"User"`
+//	}
+//	err := client.QueryGraphQL(ctx, `{ User { name } }`, nil, &data)
+func (p *Client) QueryGraphQL(
+	ctx context.Context,
+	query string,
+	variables map[string]interface{},
+	out interface{},
+) error {
+	body := graphQLRequest{
+		Query:     query,
+		Variables: variables,
+		Globals:   p.globals(),
+	}
+
+	resp, err := p.postHTTP(ctx, "graphql", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	var result graphQLResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return &protocolError{err: fmt.Errorf(
+			"decoding GraphQL response: %w", err)}
+	}
+
+	if len(result.Errors) > 0 {
+		msgs := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			msgs[i] = e.Message
+		}
+		return &queryError{msg: strings.Join(msgs, "; ")}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &clientError{msg: "GraphQL request failed: " + resp.Status}
+	}
+
+	if out == nil || len(result.Data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return &invalidArgumentError{err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryGraphQL(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/db/main/graphql", r.URL.Path)
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "edgedb", user)
+			assert.Equal(t, "secret", password)
+
+			var req graphQLRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, map[string]interface{}{"x": "y"}, req.Globals)
+
+			if req.Query == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(
+					`{"errors": [{"message": "a"}, {"message": "b"}]}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data": {"User": [{"name": "bob"}]}}`))
+		},
+	))
+	defer srv.Close()
+
+	client := httpTestClient(srv)
+	derived := client.WithGlobals(map[string]interface{}{"x": "y"})
+
+	var data struct {
+		User []struct {
+			Name string `json:"name"`
+		} `json:"User"`
+	}
+	ctx := context.Background()
+	err := derived.QueryGraphQL(ctx, "{ User { name } }", nil, &data)
+	require.NoError(t, err)
+	require.Len(t, data.User, 1)
+	assert.Equal(t, "bob", data.User[0].Name)
+
+	err = derived.QueryGraphQL(ctx, "bad", nil, &data)
+	assert.EqualError(t, err, "edgedb.QueryError: a; b")
+}
+
+// httpTestClient returns a client that sends http extension requests to srv.
+func httpTestClient(srv *httptest.Server) *Client {
+	return &Client{
+		pool: &pool{httpOnce: &sync.Once{}},
+		cfg: &connConfig{
+			addr:        dialArgs{"tcp", srv.Listener.Addr().String()},
+			user:        "edgedb",
+			password:    "secret",
+			database:    "main",
+			tlsSecurity: "strict",
+			tlsCAData: pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: srv.Certificate().Raw,
+			}),
+		},
+	}
+}
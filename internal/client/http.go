@@ -0,0 +1,138 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// postHTTP sends body encoded as json to an http extension endpoint
+// in the configured database. The caller must close the response body.
+func (p *Client) postHTTP(
+	ctx context.Context,
+	endpoint string,
+	body interface{},
+) (*http.Response, error) {
+	httpClient, err := p.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, &invalidArgumentError{err: err}
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, p.cfg.httpURL(endpoint), bytes.NewReader(data))
+	if err != nil {
+		return nil, &clientError{err: err}
+	}
+
+	cfg, err := p.cfg.resolveAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if auth := cfg.httpAuthorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &clientConnectionError{err: err}
+	}
+
+	return resp, nil
+}
+
+// globals returns the client's global variable values.
+func (p *Client) globals() map[string]interface{} {
+	if globals, ok := p.state["globals"]; ok {
+		return globals.(map[string]interface{})
+	}
+
+	return nil
+}
+
+// httpClient returns the http client used for requests to http extensions
+// like GraphQL. It is created on first use and shared by derived clients.
+func (p *Client) httpClient() (*http.Client, error) {
+	p.httpOnce.Do(func() {
+		tlsConfig, err := p.cfg.tlsConfig()
+		if err != nil {
+			p.httpErr = &configurationError{err: err}
+			return
+		}
+
+		// The edgedb-binary protocol is not used for http requests.
+		tlsConfig.NextProtos = nil
+		p.http = &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}}
+	})
+
+	return p.http, p.httpErr
+}
+
+// httpURL returns the URL for an http extension endpoint
+// in the configured database.
+func (c *connConfig) httpURL(endpoint string) string {
+	return (&url.URL{
+		Scheme: "https",
+		Host:   c.addr.address,
+		Path:   "/db/" + c.database + "/" + endpoint,
+	}).String()
+}
+
+// httpAuthorization returns the Authorization header value
+// for http requests.
+func (c *connConfig) httpAuthorization() string {
+	if c.secretKey != "" {
+		return "Bearer " + c.secretKey
+	}
+
+	if c.user == "" {
+		return ""
+	}
+
+	creds := c.user + ":" + c.password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds))
+}
+
+// httpError returns an error for an unsuccessful http extension response.
+func httpError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	if err == nil && body.Error.Message != "" {
+		return &queryError{msg: body.Error.Message}
+	}
+
+	return &clientError{msg: "http request failed: " + resp.Status}
+}
@@ -29,6 +29,10 @@ type CommandDescription struct {
 	In   descriptor.Descriptor
 	Out  descriptor.Descriptor
 	Card Cardinality
+
+	// inData and outData are the encoded In and Out descriptors.
+	inData  []byte
+	outData []byte
 }
 
 // CommandDescriptionV2 is the information returned in the
@@ -37,6 +41,10 @@ type CommandDescriptionV2 struct {
 	In   descriptor.V2
 	Out  descriptor.V2
 	Card Cardinality
+
+	// inData and outData are the encoded In and Out descriptors.
+	inData  []byte
+	outData []byte
 }
 
 // Describe returns CommandDescription for the provided cmd.
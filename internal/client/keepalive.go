@@ -0,0 +1,179 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+)
+
+// maxPingTimeout is the longest a keepalive check holds an idle connection.
+// A caller that acquires the connection during a check waits for the check
+// to finish, so checks are kept short even if keepAlive is long.
+const maxPingTimeout = time.Second
+
+// pingTimeout returns the timeout of a keepalive check.
+func pingTimeout(keepAlive time.Duration) time.Duration {
+	if keepAlive < maxPingTimeout {
+		return keepAlive
+	}
+
+	return maxPingTimeout
+}
+
+// idle keeps an idle connection until it is acquired. The connection is
+// closed if it is not acquired within timeout, or if it fails a keepalive
+// check. It is checked keepAlive after it became idle or was last checked.
+// A timeout or keepAlive of zero or less is disabled.
+func (p *Client) idle(
+	conn *transactableConn,
+	timeout time.Duration,
+	keepAlive time.Duration,
+	cancel <-chan struct{},
+	connChan chan<- *transactableConn,
+) {
+	clock := p.cfg.timeSource()
+	var expired, check <-chan time.Time
+	if timeout > 0 {
+		timer := clock.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C()
+	}
+
+	// The check timer is started again after each keepalive check.
+	var checkTimer Timer
+	if keepAlive > 0 {
+		checkTimer = clock.NewTimer(keepAlive)
+		check = checkTimer.C()
+	}
+	defer func() {
+		if checkTimer != nil {
+			checkTimer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-cancel:
+			connChan <- conn
+			return
+		case <-expired:
+			p.closeIdle(conn, connChan, "idle")
+			return
+		case <-check:
+			ctx, stop := context.WithTimeout(
+				context.Background(),
+				pingTimeout(keepAlive),
+			)
+			err := conn.ping(ctx)
+			stop()
+			if err != nil {
+				p.closeIdle(conn, connChan, "unresponsive")
+				return
+			}
+
+			checkTimer = clock.NewTimer(keepAlive)
+			check = checkTimer.C()
+		}
+	}
+}
+
+// idleTimeout returns how long a connection is kept idle given the server's
+// session_idle_timeout. Connections are closed a little before the server
+// would close them so that queries are not sent to connections that the
+// server is about to drop.
+func idleTimeout(sessionIdleTimeout time.Duration) time.Duration {
+	if sessionIdleTimeout <= 0 {
+		return sessionIdleTimeout
+	}
+
+	margin := sessionIdleTimeout / 10
+	if margin > time.Second {
+		margin = time.Second
+	}
+
+	return sessionIdleTimeout - margin
+}
+
+// closeIdle closes an idle connection that will not be acquired.
+func (p *Client) closeIdle(
+	conn *transactableConn,
+	connChan chan<- *transactableConn,
+	reason string,
+) {
+	connChan <- nil
+	p.potentialConns <- struct{}{}
+	if e := conn.Close(); e != nil {
+		log.Printf("error while closing %v connection: %v", reason, e)
+	}
+}
+
+// ping checks that the connection is still usable without reconnecting.
+func (c *reconnectingConn) ping(ctx context.Context) error {
+	if c.isClosed || c.conn == nil || c.conn.isClosed() {
+		return &clientConnectionClosedError{}
+	}
+
+	return c.conn.ping(ctx)
+}
+
+// ping sends a Sync message and waits for the server to be ready for the
+// next command.
+func (c *protocolConnection) ping(ctx context.Context) error {
+	r, err := c.acquireReader(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if e := c.soc.SetDeadline(deadline); e != nil {
+		return &clientConnectionClosedError{err: e}
+	}
+
+	w := buff.NewWriter(c.writeMemory[:0])
+	w.BeginMessage(uint8(Sync))
+	w.EndMessage()
+
+	if e := c.soc.WriteAll(w.Unwrap()); e != nil {
+		return &clientConnectionClosedError{err: e}
+	}
+
+	done := buff.NewSignal()
+	for r.Next(done.Chan) {
+		switch Message(r.MsgType) {
+		case ReadyForCommand:
+			decodeReadyForCommandMsg(r)
+			done.Signal()
+		case ErrorResponse:
+			err = wrapAll(err, decodeErrorResponseMsg(r, ""))
+		default:
+			if e := c.fallThrough(r); e != nil {
+				// the connection will not be usable after this x_x
+				return e
+			}
+		}
+	}
+
+	if r.Err != nil {
+		return wrapMessageTooLarge(&clientConnectionClosedError{err: r.Err})
+	}
+
+	return firstError(err, c.releaseReader(r))
+}
@@ -0,0 +1,79 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleClosesUnresponsiveConnection(t *testing.T) {
+	p := &Client{pool: newPool(1)}
+	p.potentialConns = make(chan struct{}, 1)
+
+	// The connection is not connected so the keepalive check fails.
+	conn := &transactableConn{reconnectingConn: &reconnectingConn{}}
+	cancel := make(chan struct{}, 1)
+	connChan := make(chan *transactableConn, 1)
+	go p.idle(conn, 0, time.Millisecond, cancel, connChan)
+
+	select {
+	case <-p.potentialConns:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the connection was not closed")
+	}
+
+	assert.Nil(t, <-connChan)
+}
+
+func TestIdleReturnsAcquiredConnection(t *testing.T) {
+	p := &Client{pool: newPool(1)}
+	conn := &transactableConn{reconnectingConn: &reconnectingConn{}}
+	cancel := make(chan struct{}, 1)
+	connChan := make(chan *transactableConn, 1)
+	go p.idle(conn, time.Hour, 0, cancel, connChan)
+
+	cancel <- struct{}{}
+	assert.Same(t, conn, <-connChan)
+	assert.False(t, conn.isClosed)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	samples := []struct {
+		session  time.Duration
+		expected time.Duration
+	}{
+		{0, 0},
+		{-time.Second, -time.Second},
+		{time.Second, 900 * time.Millisecond},
+		{10 * time.Second, 9 * time.Second},
+		{time.Minute, 59 * time.Second},
+	}
+
+	for _, s := range samples {
+		assert.Equal(t, s.expected, idleTimeout(s.session), s.session)
+	}
+}
+
+func TestPingTimeout(t *testing.T) {
+	assert.Equal(t, time.Millisecond, pingTimeout(time.Millisecond))
+	assert.Equal(t, time.Second, pingTimeout(time.Second))
+	assert.Equal(t, time.Second, pingTimeout(time.Minute))
+}
@@ -0,0 +1,222 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const keysetArgName = "__keyset"
+
+// KeysetKey is an ordering key used for keyset pagination.
+type KeysetKey struct {
+	// Expr is the expression the results are ordered by, e.g. ".created_at".
+	Expr string
+
+	// Type is the EdgeQL type of Expr, e.g. "datetime" or "uuid".
+	Type string
+
+	// Descending is true if the results are ordered by Expr descending.
+	Descending bool
+}
+
+// NewKeyset returns a Keyset that signs continuation tokens with secret.
+// At least one key is required. Keys must uniquely identify a result,
+// typically this means the last key is .id.
+func NewKeyset(secret []byte, keys ...KeysetKey) (*Keyset, error) {
+	if len(secret) == 0 {
+		return nil, &invalidArgumentError{
+			msg: "the keyset secret must not be empty"}
+	}
+
+	if len(keys) == 0 {
+		return nil, &invalidArgumentError{
+			msg: "a keyset requires at least one key"}
+	}
+
+	for _, key := range keys {
+		if key.Expr == "" || key.Type == "" {
+			return nil, &invalidArgumentError{
+				msg: fmt.Sprintf("invalid keyset key: %+v", key)}
+		}
+	}
+
+	return &Keyset{
+		secret: append([]byte(nil), secret...),
+		keys:   append([]KeysetKey(nil), keys...),
+	}, nil
+}
+
+// Keyset generates opaque signed continuation tokens for keyset pagination
+// and rewrites query filters to resume after the position a token encodes.
+// Queries using a Keyset must use named arguments.
+//
+//	keyset, err := edgedb.NewKeyset(secret,
+//	    edgedb.KeysetKey{Expr: ".created_at", Type: "datetime"},
+//	    edgedb.KeysetKey{Expr: ".id", Type: "uuid"},
+//	)
+//
+//	filter, args, err := keyset.Filter(token, nil)
+//	query := "SELECT Post {id, created_at} FILTER " + filter +
+//	    " " + keyset.OrderBy() + " LIMIT 20"
+//	err = client.Query(ctx, query, &posts, args)
+//
+//	last := posts[len(posts)-1]
+//	next, err := keyset.Token(last.CreatedAt, last.ID)
+type Keyset struct {
+	secret []byte
+	keys   []KeysetKey
+}
+
+// OrderBy returns the ORDER BY clause matching the keyset's keys.
+func (k *Keyset) OrderBy() string {
+	terms := make([]string, len(k.keys))
+	for i, key := range k.keys {
+		terms[i] = key.Expr + " " + direction(key)
+	}
+
+	return "ORDER BY " + strings.Join(terms, " THEN ")
+}
+
+// Token returns a continuation token for the row with the ordering key
+// values. Values must be in the same order as the keyset's keys and must be
+// JSON serializable.
+func (k *Keyset) Token(values ...interface{}) (string, error) {
+	if len(values) != len(k.keys) {
+		return "", &invalidArgumentError{msg: fmt.Sprintf(
+			"expected %v keyset values got %v", len(k.keys), len(values))}
+	}
+
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", &invalidArgumentError{err: err}
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." +
+		enc.EncodeToString(k.sign(payload)), nil
+}
+
+// Filter returns a FILTER expression that selects the rows after the
+// position encoded in token and a copy of args with the argument it
+// references added. args is not modified and may be nil. An empty token
+// selects every row.
+func (k *Keyset) Filter(
+	token string,
+	args map[string]interface{},
+) (string, map[string]interface{}, error) {
+	if _, ok := args[keysetArgName]; ok {
+		return "", nil, &invalidArgumentError{msg: fmt.Sprintf(
+			"the %q argument name is reserved for keyset pagination",
+			keysetArgName)}
+	}
+
+	named := make(map[string]interface{}, len(args)+1)
+	for name, val := range args {
+		named[name] = val
+	}
+
+	if token == "" {
+		return "true", named, nil
+	}
+
+	payload, err := k.verify(token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	named[keysetArgName] = payload
+
+	// Build (a > $a) OR (a = $a AND b > $b) ...
+	// Tuple comparison can not be used
+	// because keys may be ordered in different directions.
+	var terms []string
+	for i, key := range k.keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf(
+				"%v = %v", k.keys[j].Expr, k.value(j)))
+		}
+
+		op := ">"
+		if key.Descending {
+			op = "<"
+		}
+
+		parts = append(parts,
+			fmt.Sprintf("%v %v %v", key.Expr, op, k.value(i)))
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")", named, nil
+}
+
+func (k *Keyset) value(i int) string {
+	return fmt.Sprintf(
+		"<%v>json_get(<json>$%v, '%v')", k.keys[i].Type, keysetArgName, i)
+}
+
+func (k *Keyset) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(payload) // nolint:errcheck
+	return mac.Sum(nil)
+}
+
+func (k *Keyset) verify(token string) ([]byte, error) {
+	invalid := &invalidArgumentError{msg: "invalid keyset token"}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+
+	signature, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, invalid
+	}
+
+	if !hmac.Equal(signature, k.sign(payload)) {
+		return nil, invalid
+	}
+
+	var values []json.RawMessage
+	if e := json.Unmarshal(payload, &values); e != nil ||
+		len(values) != len(k.keys) {
+		return nil, invalid
+	}
+
+	return payload, nil
+}
+
+func direction(key KeysetKey) string {
+	if key.Descending {
+		return "DESC"
+	}
+
+	return "ASC"
+}
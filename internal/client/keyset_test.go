@@ -0,0 +1,135 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysetFilter(t *testing.T) {
+	keyset, err := NewKeyset(
+		[]byte("secret"),
+		KeysetKey{Expr: ".name", Type: "str"},
+		KeysetKey{Expr: ".id", Type: "int64", Descending: true},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ORDER BY .name ASC THEN .id DESC", keyset.OrderBy())
+
+	filter, args, err := keyset.Filter("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "true", filter)
+	assert.Equal(t, map[string]interface{}{}, args)
+
+	token, err := keyset.Token("b", 2)
+	require.NoError(t, err)
+
+	_, err = keyset.Token(1)
+	assert.EqualError(t, err,
+		"edgedb.InvalidArgumentError: expected 2 keyset values got 1")
+
+	in := map[string]interface{}{"limit": 10}
+	filter, args, err = keyset.Filter(token, in)
+	require.NoError(t, err)
+	assert.Equal(t, "("+
+		"(.name > <str>json_get(<json>$__keyset, '0')) OR "+
+		"(.name = <str>json_get(<json>$__keyset, '0') AND "+
+		".id < <int64>json_get(<json>$__keyset, '1')))", filter)
+	assert.Equal(t, map[string]interface{}{
+		"limit":    10,
+		"__keyset": []byte(`["b",2]`),
+	}, args)
+	assert.Equal(t, map[string]interface{}{"limit": 10}, in,
+		"the caller's args must not be modified")
+
+	_, _, err = keyset.Filter(token, args)
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		`the "__keyset" argument name is reserved for keyset pagination`)
+}
+
+func TestNewKeysetInvalid(t *testing.T) {
+	key := KeysetKey{Expr: ".id", Type: "uuid"}
+
+	_, err := NewKeyset(nil, key)
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		"the keyset secret must not be empty")
+
+	_, err = NewKeyset([]byte("secret"))
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		"a keyset requires at least one key")
+
+	_, err = NewKeyset([]byte("secret"), KeysetKey{Expr: ".id"})
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		"invalid keyset key: {Expr:.id Type: Descending:false}")
+}
+
+func TestKeysetRejectsTamperedTokens(t *testing.T) {
+	key := KeysetKey{Expr: ".id", Type: "uuid"}
+	keyset, err := NewKeyset([]byte("secret"), key)
+	require.NoError(t, err)
+	other, err := NewKeyset([]byte("other"), key)
+	require.NoError(t, err)
+
+	token, err := other.Token("00000000-0000-0000-0000-000000000001")
+	require.NoError(t, err)
+
+	for _, tok := range []string{token, "garbage", token + "x", "a.b.c"} {
+		_, _, err = keyset.Filter(tok, nil)
+		assert.EqualError(t, err,
+			"edgedb.InvalidArgumentError: invalid keyset token")
+	}
+
+	_, err = keyset.Token(1, 2)
+	assert.EqualError(t, err,
+		"edgedb.InvalidArgumentError: expected 1 keyset values got 2")
+}
+
+func TestKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	keyset, err := NewKeyset(
+		[]byte("secret"), KeysetKey{Expr: "x", Type: "int64"})
+	require.NoError(t, err)
+
+	var (
+		token string
+		pages [][]int64
+	)
+
+	for {
+		filter, args, err := keyset.Filter(token, nil)
+		require.NoError(t, err)
+
+		var page []int64
+		err = client.Query(ctx, "WITH x := {1, 2, 3, 4, 5} "+
+			"SELECT x FILTER "+filter+" "+keyset.OrderBy()+" LIMIT 2",
+			&page, args)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+
+		pages = append(pages, page)
+		token, err = keyset.Token(page[len(page)-1])
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, [][]int64{{1, 2}, {3, 4}, {5}}, pages)
+}
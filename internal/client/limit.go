@@ -0,0 +1,75 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithConcurrencyLimit returns a shallow copy of the client that runs at most
+// limit queries and transactions at a time. The returned client still shares
+// the connection pool with the client it was derived from, so a limit can
+// keep a client used for background jobs from taking every connection that
+// request handling clients need. Clients derived from the returned client
+// share its limit. A limit less than 1 removes the limit,
+// see WithoutConcurrencyLimit.
+func (p Client) WithConcurrencyLimit(limit int) *Client { // nolint:gocritic
+	if limit < 1 {
+		return p.WithoutConcurrencyLimit()
+	}
+
+	p.limit = make(chan struct{}, limit)
+	return &p
+}
+
+// WithoutConcurrencyLimit returns a shallow copy of the client without the
+// limit set by WithConcurrencyLimit. It is only limited by the size of the
+// connection pool.
+func (p Client) WithoutConcurrencyLimit() *Client { // nolint:gocritic
+	p.limit = nil
+	return &p
+}
+
+// acquireLimit waits until the client is below its concurrency limit.
+// releaseLimit must be called when the caller is done.
+func (p *Client) acquireLimit(ctx context.Context) error {
+	if p.limit == nil {
+		return nil
+	}
+
+	start := time.Now()
+	select {
+	case p.limit <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf(
+			"edgedb: %w after waiting %v for the client's concurrency limit "+
+				"(limit: %v)",
+			ctx.Err(),
+			time.Since(start).Round(time.Millisecond),
+			cap(p.limit),
+		)
+	}
+}
+
+func (p *Client) releaseLimit() {
+	if p.limit != nil {
+		<-p.limit
+	}
+}
@@ -0,0 +1,83 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	False := false
+	base := &Client{
+		isClosed:      &False,
+		isClosedMutex: &sync.RWMutex{},
+		pool:          newPool(2),
+	}
+	base.potentialConns = make(chan struct{}, 2)
+	free := func() {
+		conn := &transactableConn{reconnectingConn: &reconnectingConn{}}
+		base.freeConns <- func() *transactableConn { return conn }
+	}
+
+	limited := base.WithConcurrencyLimit(1)
+	derived := limited.WithPartialResults(true)
+	ctx := context.Background()
+
+	free()
+	_, err := limited.acquire(ctx)
+	require.NoError(t, err)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = derived.acquire(timeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Regexp(t, `^edgedb: context deadline exceeded after waiting `+
+		`\d+ms for the client's concurrency limit \(limit: 1\)$`, err)
+
+	free()
+	_, err = limited.WithoutConcurrencyLimit().acquire(ctx)
+	require.NoError(t, err, "the pool still has capacity")
+
+	limited.releaseLimit()
+	free()
+	_, err = derived.acquire(ctx)
+	require.NoError(t, err)
+
+	assert.Nil(t, limited.WithConcurrencyLimit(0).limit)
+	assert.Nil(t, limited.WithConcurrencyLimit(-1).limit)
+}
+
+func TestWithDatabaseKeepsConcurrencyLimit(t *testing.T) {
+	False := false
+	base := &Client{
+		isClosed:      &False,
+		isClosedMutex: &sync.RWMutex{},
+		pool:          newPool(2),
+		cfg:           &connConfig{},
+	}
+
+	limited := base.WithConcurrencyLimit(1)
+	other, err := limited.WithDatabase("other")
+	require.NoError(t, err)
+	assert.Equal(t, limited.limit, other.limit)
+}
@@ -0,0 +1,190 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"reflect"
+	"unsafe"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+)
+
+// WithLinkDeduplication returns a shallow copy of the client with linked
+// object deduplication enabled or disabled. When enabled, links that are
+// decoded into pointers to structs share one pointer for all objects of the
+// same struct type and id in a query's result, so an object that is linked
+// from many rows is kept in memory once. The struct type must have a field
+// for the object's id. Objects of the same struct type are expected to
+// select the same shape; only the first one decoded is kept. The elements of
+// a QueryIterator are not deduplicated. Deduplication is disabled by default.
+//
+//	var posts []struct {
+//		Title  string `edgedb:"title"`
+//		Author *User  `edgedb:"author"`
+//	}
+//	err := client.WithLinkDeduplication(true).Query(
+//		ctx, `SELECT Post { title, author: { id, name } }`, &posts)
+func (p Client) WithLinkDeduplication( // nolint:gocritic
+	enabled bool,
+) *Client {
+	p.queryOpts.linkIDs = nil
+	if enabled {
+		tag := p.decoderOptions.Tag()
+		match := p.decoderOptions.FieldMatch
+		p.queryOpts.linkIDs = func(typ reflect.Type) (uintptr, bool) {
+			sf, ok := introspect.TaggedStructField(typ, "id", tag, match)
+			if !ok || sf.Type != uuidType {
+				return 0, false
+			}
+			return sf.Offset, true
+		}
+	}
+	return &p
+}
+
+type linkKey struct {
+	typ reflect.Type
+	id  types.UUID
+}
+
+// linkDedup tracks the linked objects decoded by a query.
+type linkDedup struct {
+	// idOffsets maps struct types to the offset of their id field.
+	idOffsets map[reflect.Type]idOffset
+
+	// objects are the first pointers decoded for each linked object.
+	objects map[linkKey]reflect.Value
+}
+
+type idOffset struct {
+	offset uintptr
+	ok     bool
+}
+
+// dedupLinks replaces the pointers to linked objects in val with the first
+// pointer decoded for the same struct type and id.
+func (q *query) dedupLinks(val reflect.Value) {
+	if q.links == nil {
+		q.links = &linkDedup{
+			idOffsets: make(map[reflect.Type]idOffset),
+			objects:   make(map[linkKey]reflect.Value),
+		}
+	}
+
+	q.links.walk(val, q.linkIDs)
+}
+
+func (d *linkDedup) walk(
+	val reflect.Value,
+	linkIDs func(reflect.Type) (uintptr, bool),
+) {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() || val.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+
+		if shared, ok := d.share(val, linkIDs); ok {
+			val.Set(shared)
+			return
+		}
+
+		d.walk(val.Elem(), linkIDs)
+	case reflect.Interface:
+		if val.IsNil() {
+			return
+		}
+
+		elem := val.Elem()
+		if elem.Kind() != reflect.Ptr {
+			// values stored in interfaces can't be modified
+			return
+		}
+
+		if shared, ok := d.share(elem, linkIDs); ok {
+			val.Set(shared)
+			return
+		}
+
+		d.walk(elem, linkIDs)
+	case reflect.Struct:
+		typ := val.Type()
+		for i := 0; i < val.NumField(); i++ {
+			if typ.Field(i).IsExported() && mayHoldLinks(typ.Field(i).Type) {
+				d.walk(val.Field(i), linkIDs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if !mayHoldLinks(val.Type().Elem()) {
+			return
+		}
+
+		for i := 0; i < val.Len(); i++ {
+			d.walk(val.Index(i), linkIDs)
+		}
+	}
+}
+
+// share returns the first pointer that was decoded for the object ptr
+// points to. It returns false if ptr is the first pointer to the object or
+// if the object does not have an id.
+func (d *linkDedup) share(
+	ptr reflect.Value,
+	linkIDs func(reflect.Type) (uintptr, bool),
+) (reflect.Value, bool) {
+	typ := ptr.Type().Elem()
+	if ptr.IsNil() || typ.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	field, ok := d.idOffsets[typ]
+	if !ok {
+		field.offset, field.ok = linkIDs(typ)
+		d.idOffsets[typ] = field
+	}
+
+	if !field.ok {
+		return reflect.Value{}, false
+	}
+
+	id := *(*types.UUID)(unsafe.Add(ptr.UnsafePointer(), field.offset))
+	if id == (types.UUID{}) {
+		return reflect.Value{}, false
+	}
+
+	key := linkKey{typ, id}
+	if shared, ok := d.objects[key]; ok {
+		return shared, true
+	}
+
+	d.objects[key] = ptr
+	return reflect.Value{}, false
+}
+
+// mayHoldLinks returns false for types that can't contain pointers to
+// linked objects.
+func mayHoldLinks(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array:
+		return mayHoldLinks(typ.Elem())
+	default:
+		return false
+	}
+}
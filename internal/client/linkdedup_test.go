@@ -0,0 +1,87 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"reflect"
+	"testing"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dedupUser struct {
+	ID      types.UUID   `edgedb:"id"`
+	Name    string       `edgedb:"name"`
+	Friends []*dedupUser `edgedb:"friends"`
+}
+
+type dedupPost struct {
+	Title   string       `edgedb:"title"`
+	Author  *dedupUser   `edgedb:"author"`
+	Readers []*dedupUser `edgedb:"readers"`
+	Extra   interface{}  `edgedb:"extra"`
+}
+
+func TestDedupLinks(t *testing.T) {
+	client := (&Client{}).WithLinkDeduplication(true)
+	require.NotNil(t, client.queryOpts.linkIDs)
+	q := &query{queryOptions: client.queryOpts}
+
+	alice := func() *dedupUser {
+		return &dedupUser{ID: types.UUID{1}, Name: "alice"}
+	}
+	bob := func() *dedupUser {
+		u := &dedupUser{ID: types.UUID{2}, Name: "bob"}
+		u.Friends = []*dedupUser{alice()}
+		return u
+	}
+	anonymous := func() *dedupUser { return &dedupUser{Name: "anonymous"} }
+
+	posts := []dedupPost{
+		{Title: "a", Author: alice(), Readers: []*dedupUser{bob(), nil}},
+		{Title: "b", Author: bob(), Readers: []*dedupUser{alice(), alice()}},
+		{Title: "c", Author: anonymous(), Extra: bob()},
+		{Title: "d", Author: anonymous(), Extra: dedupUser{ID: types.UUID{1}}},
+	}
+
+	for i := range posts {
+		q.dedupLinks(reflect.ValueOf(&posts[i]).Elem())
+	}
+
+	first := posts[0].Author
+	assert.Same(t, first, posts[0].Readers[0].Friends[0])
+	assert.Same(t, first, posts[1].Readers[0])
+	assert.Same(t, first, posts[1].Readers[1])
+	assert.Same(t, posts[0].Readers[0], posts[1].Author)
+	assert.Same(t, posts[0].Readers[0], posts[2].Extra)
+	assert.Nil(t, posts[0].Readers[1])
+
+	// objects without an id are not shared
+	assert.NotSame(t, posts[2].Author, posts[3].Author)
+	assert.Equal(t, dedupUser{ID: types.UUID{1}}, posts[3].Extra)
+
+	// a retried query doesn't share the objects of the failed attempt
+	q.resetAttempt()
+	retried := dedupPost{Author: alice()}
+	q.dedupLinks(reflect.ValueOf(&retried).Elem())
+	assert.NotSame(t, first, retried.Author)
+
+	client = client.WithLinkDeduplication(false)
+	assert.Nil(t, client.queryOpts.linkIDs)
+}
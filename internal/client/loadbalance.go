@@ -0,0 +1,265 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadBalancer chooses the host that a new connection is made to when a
+// client has more than one host. See Options.Hosts.
+// Implementations must be safe for concurrent use.
+type LoadBalancer interface {
+	// Pick returns the index in hosts of the host to connect to. hosts only
+	// contains the hosts that are healthy, or all hosts if none of them are.
+	// A host is unhealthy if the last attempt to connect to it failed.
+	Pick(hosts []HostStats) int
+}
+
+// HostStats describes one of a client's hosts.
+type HostStats struct {
+	// Addr is the host's address as host:port.
+	Addr string
+
+	// InFlight is the number of the client's connections to the host that
+	// are currently running a query or transaction.
+	InFlight int
+
+	// Latency is a moving average of the time it took to open a network
+	// connection to the host. It is zero if the client has not connected to
+	// the host yet.
+	Latency time.Duration
+}
+
+// NewRoundRobinBalancer returns a LoadBalancer that picks each host in turn.
+func NewRoundRobinBalancer() LoadBalancer {
+	return &roundRobinBalancer{}
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+func (b *roundRobinBalancer) Pick(hosts []HostStats) int {
+	return int((atomic.AddUint64(&b.next, 1) - 1) % uint64(len(hosts)))
+}
+
+// NewLeastLoadedBalancer returns a LoadBalancer that picks the host with the
+// fewest queries in flight.
+func NewLeastLoadedBalancer() LoadBalancer {
+	return leastLoadedBalancer{}
+}
+
+type leastLoadedBalancer struct{}
+
+func (leastLoadedBalancer) Pick(hosts []HostStats) int {
+	best := 0
+	for i, host := range hosts {
+		if host.InFlight < hosts[best].InFlight {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// NewLatencyBalancer returns a LoadBalancer that picks the host with the
+// lowest connection latency. Hosts that have not been connected to yet are
+// picked first so that their latency is measured.
+func NewLatencyBalancer() LoadBalancer {
+	return latencyBalancer{}
+}
+
+type latencyBalancer struct{}
+
+func (latencyBalancer) Pick(hosts []HostStats) int {
+	best := 0
+	for i, host := range hosts {
+		if host.Latency < hosts[best].Latency {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// hostState is the state of one of a client's hosts.
+// A nil *hostState ignores all updates.
+type hostState struct {
+	addr dialArgs
+
+	// all fields below are guarded by the balancer's mutex
+	balancer *hostBalancer
+	inFlight int
+	latency  time.Duration
+	failed   bool
+}
+
+// begin records that a connection to the host started running a query.
+func (h *hostState) begin() {
+	if h == nil {
+		return
+	}
+
+	h.balancer.mutex.Lock()
+	h.inFlight++
+	h.balancer.mutex.Unlock()
+}
+
+// end records that a connection to the host finished running a query.
+func (h *hostState) end() {
+	if h == nil {
+		return
+	}
+
+	h.balancer.mutex.Lock()
+	h.inFlight--
+	h.balancer.mutex.Unlock()
+}
+
+// record records the result of connecting to the host with ctx.
+func (h *hostState) record(
+	ctx context.Context,
+	err error,
+	latency time.Duration,
+) {
+	if h == nil {
+		return
+	}
+
+	h.balancer.mutex.Lock()
+	defer h.balancer.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		h.failed = false
+		if h.latency == 0 {
+			h.latency = latency
+		} else {
+			h.latency = (4*h.latency + latency) / 5
+		}
+	case abandoned(ctx, err):
+		// It says nothing about the host.
+	default:
+		h.failed = true
+	}
+}
+
+// hostBalancer chooses between a client's hosts. The first host is the host
+// resolved from the client's options, DSN, environment or credentials.
+type hostBalancer struct {
+	mutex    sync.Mutex
+	strategy LoadBalancer
+	hosts    []*hostState
+
+	// fallback is picked if it is set and none of the hosts are healthy.
+	// The hosts are tried again by the next pick.
+	fallback *hostState
+}
+
+func newHostBalancer(
+	first dialArgs,
+	others []dialArgs,
+	strategy LoadBalancer,
+) *hostBalancer {
+	b := &hostBalancer{strategy: strategy}
+	for _, addr := range append([]dialArgs{first}, others...) {
+		b.hosts = append(b.hosts, &hostState{addr: addr, balancer: b})
+	}
+
+	return b
+}
+
+// pick returns the host to connect to. A nil *hostBalancer returns nil.
+func (b *hostBalancer) pick() *hostState {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	candidates := make([]*hostState, 0, len(b.hosts))
+	for _, host := range b.hosts {
+		if !host.failed {
+			candidates = append(candidates, host)
+		}
+	}
+
+	if len(candidates) == 0 && b.fallback != nil {
+		for _, host := range b.hosts {
+			host.failed = false
+		}
+
+		return b.fallback
+	}
+
+	if len(candidates) == 0 {
+		candidates = b.hosts
+	}
+
+	if b.strategy == nil || len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	stats := make([]HostStats, len(candidates))
+	for i, host := range candidates {
+		stats[i] = HostStats{
+			Addr:     host.addr.address,
+			InFlight: host.inFlight,
+			Latency:  host.latency,
+		}
+	}
+
+	i := b.strategy.Pick(stats)
+	if i < 0 || i >= len(candidates) {
+		i = 0
+	}
+
+	return candidates[i]
+}
+
+// parseHosts parses host or host:port addresses.
+// port is used for addresses without a port.
+func parseHosts(hosts []string, port int) ([]dialArgs, error) {
+	addrs := make([]dialArgs, 0, len(hosts))
+	for _, host := range hosts {
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			h, p = host, strconv.Itoa(port)
+		}
+
+		if h == "" {
+			return nil, fmt.Errorf("invalid host: %q", host)
+		}
+
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port in host %q", host)
+		}
+
+		addrs = append(addrs, dialArgs{"tcp", net.JoinHostPort(h, p)})
+	}
+
+	return addrs, nil
+}
@@ -0,0 +1,128 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHosts(t *testing.T) {
+	addrs, err := parseHosts([]string{"a", "b:1234", "[::1]:5"}, 5656)
+	require.NoError(t, err)
+	assert.Equal(t, []dialArgs{
+		{"tcp", "a:5656"},
+		{"tcp", "b:1234"},
+		{"tcp", "[::1]:5"},
+	}, addrs)
+
+	_, err = parseHosts([]string{"a:70000"}, 5656)
+	assert.EqualError(t, err, `invalid port in host "a:70000"`)
+
+	_, err = parseHosts([]string{""}, 5656)
+	assert.EqualError(t, err, `invalid host: ""`)
+}
+
+func addrs(hosts ...*hostState) []string {
+	result := make([]string, len(hosts))
+	for i, host := range hosts {
+		result[i] = host.addr.address
+	}
+	return result
+}
+
+func newTestBalancer(strategy LoadBalancer) *hostBalancer {
+	return newHostBalancer(
+		dialArgs{"tcp", "a:1"},
+		[]dialArgs{{"tcp", "b:1"}, {"tcp", "c:1"}},
+		strategy,
+	)
+}
+
+func TestFirstHealthyHost(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBalancer(nil)
+	failed := &clientConnectionFailedError{msg: "failed"}
+
+	assert.Equal(t, []string{"a:1", "a:1"}, addrs(b.pick(), b.pick()))
+
+	b.hosts[0].record(ctx, failed, 0)
+	assert.Equal(t, "b:1", b.pick().addr.address)
+
+	// context errors don't change the host's health
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.hosts[1].record(
+		canceled, &clientConnectionTimeoutError{err: canceled.Err()}, 0)
+	assert.Equal(t, "b:1", b.pick().addr.address)
+
+	b.hosts[1].record(ctx, failed, 0)
+	b.hosts[2].record(ctx, failed, 0)
+	assert.Equal(t, "a:1", b.pick().addr.address,
+		"all hosts are picked from if none are healthy")
+
+	b.hosts[0].record(ctx, nil, time.Millisecond)
+	assert.Equal(t, "a:1", b.pick().addr.address)
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBalancer(NewRoundRobinBalancer())
+	assert.Equal(t,
+		[]string{"a:1", "b:1", "c:1", "a:1"},
+		addrs(b.pick(), b.pick(), b.pick(), b.pick()),
+	)
+
+	b.hosts[1].record(ctx, &clientConnectionFailedError{msg: "failed"}, 0)
+	assert.Equal(t,
+		[]string{"a:1", "c:1", "a:1"},
+		addrs(b.pick(), b.pick(), b.pick()),
+	)
+}
+
+func TestLeastLoadedBalancer(t *testing.T) {
+	b := newTestBalancer(NewLeastLoadedBalancer())
+	assert.Equal(t, "a:1", b.pick().addr.address)
+
+	b.hosts[0].begin()
+	b.hosts[1].begin()
+	assert.Equal(t, "c:1", b.pick().addr.address)
+
+	b.hosts[0].end()
+	assert.Equal(t, "a:1", b.pick().addr.address)
+}
+
+func TestLatencyBalancer(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBalancer(NewLatencyBalancer())
+	b.hosts[0].record(ctx, nil, 30*time.Millisecond)
+	b.hosts[1].record(ctx, nil, 10*time.Millisecond)
+	assert.Equal(t, "c:1", b.pick().addr.address,
+		"hosts without a latency are picked first")
+
+	b.hosts[2].record(ctx, nil, 20*time.Millisecond)
+	assert.Equal(t, "b:1", b.pick().addr.address)
+
+	// latency is a moving average
+	b.hosts[1].record(ctx, nil, 110*time.Millisecond)
+	assert.Equal(t, 30*time.Millisecond, b.hosts[1].latency)
+	assert.Equal(t, "c:1", b.pick().addr.address)
+}
@@ -50,7 +50,7 @@ const (
 	Execute0pX                        Message = 0x45
 	ExecuteScript                     Message = 0x51
 	Flush                             Message = 0x48
-	Execute                           Message = 0x4f
+	Execute1pX                        Message = 0x4f
 	Parse                             Message = 0x50
 	Restore                           Message = 0x3c
 	RestoreBlock                      Message = 0x3d
@@ -0,0 +1,103 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+)
+
+// ErrMessageTooLarge is wrapped by the error that is returned when a
+// message from the server or a query's arguments are larger than
+// Options.MaxMessageSize.
+var ErrMessageTooLarge = buff.ErrMessageTooLarge
+
+// ResultTooLargeError is returned when a message from the server is larger
+// than Options.MaxMessageSize. The connection is closed because the rest of
+// the result can not be read. It is in the ClientConnectionClosedError
+// category, but unlike other connection errors it is not retried because
+// the result would be too large again.
+type ResultTooLargeError struct {
+	// Size is the size of the message in bytes.
+	Size int
+
+	// Limit is the client's Options.MaxMessageSize.
+	Limit int
+
+	err error
+}
+
+func (e *ResultTooLargeError) Error() string {
+	return "edgedb.ClientConnectionClosedError: " + e.err.Error()
+}
+
+func (e *ResultTooLargeError) Unwrap() error { return e.err }
+
+// Category returns true if c is ClientConnectionClosedError
+// or one of its parent categories.
+func (e *ResultTooLargeError) Category(c ErrorCategory) bool {
+	switch c {
+	case ClientConnectionClosedError, ClientConnectionError, ClientError:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasTag returns false. The query is not retried.
+func (e *ResultTooLargeError) HasTag(tag ErrorTag) bool { return false }
+
+// wrapMessageTooLarge returns a ResultTooLargeError if err was caused by a
+// message from the server that is larger than the maximum message size.
+func wrapMessageTooLarge(err error) error {
+	var tooLarge *buff.MessageTooLargeError
+	if !errors.As(err, &tooLarge) {
+		return err
+	}
+
+	return &ResultTooLargeError{
+		Size:  tooLarge.Size,
+		Limit: tooLarge.Limit,
+		err:   tooLarge,
+	}
+}
+
+// encodeArgs writes q's arguments to w. The arguments are not sent if they
+// are larger than the connection's maximum message size.
+func (c *protocolConnection) encodeArgs(
+	w *buff.Writer,
+	q *query,
+	in codecs.Encoder,
+) error {
+	start := w.Len()
+	if e := in.Encode(w, q.args, codecs.Path("args"), true); e != nil {
+		return &invalidArgumentError{msg: e.Error()}
+	}
+
+	size := w.Len() - start
+	if c.maxMessageSize > 0 && size > c.maxMessageSize {
+		return &invalidArgumentError{err: fmt.Errorf(
+			"%w: the arguments are %v bytes, the limit is %v",
+			ErrMessageTooLarge, size, c.maxMessageSize,
+		)}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeArgsMaxMessageSize(t *testing.T) {
+	bytesDesc := descriptor.V2{Type: descriptor.Scalar, ID: codecs.BytesID}
+	in, err := codecs.BuildEncoderV2(&descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "0", Desc: bytesDesc, Required: true},
+		},
+	}, protocolVersion2p0)
+	require.NoError(t, err)
+
+	c := &protocolConnection{maxMessageSize: 20}
+	w := buff.NewWriter(nil)
+	w.BeginMessage(uint8(Execute1pX))
+
+	q := &query{args: []interface{}{[]byte("abcd")}}
+	require.NoError(t, c.encodeArgs(w, q, in))
+
+	q = &query{args: []interface{}{[]byte("abcde")}}
+	err = c.encodeArgs(w, q, in)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
+		"message is too large: the arguments are 21 bytes, the limit is 20")
+
+	var edbErr Error
+	require.ErrorAs(t, err, &edbErr)
+	assert.True(t, edbErr.Category(InvalidArgumentError))
+}
+
+func TestWrapMessageTooLarge(t *testing.T) {
+	err := wrapMessageTooLarge(&clientConnectionClosedError{
+		err: &buff.MessageTooLargeError{MsgType: 0x44, Size: 30, Limit: 20},
+	})
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+	assert.EqualError(t, err, "edgedb.ClientConnectionClosedError: "+
+		"message is too large: message type 0x44 is 30 bytes, "+
+		"the limit is 20")
+
+	var tooLarge *ResultTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, 30, tooLarge.Size)
+	assert.Equal(t, 20, tooLarge.Limit)
+
+	var edbErr Error
+	require.ErrorAs(t, err, &edbErr)
+	assert.True(t, edbErr.Category(ClientConnectionClosedError))
+	assert.False(t, edbErr.HasTag(ShouldRetry))
+
+	other := &clientConnectionClosedError{msg: "closed"}
+	assert.Same(t, other, wrapMessageTooLarge(other))
+	assert.Nil(t, wrapMessageTooLarge(nil))
+}
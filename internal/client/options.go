@@ -17,11 +17,15 @@
 package edgedb
 
 import (
+	"crypto/tls"
 	"fmt"
 	"math"
+	"reflect"
 	"time"
 
+	"github.com/sebastiean/edgedb-go/internal/codecs"
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/introspect"
 )
 
 // Options for connecting to an EdgeDB server
@@ -43,6 +47,24 @@ type Options struct {
 	// their defaults.
 	Port int
 
+	// Hosts are the addresses of additional servers that serve the same
+	// database, given as host or host:port. Addresses without a port use the
+	// resolved port. New connections are made to the first healthy host,
+	// the host resolved from the other options, unless LoadBalancer is set.
+	Hosts []string
+
+	// LoadBalancer chooses the host that new connections are made to when
+	// Hosts is set. See NewRoundRobinBalancer, NewLeastLoadedBalancer and
+	// NewLatencyBalancer.
+	LoadBalancer LoadBalancer
+
+	// ReadReplicas are the addresses of read replicas of the database,
+	// given as host or host:port. Addresses without a port use the resolved
+	// port. Clients returned by Client.WithReadOnly connect to the read
+	// replicas, other clients connect to the primary. If none of the read
+	// replicas can be reached read only clients connect to the primary.
+	ReadReplicas []string
+
 	// Credentials is a JSON string containing connection credentials.
 	//
 	// Credentials cannot be specified alongside the 'dsn' argument, Host,
@@ -84,10 +106,28 @@ type Options struct {
 	// ConnectTimeout is used when establishing connections in the background.
 	ConnectTimeout time.Duration
 
+	// EagerConnect makes CreateClient connect to the server before returning
+	// and return an error if it can't. By default clients connect on first
+	// use so that creating a client that is never used costs nothing and
+	// can't fail because the server is unavailable.
+	EagerConnect bool
+
 	// WaitUntilAvailable determines how long to wait
 	// to reestablish a connection.
 	WaitUntilAvailable time.Duration
 
+	// KeepAliveInterval is how often idle connections are checked with a
+	// round trip to the server. A connection that fails the check, or does
+	// not respond within a second or the interval if it is shorter, is closed
+	// so that the next query connects again instead of failing. Zero
+	// disables the checks.
+	//
+	// Independent of KeepAliveInterval, idle connections are closed shortly
+	// before the server's session_idle_timeout. A query that finds its
+	// connection closed by the server for being idle is sent again on a
+	// new connection.
+	KeepAliveInterval time.Duration
+
 	// Concurrency determines the maximum number of connections.
 	// If Concurrency is zero, max(4, runtime.NumCPU()) will be used.
 	// Has no effect for single connections.
@@ -109,6 +149,164 @@ type Options struct {
 
 	// SecretKey is used to connect to cloud instances.
 	SecretKey string
+
+	// SecretProvider is asked for the password and cloud secret key if they
+	// are not set by any other option, the DSN, environment variables or
+	// credentials. See NewKeyringSecretProvider.
+	SecretProvider SecretProvider
+
+	// AuthProvider is called every time a connection is established and can
+	// replace the user, password and secret key used to authenticate it.
+	AuthProvider AuthProvider
+
+	// CircuitBreaker stops the client from connecting while the server is
+	// unavailable. It is disabled by default.
+	CircuitBreaker CircuitBreakerOptions
+
+	// Trace sends the trace context of each query's context to the server.
+	// It is disabled by default.
+	Trace TraceOptions
+
+	// MaxMessageSize is the largest message in bytes that the client
+	// accepts from the server, and the largest size of a query's encoded
+	// arguments. A larger message from the server closes the connection and
+	// returns a ResultTooLargeError. Larger arguments are not sent. In both
+	// cases the returned error wraps ErrMessageTooLarge. Results that
+	// QuerySingle writes to an io.Writer are not limited. Zero or less means
+	// no limit.
+	MaxMessageSize int
+
+	// CacheSize is the maximum number of entries in each of the client's
+	// query and codec caches. If CacheSize is zero, 1,000 will be used.
+	// A negative CacheSize disables caching.
+	CacheSize int
+
+	// DescriptorCacheFile is the path of a file that the type descriptors of
+	// the queries the client runs are saved to when it is closed and loaded
+	// from when it is created. This avoids describing each query again after
+	// a restart. Clients created with Client.WithDatabase do not use it.
+	DescriptorCacheFile string
+
+	// StructTag is the struct tag key used to match struct fields to query
+	// result fields. If StructTag is empty "edgedb" is used. This allows
+	// struct types that are tagged for another library, for example with
+	// `db:"name"`, to be used without adding edgedb tags.
+	StructTag string
+
+	// FieldMatch determines how query result fields are matched to struct
+	// fields that are not tagged. The default is FieldMatchExact.
+	FieldMatch FieldMatch
+
+	// MissingField determines how optional query result fields are decoded
+	// into struct fields that are not optional types.
+	// The default is MissingFieldError.
+	MissingField MissingFieldPolicy
+
+	// ObjectTypes maps object type names, e.g. default::Movie, to values of
+	// the struct types that objects of the type are decoded into when the
+	// out type is an interface, e.g. Movie{} or &Movie{}. This allows a
+	// query over an abstract type to decode each object into the struct for
+	// its concrete type, if the struct or pointer implements the interface.
+	// Shape fields that a struct does not have are skipped.
+	//
+	// Decoding into interfaces requires protocol 2.0 or later and implicit
+	// type names, see QueryOptions.WithImplicitTypeNames.
+	ObjectTypes map[string]interface{}
+
+	// Clock is the source of time for retry backoff, reconnect attempts,
+	// keepalive checks, idle timeouts and the circuit breaker. If Clock is
+	// nil the system clock is used.
+	Clock Clock
+
+	// Rand is the source of randomness for the jitter added to retry backoff
+	// and reconnect attempts. If Rand is nil a randomly seeded source is
+	// used.
+	Rand Rand
+}
+
+// objectTypes returns the reflect types of o.ObjectTypes.
+func (o *Options) objectTypes() (map[string]reflect.Type, error) {
+	if len(o.ObjectTypes) == 0 {
+		return nil, nil
+	}
+
+	objectTypes := make(map[string]reflect.Type, len(o.ObjectTypes))
+	for name, val := range o.ObjectTypes {
+		typ := reflect.TypeOf(val)
+		structType := typ
+		if typ != nil && typ.Kind() == reflect.Ptr {
+			structType = typ.Elem()
+		}
+
+		if structType == nil || structType.Kind() != reflect.Struct {
+			return nil, fmt.Errorf(
+				"invalid ObjectTypes value for %v: "+
+					"expected a struct or a pointer to a struct got %T",
+				name, val)
+		}
+
+		objectTypes[name] = typ
+	}
+
+	return objectTypes, nil
+}
+
+// FieldMatch specifies how query result fields are matched to untagged
+// struct fields.
+type FieldMatch int
+
+const (
+	// FieldMatchExact matches untagged struct fields whose name is exactly
+	// the same as the query result field name.
+	FieldMatchExact FieldMatch = iota
+	// FieldMatchCaseInsensitive matches untagged struct fields whose name
+	// is the same as the query result field name ignoring case.
+	FieldMatchCaseInsensitive
+	// FieldMatchTagOnly only matches struct fields by their tag.
+	FieldMatchTagOnly
+)
+
+func (m FieldMatch) introspect() (introspect.FieldMatch, error) {
+	switch m {
+	case FieldMatchExact:
+		return introspect.MatchExact, nil
+	case FieldMatchCaseInsensitive:
+		return introspect.MatchCaseInsensitive, nil
+	case FieldMatchTagOnly:
+		return introspect.MatchTagOnly, nil
+	default:
+		return 0, fmt.Errorf("invalid FieldMatch: %v", int(m))
+	}
+}
+
+// MissingFieldPolicy specifies how optional query result fields are decoded
+// into struct fields that are not optional types like edgedb.OptionalStr.
+type MissingFieldPolicy int
+
+const (
+	// MissingFieldError returns an error if an optional field is decoded
+	// into a struct field that is not an optional type.
+	MissingFieldError MissingFieldPolicy = iota
+	// MissingFieldZero sets struct fields to their zero value when an
+	// optional field is missing. Note that a missing value can not be told
+	// apart from a zero value.
+	MissingFieldZero
+	// MissingFieldNil allows optional fields to be decoded into pointer
+	// struct fields which are set to nil when the field is missing.
+	MissingFieldNil
+)
+
+func (p MissingFieldPolicy) codecs() (codecs.MissingPolicy, error) {
+	switch p {
+	case MissingFieldError:
+		return codecs.MissingError, nil
+	case MissingFieldZero:
+		return codecs.MissingZero, nil
+	case MissingFieldNil:
+		return codecs.MissingPointerNil, nil
+	default:
+		return 0, fmt.Errorf("invalid MissingFieldPolicy: %v", int(p))
+	}
 }
 
 // TLSOptions contains the parameters needed to configure TLS on EdgeDB
@@ -120,6 +318,13 @@ type TLSOptions struct {
 	CAFile string
 	// Determines how strict we are with TLS checks
 	SecurityMode TLSSecurityMode
+	// Config is the base TLS configuration, for example to present a
+	// client certificate. It is copied before it is used. Its RootCAs are
+	// replaced if CA or CAFile is set, or if a CA is configured in the
+	// credentials, and default to the system roots if nil. SecurityMode
+	// and the edgedb-binary protocol are always applied on top of it, so
+	// its InsecureSkipVerify is ignored.
+	Config *tls.Config
 }
 
 // TLSSecurityMode specifies how strict TLS validation is.
@@ -141,9 +346,9 @@ const (
 // before making the next attempt when retrying a transaction.
 type RetryBackoff func(n int) time.Duration
 
-func defaultBackoff(attempt int) time.Duration {
+func defaultBackoff(attempt int, rand Rand) time.Duration {
 	backoff := math.Pow(2.0, float64(attempt)) * 100.0
-	jitter := rnd.Float64() * 100.0
+	jitter := rand.Float64() * 100.0
 	return time.Duration(backoff+jitter) * time.Millisecond
 }
 
@@ -168,7 +373,6 @@ func NewRetryRule() RetryRule {
 	return RetryRule{
 		fromFactory: true,
 		attempts:    3,
-		backoff:     defaultBackoff,
 	}
 }
 
@@ -212,6 +416,21 @@ func (r RetryRule) WithBackoff(fn RetryBackoff) RetryRule {
 	return r
 }
 
+// delay returns how long to wait after the nth attempt. rand is used for
+// the jitter of the default backoff.
+func (r RetryRule) delay(n int, rand Rand) time.Duration {
+	if r.backoff == nil {
+		return defaultBackoff(n, rand)
+	}
+
+	return r.backoff(n)
+}
+
+// NewRetryOptions returns the default retry options.
+func NewRetryOptions() RetryOptions {
+	return RetryOptions{fromFactory: true}.WithDefault(NewRetryRule())
+}
+
 // RetryOptions configures how Tx() retries failed transactions.  Use
 // NewRetryOptions to get a default RetryOptions value instead of creating one
 // yourself.
@@ -307,15 +526,16 @@ func (o TxOptions) WithIsolation(i IsolationLevel) TxOptions {
 	return o
 }
 
-// WithReadOnly returns a shallow copy of the client
+// WithReadOnly returns a copy of the TxOptions
 // with the transaction read only access mode set to r.
 func (o TxOptions) WithReadOnly(r bool) TxOptions {
 	o.readOnly = r
 	return o
 }
 
-// WithDeferrable returns a shallow copy of the client
+// WithDeferrable returns a copy of the TxOptions
 // with the transaction deferrable mode set to d.
+// Deferrable only has an effect on read only serializable transactions.
 func (o TxOptions) WithDeferrable(d bool) TxOptions {
 	o.deferrable = d
 	return o
@@ -347,8 +567,170 @@ func (o TxOptions) startTxQuery() string { // nolint:gocritic
 	return query
 }
 
+// Capability is a bit mask of query capabilities.
+// See QueryOptions.WithAllowedCapabilities().
+type Capability uint64
+
+// The following capabilities can be combined using bitwise or.
+const (
+	// CapabilityModifications allows queries that modify data.
+	CapabilityModifications Capability = 0x1
+
+	// CapabilitySessionConfig allows session configuration commands.
+	CapabilitySessionConfig Capability = 0x2
+
+	// CapabilityTransaction allows transaction commands.
+	CapabilityTransaction Capability = 0x4
+
+	// CapabilityDDL allows schema modifications.
+	CapabilityDDL Capability = 0x8
+
+	// CapabilityPersistentConfig allows server or database configuration
+	// commands.
+	CapabilityPersistentConfig Capability = 0x10
+
+	// CapabilityAll allows every capability the client supports.
+	CapabilityAll Capability = 0xffffffffffffffff
+)
+
+const (
+	compilationFlagImplicitTypeIDs   uint64 = 0x1
+	compilationFlagImplicitTypeNames uint64 = 0x2
+)
+
+// NewQueryOptions returns the default QueryOptions value.
+func NewQueryOptions() QueryOptions {
+	return QueryOptions{
+		fromFactory:  true,
+		capabilities: CapabilityAll,
+	}
+}
+
+// QueryOptions configures how queries are compiled and run.
+// Use NewQueryOptions to get a default QueryOptions value
+// instead of creating one yourself.
+type QueryOptions struct {
+	// fromFactory indicates that a QueryOptions value was created using
+	// NewQueryOptions() and not created directly with QueryOptions{}.
+	// Requiring users to use the factory function allows for nonzero
+	// default values.
+	fromFactory bool
+
+	implicitLimit     uint64
+	readOnly          bool
+	capabilities      Capability
+	implicitTypeIDs   bool
+	implicitTypeNames bool
+	noCache           bool
+}
+
+// WithImplicitLimit returns a copy of the QueryOptions
+// with the implicit limit set to limit.
+// The server returns at most limit results from each set in a query.
+// A limit of 0 disables the implicit limit.
+func (o QueryOptions) WithImplicitLimit(limit uint64) QueryOptions {
+	o.implicitLimit = limit
+	return o
+}
+
+// WithReadOnly returns a copy of the QueryOptions
+// with read only mode set to r.
+// Read only queries are not allowed to modify data or the schema.
+func (o QueryOptions) WithReadOnly(r bool) QueryOptions {
+	o.readOnly = r
+	return o
+}
+
+// WithAllowedCapabilities returns a copy of the QueryOptions
+// with the allowed capabilities set to c.
+// Queries that need capabilities that are not allowed fail
+// with a DisabledCapabilityError.
+func (o QueryOptions) WithAllowedCapabilities(c Capability) QueryOptions {
+	o.capabilities = c
+	return o
+}
+
+// WithImplicitTypeIDs returns a copy of the QueryOptions
+// with implicit type ids enabled or disabled.
+// When enabled the server adds an implicit __tid__ field
+// holding the object's type id to every object in the results.
+func (o QueryOptions) WithImplicitTypeIDs(enabled bool) QueryOptions {
+	o.implicitTypeIDs = enabled
+	return o
+}
+
+// WithImplicitTypeNames returns a copy of the QueryOptions
+// with implicit type names enabled or disabled.
+// When enabled the server adds an implicit __tname__ field
+// holding the name of the object's type, e.g. default::User,
+// to every object in the results. Queries over abstract types can decode it
+// into a string field tagged __tname__ to find the concrete type of each
+// object. The field is tagged with the client's Options.StructTag, for
+// example `edgedb:"__tname__"` with the default tag.
+func (o QueryOptions) WithImplicitTypeNames(enabled bool) QueryOptions {
+	o.implicitTypeNames = enabled
+	return o
+}
+
+// WithQueryCache returns a copy of the QueryOptions
+// with query caching enabled or disabled.
+// The client caches the type descriptors and capabilities of each distinct
+// query string it runs. Disabling the query cache for queries that are built
+// dynamically and rarely repeated keeps them from evicting cached queries
+// that are run often. Query caching is enabled by default.
+func (o QueryOptions) WithQueryCache(enabled bool) QueryOptions {
+	o.noCache = !enabled
+	return o
+}
+
+// allowedCapabilities returns the capabilities in base
+// that are allowed by the options.
+func (o QueryOptions) allowedCapabilities(base uint64) uint64 {
+	if !o.fromFactory {
+		return base
+	}
+
+	allowed := base & uint64(o.capabilities)
+	if o.readOnly {
+		allowed &^= uint64(CapabilityModifications | CapabilityDDL)
+	}
+
+	return allowed
+}
+
+func (o QueryOptions) compilationFlags() uint64 {
+	var flags uint64
+	if o.implicitTypeIDs {
+		flags |= compilationFlagImplicitTypeIDs
+	}
+
+	if o.implicitTypeNames {
+		flags |= compilationFlagImplicitTypeNames
+	}
+
+	return flags
+}
+
+// WithQueryOptions returns a shallow copy of the client
+// with the QueryOptions set to opts.
+func (p Client) WithQueryOptions( // nolint:gocritic
+	opts QueryOptions,
+) *Client {
+	if !opts.fromFactory {
+		panic("QueryOptions not created with NewQueryOptions() are not valid")
+	}
+
+	p.queryOpts.settings = opts
+	return &p
+}
+
 // WithTxOptions returns a shallow copy of the client
-// with the TxOptions set to opts.
+// with the TxOptions set to opts. The options are sent as
+// START TRANSACTION modifiers. To configure a single transaction
+// call Tx on the returned client:
+//
+//	opts := edgedb.NewTxOptions().WithReadOnly(true)
+//	err := client.WithTxOptions(opts).Tx(ctx, action)
 func (p Client) WithTxOptions(opts TxOptions) *Client { // nolint:gocritic
 	if !opts.fromFactory {
 		panic("TxOptions not created with NewTxOptions() are not valid")
@@ -371,7 +753,44 @@ func (p Client) WithRetryOptions( // nolint:gocritic
 	return &p
 }
 
+// WithPartialResults returns a shallow copy of the client
+// with partial results enabled or disabled.
+// When enabled and a query fails after some results were already decoded,
+// the out argument is set to the results decoded before the failure
+// and the error is returned. When disabled, which is the default,
+// the out argument is left empty if the query fails.
+func (p Client) WithPartialResults(enabled bool) *Client { // nolint:gocritic
+	p.queryOpts.partialResults = enabled
+	return &p
+}
+
+// WithWarningHandler returns a shallow copy of the client
+// with the WarningHandler set to handler.
+// A nil handler ignores warnings.
+func (p Client) WithWarningHandler( // nolint:gocritic
+	handler WarningHandler,
+) *Client {
+	p.queryOpts.warningHandler = handler
+	return &p
+}
+
+// WithRetryHandler returns a shallow copy of the client
+// with the RetryHandler set to handler.
+// A nil handler does not report retries.
+func (p Client) WithRetryHandler( // nolint:gocritic
+	handler RetryHandler,
+) *Client {
+	p.queryOpts.retryHandler = handler
+	return &p
+}
+
 // WithConfig sets configuration values for the returned client.
+// The values are encoded into the protocol state that is sent with every
+// query. Values must have the Go type of the setting's EdgeDB type.
+//
+//	c := client.WithConfig(map[string]interface{}{
+//		"query_execution_timeout": edgedb.Duration(5_000_000),
+//	})
 func (p Client) WithConfig( // nolint:gocritic
 	cfg map[string]interface{},
 ) *Client {
@@ -408,6 +827,25 @@ func (p Client) WithoutConfig(key ...string) *Client { // nolint:gocritic
 	return &p
 }
 
+// WithDefaultModule sets the default module for the returned client.
+// Names in queries that are not fully qualified are resolved
+// in the default module.
+func (p Client) WithDefaultModule(module string) *Client { // nolint:gocritic
+	state := copyState(p.state)
+	state["module"] = module
+	p.state = state
+	return &p
+}
+
+// WithoutDefaultModule resets the default module for the returned client
+// to the server's default.
+func (p Client) WithoutDefaultModule() *Client { // nolint:gocritic
+	state := copyState(p.state)
+	delete(state, "module")
+	p.state = state
+	return &p
+}
+
 // ModuleAlias is an alias name and module name pair.
 type ModuleAlias struct {
 	Alias  string
@@ -415,6 +853,12 @@ type ModuleAlias struct {
 }
 
 // WithModuleAliases sets module name aliases for the returned client.
+// Like WithConfig the aliases are sent in the protocol state.
+//
+//	c := client.WithModuleAliases(edgedb.ModuleAlias{
+//		Alias:  "m",
+//		Module: "math",
+//	})
 func (p Client) WithModuleAliases( // nolint:gocritic
 	aliases ...ModuleAlias,
 ) *Client {
@@ -463,6 +907,15 @@ func (p Client) WithoutModuleAliases( // nolint:gocritic
 }
 
 // WithGlobals sets values for global variables for the returned client.
+// The globals are encoded into the protocol state that is sent with every
+// query. Global names are fully qualified.
+//
+//	c := client.WithGlobals(map[string]interface{}{
+//		"default::current_user_id": id,
+//	})
+//
+// The state is supported by protocol 1.0 and later (EdgeDB 2.0).
+// Queries with globals on older servers return an InterfaceError.
 func (p Client) WithGlobals( // nolint:gocritic
 	globals map[string]interface{},
 ) *Client {
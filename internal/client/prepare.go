@@ -0,0 +1,307 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// preparedKey identifies a prepared query. Unlike queryKey it does not
+// include the out type or the expected cardinality so that a prepared query
+// can be run by any of the PreparedQuery methods.
+type preparedKey struct {
+	cmd   string
+	fmt   Format
+	flags uint64
+}
+
+func makePreparedKey(q *query) preparedKey {
+	return preparedKey{
+		cmd:   q.cmd,
+		fmt:   q.fmt,
+		flags: q.settings.compilationFlags(),
+	}
+}
+
+// prepared is the result of describing a prepared query.
+type prepared struct {
+	ids          idPair
+	capabilities uint64
+}
+
+// PreparedQuery is a query that has already been described by the server.
+// Its methods skip describing the query again on all of the client's
+// connections. If the schema changes the query is described again
+// automatically. Use Client.Prepare to create a PreparedQuery. A
+// PreparedQuery is safe for concurrent use.
+//
+// Prepared queries are not named statements on the server. Messages of
+// protocol 1.0 and later have no statement name, and queries sent to servers
+// that use an earlier protocol always use the unnamed statement.
+type PreparedQuery struct {
+	client *Client
+	cmd    string
+}
+
+// Prepare describes cmd so that it can be run without describing it again.
+// The returned PreparedQuery uses the client's options and state.
+func (p *Client) Prepare(
+	ctx context.Context,
+	cmd string,
+) (*PreparedQuery, error) {
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute runs queries with the Null output format,
+	// the other methods use the Binary format.
+	for _, format := range []Format{Binary, Null} {
+		q := &query{
+			method:       "Prepare",
+			cmd:          cmd,
+			fmt:          format,
+			expCard:      Many,
+			capabilities: conn.capabilities1pX(),
+			state:        copyState(p.state),
+		}
+		q.setOptions(p.queryOpts)
+
+		if _, err = conn.prepareFlow(ctx, q); err != nil {
+			break
+		}
+	}
+
+	if e := firstError(err, p.release(conn, err)); e != nil {
+		return nil, e
+	}
+
+	return &PreparedQuery{client: p, cmd: cmd}, nil
+}
+
+// Execute runs the prepared query. See Client.Execute.
+func (pq *PreparedQuery) Execute(
+	ctx context.Context,
+	args ...interface{},
+) error {
+	return pq.client.Execute(ctx, pq.cmd, args...)
+}
+
+// Query runs the prepared query and returns the results. See Client.Query.
+func (pq *PreparedQuery) Query(
+	ctx context.Context,
+	out interface{},
+	args ...interface{},
+) error {
+	return pq.client.Query(ctx, pq.cmd, out, args...)
+}
+
+// QuerySingle runs the prepared query and returns at most one result.
+// See Client.QuerySingle.
+func (pq *PreparedQuery) QuerySingle(
+	ctx context.Context,
+	out interface{},
+	args ...interface{},
+) error {
+	return pq.client.QuerySingle(ctx, pq.cmd, out, args...)
+}
+
+func (c *reconnectingConn) prepareFlow(
+	ctx context.Context,
+	q *query,
+) (*Description, error) {
+	if e := c.ensureConnection(ctx); e != nil {
+		return nil, e
+	}
+
+	if e := c.assertUnborrowed(); e != nil {
+		return nil, e
+	}
+
+	return c.conn.prepareFlow(ctx, q)
+}
+
+func (c *reconnectingConn) parseFlow(
+	ctx context.Context,
+	q *query,
+) (*Description, error) {
+	if e := c.ensureConnection(ctx); e != nil {
+		return nil, e
+	}
+
+	if e := c.assertUnborrowed(); e != nil {
+		return nil, e
+	}
+
+	return c.conn.parseFlow(ctx, q)
+}
+
+// prepareFlow describes q and caches its type IDs for all of the query
+// methods.
+func (c *protocolConnection) prepareFlow(
+	ctx context.Context,
+	q *query,
+) (*Description, error) {
+	if q.fmt == Null && c.protocolVersion.LT(protocolVersion1p0) {
+		// Execute doesn't describe queries before protocol 1.0.
+		return nil, nil
+	}
+
+	desc, err := c.parseFlow(ctx, q)
+	if err == nil && !q.settings.noCache {
+		c.preparedCache.Put(makePreparedKey(q), prepared{
+			ids:          q.descIDs,
+			capabilities: q.reportedCapabilities,
+		})
+		err = c.cacheArgEncoder(q.descIDs.in)
+	}
+
+	return desc, err
+}
+
+// cacheArgEncoder builds the arguments encoder for the descriptor id so that
+// BoundQuery can bind to it.
+func (c *protocolConnection) cacheArgEncoder(id types.UUID) error {
+	if _, ok := c.inCodecCache.Get(id); ok {
+		return nil
+	}
+
+	desc, ok := descCache.Get(id)
+	if !ok {
+		return nil
+	}
+
+	var (
+		in  codecs.Encoder
+		err error
+	)
+
+	switch d := desc.(type) {
+	case descriptor.V2:
+		in, err = codecs.BuildEncoderV2(&d, c.protocolVersion)
+	case descriptor.Descriptor:
+		in, err = codecs.BuildEncoder(d, c.protocolVersion)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return &invalidArgumentError{msg: err.Error()}
+	}
+
+	c.inCodecCache.Put(id, in)
+	return nil
+}
+
+// argEncoder returns the cached arguments encoder of the prepared query.
+func (pq *PreparedQuery) argEncoder() (codecs.Encoder, bool) {
+	q := &query{cmd: pq.cmd, fmt: Binary}
+	q.setOptions(pq.client.queryOpts)
+
+	val, ok := pq.client.preparedCache.Get(makePreparedKey(q))
+	if !ok {
+		return nil, false
+	}
+
+	in, ok := pq.client.inCodecCache.Get(val.(prepared).ids.in)
+	if !ok {
+		return nil, false
+	}
+
+	return in.(codecs.Encoder), true
+}
+
+// parseFlow describes q without running it.
+func (c *protocolConnection) parseFlow(
+	ctx context.Context,
+	q *query,
+) (*Description, error) {
+	if e := c.enter(q.method); e != nil {
+		return nil, e
+	}
+	defer c.exit()
+
+	r, err := c.acquireReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	err = c.soc.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	q.annotate(ctx)
+	canceled := c.soc.closeOnCancel(ctx)
+
+	var desc *Description
+	switch {
+	case c.protocolVersion.GTE(protocolVersion2p0):
+		var d *CommandDescriptionV2
+		if d, err = c.parse2pX(r, q); err == nil {
+			desc = newDescriptionV2(q, d)
+		}
+	case c.protocolVersion.GTE(protocolVersion1p0):
+		var d *CommandDescription
+		if d, err = c.parse1pX(r, q); err == nil {
+			desc = newDescription(q, d)
+		}
+	default:
+		err = c.prepare0pX(r, q)
+		if err == nil {
+			var d *CommandDescription
+			if d, err = c.describe(r, q); err == nil {
+				desc = newDescription(q, d)
+			}
+		}
+	}
+
+	if canceled() {
+		err = wrapNetError(ctx.Err())
+	}
+
+	err = firstError(wrapMessageTooLarge(err), c.releaseReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return desc, nil
+}
+
+// getPreparedTypeIDs adds the type IDs of a prepared query to the
+// connection's caches and returns them.
+func (c *protocolConnection) getPreparedTypeIDs(q *query) (*idPair, bool) {
+	val, ok := c.preparedCache.Get(makePreparedKey(q))
+	if !ok {
+		return nil, false
+	}
+
+	p := val.(prepared)
+	if q.expCard == AtMostOne && p.ids.card == Many {
+		// Let the server report the cardinality mismatch.
+		return nil, false
+	}
+
+	c.typeIDCache.Put(makeKey(q), p.ids)
+	c.capabilitiesCache.Put(makeKey(q), p.capabilities)
+	return &p.ids, true
+}
@@ -0,0 +1,73 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedQuery(t *testing.T) {
+	ctx := context.Background()
+	p, err := CreateClient(ctx, opts)
+	require.NoError(t, err)
+	defer p.Close() // nolint:errcheck
+
+	cmd := "SELECT <int64>$0 + 1"
+	pq, err := p.Prepare(ctx, cmd)
+	require.NoError(t, err)
+
+	for _, format := range []Format{Binary, Null} {
+		_, ok := p.preparedCache.Get(preparedKey{
+			cmd:   cmd,
+			fmt:   format,
+			flags: p.queryOpts.settings.compilationFlags(),
+		})
+		assert.True(t, ok, "format %v is prepared", format)
+	}
+
+	var single int64
+	err = pq.QuerySingle(ctx, &single, int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), single)
+
+	var many []int64
+	err = pq.Query(ctx, &many, int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, many)
+
+	require.NoError(t, pq.Execute(ctx, int64(3)))
+
+	// The type IDs were cached for the out types used above.
+	q, err := newQuery("QuerySingle", cmd, nil, 0, nil, &single)
+	require.NoError(t, err)
+	q.setOptions(p.queryOpts)
+	_, ok := p.typeIDCache.Get(makeKey(q))
+	assert.True(t, ok)
+}
+
+func TestPrepareInvalidQuery(t *testing.T) {
+	ctx := context.Background()
+	_, err := client.Prepare(ctx, "SELECT 1 +")
+
+	var edbErr Error
+	require.ErrorAs(t, err, &edbErr)
+	assert.True(t, edbErr.Category(EdgeQLSyntaxError), err)
+}
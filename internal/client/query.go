@@ -19,16 +19,53 @@ package edgedb
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
 
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 	"github.com/sebastiean/edgedb-go/internal/header"
 	"github.com/sebastiean/edgedb-go/internal/introspect"
+	"github.com/sebastiean/edgedb-go/internal/marshal"
 )
 
+// queryOptions are client settings that change how query results are
+// handled.
+type queryOptions struct {
+	// partialResults causes the rows decoded before an error to be written
+	// to the out argument instead of being discarded.
+	partialResults bool
+
+	// warningHandler is called with the warnings the server reports.
+	warningHandler WarningHandler
+
+	// retryHandler is called when a query or transaction is retried.
+	retryHandler RetryHandler
+
+	// settings are the user's QueryOptions.
+	settings QueryOptions
+
+	// resultCache stores the results of read only queries if it is not nil.
+	resultCache ResultCache
+
+	// trace configures the trace context that is sent with queries.
+	trace TraceOptions
+
+	// arrayChunkSize is the number of elements of an array argument
+	// that Client.Query sends in one query. 0 disables chunking.
+	arrayChunkSize int
+
+	// linkIDs returns the offset of a struct type's id field. Linked
+	// objects are deduplicated if it is not nil.
+	// See Client.WithLinkDeduplication.
+	linkIDs func(reflect.Type) (uintptr, bool)
+}
+
 type query struct {
+	queryOptions
 	out          reflect.Value
 	outType      reflect.Type
 	method       string
@@ -38,6 +75,57 @@ type query struct {
 	args         []interface{}
 	capabilities uint64
 	state        map[string]interface{}
+
+	// descIDs are the type descriptor ids used to execute the query.
+	descIDs idPair
+
+	// meta is filled with the result metadata of the query if it is not
+	// nil. Its type is set from the out descriptor that the query's result
+	// is decoded with.
+	meta *ResultMeta
+
+	// reportedCapabilities are the capabilities the server reported
+	// for the query if capabilitiesReported is true.
+	reportedCapabilities uint64
+	capabilitiesReported bool
+
+	// recordResults causes the encoded result elements
+	// to be kept in results so they can be stored in a ResultCache.
+	recordResults bool
+	results       [][]byte
+
+	// streamed is true if an argument was streamed from a reader when the
+	// query was sent, if result elements were passed to a QueryIterator or
+	// elementFunc, or if result bytes were written to writer. None of these
+	// can be repeated so the query can't be retried.
+	streamed bool
+
+	// writer is the destination of a QuerySingle query's std::bytes result
+	// if the out argument is an io.Writer. The result is streamed to it
+	// instead of being decoded.
+	writer io.Writer
+
+	// elementFunc is called with each element of a QueryJSONElementsFunc
+	// query's result instead of decoding the elements into out.
+	elementFunc func(json.RawMessage) error
+
+	// iter decodes each element of a QueryIter query's result
+	// instead of decoding the elements into out.
+	iter *QueryIterator
+
+	// annotations are sent with the query by protocol 2.0 and later.
+	annotations map[string]string
+
+	// links are the linked objects that have been decoded
+	// if linked object deduplication is enabled.
+	links *linkDedup
+}
+
+// resetAttempt drops the results and linked objects decoded by a failed
+// attempt to run q before it is sent again.
+func (q *query) resetAttempt() {
+	q.results = nil
+	q.links = nil
 }
 
 func (q *query) flat() bool {
@@ -56,7 +144,28 @@ func (q *query) headers0pX() header.Header {
 	bts := make([]byte, 8)
 	binary.BigEndian.PutUint64(bts, q.capabilities)
 
-	return header.Header{header.AllowCapabilities: bts}
+	headers := header.Header{header.AllowCapabilities: bts}
+	if q.settings.implicitLimit != 0 {
+		headers[header.ImplicitLimit] = []byte(
+			strconv.FormatUint(q.settings.implicitLimit, 10))
+	}
+
+	if q.settings.implicitTypeIDs {
+		headers[header.ImplicitTypeIDs] = []byte("true")
+	}
+
+	if q.settings.implicitTypeNames {
+		headers[header.ImplicitTypeNames] = []byte("true")
+	}
+
+	return headers
+}
+
+// setOptions sets the query's options and restricts its capabilities
+// to the capabilities allowed by the options.
+func (q *query) setOptions(opts queryOptions) {
+	q.queryOptions = opts
+	q.capabilities = opts.settings.allowedCapabilities(q.capabilities)
 }
 
 // newQuery returns a new granular flow query.
@@ -95,6 +204,9 @@ func newQuery(
 	case "QuerySingleJSON":
 		expCard = AtMostOne
 		frmt = JSON
+	case "QueryJSONElements":
+		expCard = Many
+		frmt = JSONElements
 	default:
 		return nil, fmt.Errorf("unknown query method %q", method)
 	}
@@ -109,6 +221,27 @@ func newQuery(
 		state:        state,
 	}
 
+	if w, ok := streamDestination(method, out); ok {
+		q.writer = w
+		q.out = reflect.New(bytesType).Elem()
+		q.outType = bytesType
+		return &q, nil
+	}
+
+	if fn, ok := out.(func(json.RawMessage) error); ok {
+		q.elementFunc = fn
+		q.out = reflect.New(rawMessagesType).Elem()
+		q.outType = rawMessageType
+		return &q, nil
+	}
+
+	if iter, ok := out.(*QueryIterator); ok {
+		q.iter = iter
+		q.out = reflect.New(reflect.SliceOf(iter.typ)).Elem()
+		q.outType = iter.typ
+		return &q, nil
+	}
+
 	var err error
 
 	if frmt == JSON || expCard == AtMostOne {
@@ -132,6 +265,28 @@ func newQuery(
 	return &q, nil
 }
 
+var (
+	bytesType       = reflect.TypeOf([]byte(nil))
+	rawMessageType  = reflect.TypeOf(json.RawMessage(nil))
+	rawMessagesType = reflect.TypeOf([]json.RawMessage(nil))
+	uuidType        = reflect.TypeOf(types.UUID{})
+)
+
+// streamDestination returns out if the result of a QuerySingle query
+// should be streamed to it. Types that unmarshal std::bytes are decoded.
+func streamDestination(method string, out interface{}) (io.Writer, bool) {
+	if method != "QuerySingle" {
+		return nil, false
+	}
+
+	if _, ok := out.(marshal.BytesUnmarshaler); ok {
+		return nil, false
+	}
+
+	w, ok := out.(io.Writer)
+	return w, ok
+}
+
 type queryable interface {
 	capabilities1pX() uint64
 	granularFlow(context.Context, *query) error
@@ -148,6 +303,41 @@ func runQuery(
 	out interface{},
 	args []interface{},
 	state map[string]interface{},
+	opts queryOptions,
+) error {
+	return execQuery(ctx, c, method, cmd, out, args, state, opts, nil)
+}
+
+// runQueryMeta runs a query and returns its result metadata.
+func runQueryMeta(
+	ctx context.Context,
+	c queryable,
+	method, cmd string,
+	out interface{},
+	args []interface{},
+	state map[string]interface{},
+	opts queryOptions,
+) (*ResultMeta, error) {
+	meta := &ResultMeta{}
+	err := execQuery(ctx, c, method, cmd, out, args, state, opts, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// execQuery runs a query. If meta is not nil it is filled with the query's
+// result metadata.
+func execQuery(
+	ctx context.Context,
+	c queryable,
+	method, cmd string,
+	out interface{},
+	args []interface{},
+	state map[string]interface{},
+	opts queryOptions,
+	meta *ResultMeta,
 ) error {
 	if method == "QuerySingleJSON" {
 		switch out.(type) {
@@ -163,8 +353,19 @@ func runQuery(
 	if err != nil {
 		return err
 	}
+	q.setOptions(opts)
+	q.meta = meta
 
-	err = c.granularFlow(ctx, q)
+	return q.exec(ctx, c, out)
+}
+
+// exec runs the query on c and decodes its results into out.
+func (q *query) exec(ctx context.Context, c queryable, out interface{}) error {
+	err := q.handleWarnings(c.granularFlow(ctx, q))
+	if err == nil && q.meta != nil {
+		q.meta.Cardinality = q.descIDs.card.String()
+		q.meta.Digest = queryDigest(q.cmd, q.descIDs.out)
+	}
 
 	var edbErr Error
 	if errors.As(err, &edbErr) &&
@@ -179,6 +380,20 @@ func runQuery(
 	return err
 }
 
+// setResult sets the out argument to the decoded rows. If the query failed
+// the rows are discarded unless partial results are enabled.
+func (q *query) setResult(rows reflect.Value, err error) {
+	if q.flat() || q.fmt == Null {
+		return
+	}
+
+	if err != nil && !q.partialResults {
+		rows = rows.Slice(0, 0)
+	}
+
+	q.out.Set(rows)
+}
+
 func copyState(in map[string]interface{}) map[string]interface{} {
 	out := make(map[string]interface{}, len(in))
 
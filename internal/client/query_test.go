@@ -17,13 +17,21 @@
 package edgedb
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/sebastiean/edgedb-go/internal/buff"
 	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -68,6 +76,42 @@ func TestQueryCachingIncludesOutType(t *testing.T) {
 	assert.EqualError(t, err, "edgedb.NoDataError: zero results")
 }
 
+func TestQueryRetriesStaleDescriptors(t *testing.T) {
+	ctx := context.Background()
+	dbName := fmt.Sprintf("test%v", rand.Intn(10_000))
+	err := client.Execute(ctx, "CREATE DATABASE "+dbName)
+	require.NoError(t, err)
+
+	p, err := client.WithDatabase(dbName)
+	require.NoError(t, err)
+	defer p.Close() // nolint:errcheck
+
+	// ddl has its own caches so p's cached descriptors become stale.
+	ddl, err := client.WithDatabase(dbName)
+	require.NoError(t, err)
+	defer ddl.Close() // nolint:errcheck
+
+	err = ddl.Execute(ctx, "CREATE SCALAR TYPE Stale EXTENDING int64")
+	require.NoError(t, err)
+
+	query := "SELECT <int64><Stale>$0"
+	var result int64
+	err = p.QuerySingle(ctx, query, &result, int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result)
+
+	// Recreating the type changes its id and the query's in descriptor.
+	err = ddl.Execute(ctx, `
+		DROP SCALAR TYPE Stale;
+		CREATE SCALAR TYPE Stale EXTENDING int64;
+	`)
+	require.NoError(t, err)
+
+	err = p.QuerySingle(ctx, query, &result, int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result)
+}
+
 func TestObjectWithoutID(t *testing.T) {
 	ctx := context.Background()
 
@@ -150,6 +194,9 @@ func TestMissmatchedResultType(t *testing.T) {
 	expected := "edgedb.InvalidArgumentError: " +
 		"the \"out\" argument does not match query schema: " +
 		"expected edgedb.A.x.y.z to be int64 or edgedb.OptionalInt64 got int"
+	if protocolVersion.GTE(protocolVersion2p0) {
+		expected += " (server type std::int64)"
+	}
 	assert.EqualError(t, err, expected)
 }
 
@@ -255,6 +302,149 @@ func TestQueryJSON(t *testing.T) {
 	)
 }
 
+func TestQueryJSONElements(t *testing.T) {
+	ctx := context.Background()
+	var result []json.RawMessage
+	err := client.QueryJSONElements(
+		ctx,
+		"SELECT {(a := 0, b := <int64>$0), (a := 42, b := <int64>$1)}",
+		&result,
+		int64(1),
+		int64(2),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []json.RawMessage{
+		json.RawMessage(`{"a" : 0, "b" : 1}`),
+		json.RawMessage(`{"a" : 42, "b" : 2}`),
+	}, result)
+}
+
+func TestQueryJSONElementsFunc(t *testing.T) {
+	ctx := context.Background()
+	var lines bytes.Buffer
+	err := client.QueryJSONElementsFunc(
+		ctx,
+		"SELECT {1, 2, 3}",
+		func(doc json.RawMessage) error {
+			lines.Write(doc)
+			return lines.WriteByte('\n')
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n", lines.String())
+
+	stop := errors.New("stop")
+	var docs []json.RawMessage
+	err = client.QueryJSONElementsFunc(
+		ctx,
+		"SELECT {1, 2, 3}",
+		func(doc json.RawMessage) error {
+			docs = append(docs, doc)
+			return stop
+		},
+	)
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []json.RawMessage{json.RawMessage("1")}, docs)
+}
+
+func TestDecodeDataMsgMarksElementFuncStreamed(t *testing.T) {
+	w := buff.NewWriter(nil)
+	w.BeginMessage(uint8(Data))
+	w.PushUint16(1) // element count
+	w.PushUint32(1) // element length
+	w.PushUint8('1')
+	w.EndMessage()
+
+	stop := errors.New("stop")
+	q := &query{elementFunc: func(json.RawMessage) error { return stop }}
+	r := buff.SimpleReader(w.Unwrap()[5:])
+	_, err := decodeDataMsg(r, q, nil, reflect.Value{})
+	assert.ErrorIs(t, err, stop)
+	assert.True(t, q.streamed, "elements passed to the callback can't be "+
+		"retried")
+}
+
+type reuseRow struct {
+	Name string
+	Tags []string
+}
+
+// reuseDecoder decodes a string into Name and appends it to Tags.
+type reuseDecoder struct{}
+
+func (reuseDecoder) DescriptorID() types.UUID { return types.UUID{} }
+
+func (reuseDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	row := (*reuseRow)(out)
+	row.Name = string(r.Buf)
+	row.Tags = append(row.Tags, row.Name)
+	r.Discard(len(r.Buf))
+	return nil
+}
+
+func TestDecodeElementZeroesReusedCapacity(t *testing.T) {
+	q := &query{expCard: Many, outType: reflect.TypeOf(reuseRow{})}
+	rows := make([]reuseRow, 0, 1)
+
+	out, err := decodeElement(
+		buff.SimpleReader([]byte("a")), q, reuseDecoder{},
+		reflect.ValueOf(rows))
+	require.NoError(t, err)
+	rows = out.Interface().([]reuseRow)
+	require.Equal(t, []reuseRow{{Name: "a", Tags: []string{"a"}}}, rows)
+	first := rows[0].Tags
+
+	out, err = decodeElement(
+		buff.SimpleReader([]byte("b")), q, reuseDecoder{},
+		reflect.ValueOf(rows[:0]))
+	require.NoError(t, err)
+	assert.Equal(t, []reuseRow{{Name: "b", Tags: []string{"b"}}},
+		out.Interface().([]reuseRow))
+	assert.Equal(t, []string{"a"}, first,
+		"nested slices must not alias the previous rows")
+}
+
+func TestQueryIter(t *testing.T) {
+	ctx := context.Background()
+	iter := client.QueryIter(ctx, "SELECT {1, 2, 3}")
+	var results []int64
+	var result int64
+	for iter.Next(&result) {
+		results = append(results, result)
+	}
+	require.NoError(t, iter.Err())
+	require.NoError(t, iter.Close())
+	assert.Equal(t, []int64{1, 2, 3}, results)
+
+	// Closing the iterator early ends the query.
+	iter = client.QueryIter(ctx, "SELECT {1, 2, 3}")
+	require.True(t, iter.Next(&result))
+	assert.Equal(t, int64(1), result)
+	require.NoError(t, iter.Close())
+	assert.False(t, iter.Next(&result))
+
+	iter = client.QueryIter(ctx, "SELECT range_unpack(range(0, 10_000_000))")
+	require.True(t, iter.Next(&result))
+	require.NoError(t, iter.Close())
+
+	err := client.QuerySingle(ctx, "SELECT 4", &result)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), result)
+
+	iter = client.QueryIter(ctx, "SELECT {1, 2, 3}")
+	require.True(t, iter.Next(&result))
+	var wrongType string
+	assert.False(t, iter.Next(&wrongType))
+	assert.EqualError(t, iter.Err(), "edgedb.InterfaceError: "+
+		`the "out" argument must be *int64, got *string`)
+	assert.Equal(t, iter.Err(), iter.Close())
+
+	iter = client.QueryIter(ctx, "SELECT {1, 2, 3}")
+	assert.False(t, iter.Next(result))
+	assert.EqualError(t, iter.Err(), "edgedb.InterfaceError: "+
+		`the "out" argument must be a non nil pointer, got int64`)
+}
+
 func TestQuerySingleJSON(t *testing.T) {
 	ctx := context.Background()
 	var result []byte
@@ -656,6 +846,34 @@ func TestWithoutModuleAliases(t *testing.T) {
 		"        ^ error")
 }
 
+func TestWithDefaultModule(t *testing.T) {
+	if protocolVersion.LT(protocolVersion1p0) {
+		t.Skip()
+	}
+
+	ctx := context.Background()
+	var result float64
+
+	err := client.QuerySingle(ctx, "SELECT mean({1, 3})", &result)
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(InvalidReferenceError), err)
+
+	m := client.WithDefaultModule("math")
+	err = m.QuerySingle(ctx, "SELECT mean({1, 3})", &result)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), result)
+
+	err = m.WithoutDefaultModule().
+		QuerySingle(ctx, "SELECT mean({1, 3})", &result)
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(InvalidReferenceError), err)
+
+	err = client.QuerySingle(ctx, "SELECT mean({1, 3})", &result)
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(InvalidReferenceError), err)
+}
+
 func TestWithGlobals(t *testing.T) {
 	if protocolVersion.LT(protocolVersion1p0) {
 		t.Skip()
@@ -1126,3 +1344,385 @@ func TestWithConfigWrongServerVersion(t *testing.T) {
 		"are not supported by the server. "+
 		"Upgrade your server to version 2.0 or greater to use these features.")
 }
+
+func TestQueryArrayChunking(t *testing.T) {
+	ctx := context.Background()
+	c := client.WithArrayChunking(2)
+
+	var result []int64
+	err := c.Query(ctx, "SELECT array_unpack(<array<int64>>$0)",
+		&result, []int64{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4, 5}, result)
+
+	err = c.Query(ctx, "SELECT array_unpack(<array<int64>>$ids) * $factor",
+		&result, map[string]interface{}{
+			"ids":    []int64{1, 2, 3},
+			"factor": int64(10),
+		})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{10, 20, 30}, result)
+}
+
+func TestQueryReusesOutCapacity(t *testing.T) {
+	ctx := context.Background()
+
+	type Result struct {
+		Names []string `edgedb:"names"`
+	}
+
+	query := "SELECT {(names := ['a', 'b']), (names := ['c'])}"
+	result := make([]Result, 0, 4)
+	err := client.Query(ctx, query, &result)
+	require.NoError(t, err)
+
+	first := &result[0]
+	names := &result[0].Names[0]
+
+	err = client.Query(ctx, query, &result)
+	require.NoError(t, err)
+
+	expected := []Result{{Names: []string{"a", "b"}}, {Names: []string{"c"}}}
+	assert.Equal(t, expected, result)
+	assert.Equal(t, 4, cap(result))
+	assert.Same(t, first, &result[0], "rows should not be reallocated")
+	assert.Same(t, names, &result[0].Names[0],
+		"nested slices should not be reallocated")
+}
+
+type failingInt64 int64
+
+func (i *failingInt64) UnmarshalEdgeDBInt64(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("wrong number of bytes")
+	}
+
+	val := int64(binary.BigEndian.Uint64(data))
+	if val == 3 {
+		return errors.New("three is not allowed")
+	}
+
+	*i = failingInt64(val)
+	return nil
+}
+
+func TestQueryPartialResults(t *testing.T) {
+	ctx := context.Background()
+	query := "SELECT {1, 2, 3, 4}"
+
+	var result []failingInt64
+	err := client.Query(ctx, query, &result)
+	assert.EqualError(t, err, "three is not allowed")
+	assert.Empty(t, result)
+
+	err = client.WithPartialResults(true).Query(ctx, query, &result)
+	assert.EqualError(t, err, "three is not allowed")
+	assert.Equal(t, []failingInt64{1, 2}, result)
+}
+
+func TestQueryMeta(t *testing.T) {
+	ctx := context.Background()
+
+	var result []struct {
+		Name string  `edgedb:"name"`
+		Nums []int64 `edgedb:"nums"`
+	}
+	meta, err := client.QueryMeta(ctx,
+		"SELECT (name := 'a', nums := [1, 2])", &result)
+	require.NoError(t, err)
+
+	assert.Equal(t, "One", meta.Cardinality)
+	assert.Equal(t, "namedtuple", meta.Type.Kind)
+	require.Equal(t, 2, len(meta.Type.Fields))
+
+	name := meta.Type.Fields[0]
+	assert.Equal(t, "name", name.Name)
+	assert.Equal(t, ResultType{Name: "std::str", Kind: "scalar"}, name.Type)
+
+	nums := meta.Type.Fields[1]
+	assert.Equal(t, "nums", nums.Name)
+	assert.Equal(t, "array", nums.Type.Kind)
+	require.Equal(t, 1, len(nums.Type.Fields))
+	assert.Equal(t, "std::int64", nums.Type.Fields[0].Type.Name)
+
+	var objects []struct {
+		Name string `edgedb:"name"`
+	}
+	meta, err = client.QueryMeta(ctx,
+		"SELECT schema::ObjectType { name } LIMIT 1", &objects)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Many", meta.Cardinality)
+	assert.Equal(t, "object", meta.Type.Kind)
+
+	var explicit []string
+	for _, field := range meta.Type.Fields {
+		if !field.Implicit {
+			explicit = append(explicit, field.Name)
+		}
+	}
+	assert.Equal(t, []string{"name"}, explicit)
+
+	digest := meta.Digest
+	meta, err = client.QueryMeta(ctx,
+		"SELECT schema::ObjectType {\n\tname\n} LIMIT 1;", &objects)
+	require.NoError(t, err)
+	assert.Equal(t, digest, meta.Digest)
+}
+
+func TestQueryDynamic(t *testing.T) {
+	ctx := context.Background()
+
+	rows, err := client.QueryDynamic(ctx, `
+		SELECT (
+			name := 'a',
+			num := <int64>$0,
+			tags := ['x', 'y'],
+			pair := (1.5, <cal::local_date>'2021-01-02'),
+		)`, int64(7))
+	require.NoError(t, err)
+
+	date := types.NewLocalDate(2021, 1, 2)
+	assert.Equal(t, []map[string]interface{}{{
+		"name": "a",
+		"num":  int64(7),
+		"tags": []interface{}{"x", "y"},
+		"pair": []interface{}{1.5, date},
+	}}, rows)
+
+	rows, err = client.QueryDynamic(ctx,
+		"SELECT schema::ObjectType { name } FILTER .name = 'std::Object'")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rows))
+	assert.Equal(t, "std::Object", rows[0]["name"])
+
+	_, err = client.QueryDynamic(ctx, "SELECT 1")
+	assert.Error(t, err)
+}
+
+func TestQueryFreeAndConfigObjects(t *testing.T) {
+	ctx := context.Background()
+
+	type User struct {
+		Name types.OptionalStr `edgedb:"name"`
+	}
+	var free struct {
+		Num   int64   `edgedb:"num"`
+		Str   string  `edgedb:"str"`
+		Nums  []int64 `edgedb:"nums"`
+		Users []User  `edgedb:"users"`
+	}
+	err := client.QuerySingle(ctx, `
+		WITH u := (INSERT User { name := 'free' })
+		SELECT {
+			num := 1,
+			str := 'a',
+			nums := {2, 3},
+			users := (SELECT u { name }),
+		}`,
+		&free,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), free.Num)
+	assert.Equal(t, "a", free.Str)
+	assert.Equal(t, []int64{2, 3}, free.Nums)
+	require.Equal(t, 1, len(free.Users))
+	name, _ := free.Users[0].Name.Get()
+	assert.Equal(t, "free", name)
+
+	var config struct {
+		Timeout types.OptionalDuration `edgedb:"query_execution_timeout"`
+	}
+	err = client.QuerySingle(ctx,
+		"SELECT cfg::Config { query_execution_timeout } LIMIT 1", &config)
+	require.NoError(t, err)
+}
+
+func TestQueryScalarSet(t *testing.T) {
+	ctx := context.Background()
+
+	var names []string
+	err := client.Query(ctx, "SELECT {'a', 'b'}", &names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	err = client.Query(ctx, "SELECT <str>{}", &names)
+	require.NoError(t, err)
+	assert.NotNil(t, names, "empty sets should truncate the out slice")
+	assert.Empty(t, names)
+
+	var numbers []int64
+	err = client.Query(ctx, "SELECT {1, 2, 3}", &numbers)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, numbers)
+
+	numbers = nil
+	err = client.Query(ctx, "SELECT <int64>{}", &numbers)
+	require.NoError(t, err)
+	assert.Empty(t, numbers)
+}
+
+func TestQueryOptions(t *testing.T) {
+	ctx := context.Background()
+
+	limited := client.WithQueryOptions(NewQueryOptions().WithImplicitLimit(2))
+	var numbers []int64
+	err := limited.Query(ctx, "SELECT {1, 2, 3}", &numbers)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, numbers)
+
+	err = client.Query(ctx, "SELECT {1, 2, 3}", &numbers)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, numbers)
+
+	readOnly := client.WithQueryOptions(NewQueryOptions().WithReadOnly(true))
+	err = readOnly.Execute(ctx, "INSERT User { name := 'read only' }")
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(DisabledCapabilityError), err)
+
+	noDDL := client.WithQueryOptions(NewQueryOptions().
+		WithAllowedCapabilities(CapabilityAll &^ CapabilityDDL))
+	err = noDDL.Execute(ctx, "CREATE TYPE QueryOptionsTest")
+	require.True(t, errors.As(err, &edbErr), "wrong error: %v", err)
+	assert.True(t, edbErr.Category(DisabledCapabilityError), err)
+
+	var user struct {
+		ID   types.UUID `edgedb:"id"`
+		TID  types.UUID `edgedb:"__tid__"`
+		Name string     `edgedb:"name"`
+	}
+	typeIDs := client.WithQueryOptions(
+		NewQueryOptions().WithImplicitTypeIDs(true))
+	err = typeIDs.QuerySingle(ctx,
+		"SELECT User { name } LIMIT 1", &user)
+	require.NoError(t, err)
+	assert.NotEqual(t, types.UUID{}, user.TID)
+
+	var object struct {
+		ID       types.UUID `edgedb:"id"`
+		TypeName string     `edgedb:"__tname__"`
+	}
+	typeNames := client.WithQueryOptions(
+		NewQueryOptions().WithImplicitTypeNames(true))
+	err = typeNames.QuerySingle(ctx,
+		"SELECT Object FILTER Object IS User LIMIT 1", &object)
+	require.NoError(t, err)
+	assert.Equal(t, "default::User", object.TypeName)
+}
+
+func TestQueryWithoutQueryCache(t *testing.T) {
+	ctx := context.Background()
+	uncached := client.WithQueryOptions(
+		NewQueryOptions().WithQueryCache(false))
+
+	query := "SELECT 'uncached query'"
+	var result string
+	err := uncached.QuerySingle(ctx, query, &result)
+	require.NoError(t, err)
+	assert.Equal(t, "uncached query", result)
+
+	key := queryKey{
+		cmd:     query,
+		fmt:     Binary,
+		expCard: AtMostOne,
+		outType: reflect.TypeOf(result),
+	}
+	_, ok := client.typeIDCache.Get(key)
+	assert.False(t, ok, "uncached queries should not be cached")
+
+	err = client.QuerySingle(ctx, query, &result)
+	require.NoError(t, err)
+	_, ok = client.typeIDCache.Get(key)
+	assert.True(t, ok, "queries should be cached by default")
+}
+
+type countingResultCache struct {
+	ResultCache
+	hits int
+}
+
+func (c *countingResultCache) Get(key string) ([]byte, bool) {
+	val, ok := c.ResultCache.Get(key)
+	if ok {
+		c.hits++
+	}
+
+	return val, ok
+}
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+	results := &countingResultCache{
+		ResultCache: NewMemoryResultCache(10, time.Minute)}
+	cached := client.WithResultCache(results)
+
+	query := "SELECT {<str>$0, 'result cache'}"
+	for i := 0; i < 3; i++ {
+		var result []string
+		err := cached.Query(ctx, query, &result, "cached")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cached", "result cache"}, result)
+	}
+	assert.Greater(t, results.hits, 0)
+
+	hits := results.hits
+	var result []string
+	err := cached.Query(ctx, query, &result, "other")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other", "result cache"}, result)
+	assert.Equal(t, hits, results.hits, "different args should not hit")
+
+	err = cached.WithGlobals(map[string]interface{}{
+		"default::global_str": "x",
+	}).Query(ctx, query, &result, "cached")
+	require.NoError(t, err)
+	assert.Equal(t, hits, results.hits, "different globals should not hit")
+
+	err = cached.Execute(ctx, "INSERT User { name := 'result cache' }")
+	require.NoError(t, err)
+	query = "SELECT count(User)"
+	var count int64
+	err = cached.QuerySingle(ctx, query, &count)
+	require.NoError(t, err)
+	err = cached.QuerySingle(ctx, query, &count)
+	require.NoError(t, err)
+	assert.Equal(t, hits+1, results.hits)
+}
+
+func TestBytesReaderArgument(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("edgedb"), 100_000)
+
+	var result int64
+	err := client.QuerySingle(ctx, "SELECT len(<bytes>$0)", &result,
+		types.NewBytesReader(bytes.NewReader(data), len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), result)
+
+	// The connection is closed if the reader is too short.
+	err = client.QuerySingle(ctx, "SELECT len(<bytes>$0)", &result,
+		types.NewBytesReader(bytes.NewReader(data), len(data)+1))
+	var edbErr Error
+	require.True(t, errors.As(err, &edbErr), err)
+	assert.True(t, edbErr.Category(ClientConnectionClosedError), err)
+}
+
+func TestQuerySingleToWriter(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("edgedb"), 100_000)
+
+	var out bytes.Buffer
+	err := client.QuerySingle(ctx, "SELECT <bytes>$0", &out, data)
+	require.NoError(t, err)
+	assert.Equal(t, data, out.Bytes())
+
+	err = client.QuerySingle(ctx, "SELECT 1", &out)
+	expected := "edgedb.InvalidArgumentError: " +
+		"the \"out\" argument does not match query schema: " +
+		"expected []uint8 to be int64 or edgedb.OptionalInt64 got []uint8"
+	if protocolVersion.GTE(protocolVersion2p0) {
+		expected += " (server type std::int64)"
+	}
+	assert.EqualError(t, err, expected)
+}
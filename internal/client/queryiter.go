@@ -0,0 +1,217 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+)
+
+var errIteratorClosed = errors.New("the iterator was closed")
+
+// QueryIterator decodes the elements of a query's result one at a time as
+// they are received from the server. See Client.QueryIter.
+// A QueryIterator is not safe for concurrent use.
+type QueryIterator struct {
+	client *Client
+	cmd    string
+	args   []interface{}
+
+	// ctx is canceled by Close to end the query early.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// typ is the element type. It is set by the first call to Next.
+	typ reflect.Type
+
+	// n is the number of elements that have been decoded.
+	n int
+
+	// next receives the destination of the next element.
+	next chan unsafe.Pointer
+	// decoded is sent to after an element is decoded.
+	decoded chan struct{}
+	// stop is closed by Close.
+	stop chan struct{}
+	// done is closed when the query has finished and err is set.
+	done chan struct{}
+
+	err     error
+	started bool
+	closed  bool
+}
+
+// QueryIter returns an iterator over the result of a query. The elements are
+// decoded as they are received instead of being held in memory at once, so
+// large results can be processed with bounded memory.
+//
+// The query is sent by the first call to Next, and the iterator holds one of
+// the client's connections until Next returns false or Close is called.
+// Close must always be called. A query that fails after an element has been
+// decoded is not retried.
+func (p *Client) QueryIter(
+	ctx context.Context,
+	cmd string,
+	args ...interface{},
+) *QueryIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	return &QueryIterator{
+		client:  p,
+		cmd:     cmd,
+		args:    args,
+		ctx:     ctx,
+		cancel:  cancel,
+		next:    make(chan unsafe.Pointer),
+		decoded: make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Next decodes the next element into out and returns true. out must be a
+// pointer to the same type on every call. Next returns false if there are no
+// more elements or if the query failed, see Err.
+func (i *QueryIterator) Next(out interface{}) bool {
+	if i.closed {
+		return false
+	}
+
+	val := reflect.ValueOf(out)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		i.fail(&interfaceError{msg: fmt.Sprintf(
+			`the "out" argument must be a non nil pointer, got %T`, out)})
+		return false
+	}
+
+	if !i.started {
+		i.typ = val.Type().Elem()
+		i.started = true
+		go i.run()
+	} else if val.Type().Elem() != i.typ {
+		i.fail(&interfaceError{msg: fmt.Sprintf(
+			`the "out" argument must be *%v, got %T`, i.typ, out)})
+		return false
+	}
+
+	select {
+	case i.next <- unsafe.Pointer(val.Pointer()):
+	case <-i.done:
+		return false
+	}
+
+	select {
+	case <-i.decoded:
+		return true
+	case <-i.done:
+		return false
+	}
+}
+
+// Err returns the error that ended the iteration, if any.
+func (i *QueryIterator) Err() error {
+	select {
+	case <-i.done:
+		return i.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the query if it is still running and returns the error that
+// ended the iteration, if any. A running query is ended by closing its
+// connection instead of reading the rest of the result, the client opens a
+// new connection when one is needed.
+func (i *QueryIterator) Close() error {
+	if i.closed {
+		return i.err
+	}
+
+	i.closed = true
+	i.cancel()
+	if i.started {
+		close(i.stop)
+	} else {
+		close(i.done)
+	}
+
+	<-i.done
+	return i.err
+}
+
+// fail closes the iterator with err.
+func (i *QueryIterator) fail(err error) {
+	_ = i.Close()
+	i.err = err
+}
+
+// run runs the query on one of the client's connections.
+func (i *QueryIterator) run() {
+	defer close(i.done)
+
+	p := i.client
+	conn, err := p.acquire(i.ctx)
+	if err != nil {
+		i.err = err
+		return
+	}
+
+	err = runQuery(
+		i.ctx, conn, "Query", i.cmd, i, i.args, p.state, p.queryOpts)
+	i.err = firstError(err, p.release(conn, err))
+
+	select {
+	case <-i.stop:
+		// Errors caused by Close canceling the query are not reported.
+		if errors.Is(i.err, errIteratorClosed) ||
+			errors.Is(i.err, context.Canceled) {
+			i.err = nil
+		}
+	default:
+	}
+}
+
+// yield decodes a result element into the destination passed to Next.
+func (i *QueryIterator) yield(
+	r *buff.Reader,
+	q *query,
+	decoder codecs.Decoder,
+) error {
+	var out unsafe.Pointer
+	select {
+	case out = <-i.next:
+	case <-i.stop:
+		return errIteratorClosed
+	case <-i.ctx.Done():
+		return i.ctx.Err()
+	}
+
+	// The element is consumed by the caller so it can't be retried.
+	q.streamed = true
+	if err := decode(r, q, decoder, out); err != nil {
+		return codecs.WrapIndex(err, i.n)
+	}
+
+	i.n++
+	i.decoded <- struct{}{}
+	return nil
+}
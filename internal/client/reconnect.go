@@ -19,6 +19,7 @@ package edgedb
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 )
 
@@ -27,6 +28,9 @@ type reconnectingConn struct {
 	cacheCollection
 	cfg *connConfig
 
+	// stats are kept when the connection is reestablished.
+	stats *connStats
+
 	// isClosed is true when the connection has been closed by a user.
 	isClosed bool
 }
@@ -42,14 +46,21 @@ func (c *reconnectingConn) reconnect(
 		return &interfaceError{msg: "Connection is closed"}
 	}
 
-	maxTime := time.Now().Add(c.cfg.waitUntilAvailable)
+	clock := c.cfg.timeSource()
+	maxTime := clock.Now().Add(c.cfg.waitUntilAvailable)
 	if deadline, ok := ctx.Deadline(); ok && deadline.Before(maxTime) {
 		maxTime = deadline
 	}
 
 	var edbErr Error
 	for {
-		conn, err := connectWithTimeout(ctx, c.cfg, c.cacheCollection)
+		if err := c.cfg.breaker.allow(); err != nil {
+			return err
+		}
+
+		conn, err := connectWithTimeout(
+			ctx, c.cfg, c.cacheCollection, c.stats)
+		c.cfg.breaker.record(ctx, err)
 		if err == nil {
 			c.conn = conn
 			return nil
@@ -60,11 +71,12 @@ func (c *reconnectingConn) reconnect(
 			!errors.As(err, &edbErr) ||
 			!edbErr.Category(ClientConnectionError) ||
 			!edbErr.HasTag(ShouldReconnect) ||
-			time.Now().After(maxTime) {
+			clock.Now().After(maxTime) {
 			return err
 		}
 
-		time.Sleep(time.Duration(10+rnd.Intn(200)) * time.Millisecond)
+		jitter := c.cfg.randSource().Intn(200)
+		clock.Sleep(time.Duration(10+jitter) * time.Millisecond)
 	}
 }
 
@@ -82,6 +94,15 @@ func (c *reconnectingConn) scriptFlow(ctx context.Context, q *query) error {
 		return e
 	}
 
+	err := c.borrowableConn.scriptFlow(ctx, q)
+	if !c.idleTimedOut(q, err) {
+		return err
+	}
+
+	if e := c.reconnectIdle(ctx); e != nil {
+		return e
+	}
+
 	return c.borrowableConn.scriptFlow(ctx, q)
 }
 
@@ -93,9 +114,38 @@ func (c *reconnectingConn) granularFlow(
 		return e
 	}
 
+	err := c.borrowableConn.granularFlow(ctx, q)
+	if !c.idleTimedOut(q, err) {
+		return err
+	}
+
+	if e := c.reconnectIdle(ctx); e != nil {
+		return e
+	}
+
+	q.resetAttempt()
 	return c.borrowableConn.granularFlow(ctx, q)
 }
 
+// idleTimedOut returns true if the server closed the connection for being
+// idle before it ran q. The server only closes idle connections, so q can be
+// sent again on a new connection without counting as a retry attempt.
+// Queries with streamed arguments can't be sent again because their readers
+// may have been consumed.
+func (c *reconnectingConn) idleTimedOut(q *query, err error) bool {
+	return !q.streamed && !c.isClosed && isIdleSessionTimeout(err)
+}
+
+// reconnectIdle replaces a connection that the server closed for being idle.
+// The old socket may not have seen the server close it yet.
+func (c *reconnectingConn) reconnectIdle(ctx context.Context) error {
+	if err := c.conn.soc.Close(); err != nil {
+		log.Println("error closing socket:", err)
+	}
+
+	return c.reconnect(ctx, false)
+}
+
 // Close closes the connection. Connections are not usable after they are
 // closed.
 func (c *reconnectingConn) Close() (err error) {
@@ -104,6 +154,7 @@ func (c *reconnectingConn) Close() (err error) {
 	}
 
 	c.isClosed = true
+	c.stats.close()
 	if c.conn != nil && !c.conn.isClosed() {
 		err = c.conn.close()
 	}
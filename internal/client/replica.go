@@ -0,0 +1,86 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"errors"
+)
+
+// WithReadOnly returns a copy of the client whose queries and transactions
+// are read only. If Options.ReadReplicas is set the copy connects to the
+// read replicas instead of the primary. Its connections are shared with all
+// other read only copies of the client.
+//
+// Execute, Query, QuerySingle, QueryJSON, QuerySingleJSON and
+// QueryJSONElements are run again on the primary if a read replica can not
+// be reached or if it reports an InvalidReferenceError, which happens when
+// its schema has not caught up with the primary yet. Queries that have
+// streamed arguments from an io.Reader or results to an io.Writer are not
+// run again.
+func (p Client) WithReadOnly() *Client { // nolint:gocritic
+	p.queryOpts.settings = p.queryOpts.settings.WithReadOnly(true)
+	p.txOpts = p.txOpts.WithReadOnly(true)
+
+	if p.cfg.replicas == nil {
+		return &p
+	}
+
+	p.pool = p.pool.readOnlyPool(p.cfg)
+	cfg := *p.cfg
+	cfg.hosts = cfg.replicas
+	cfg.replicas = nil
+	// Failed replicas fall back to the primary which has its own circuit
+	// breaker, the replicas must not open it.
+	cfg.breaker = nil
+	p.cfg = &cfg
+	return &p
+}
+
+// readOnlyPool returns the pool of connections to the read replicas.
+// cfg is the primary's configuration.
+func (p *pool) readOnlyPool(cfg *connConfig) *pool {
+	p.potentialConnsMutext.Lock()
+	defer p.potentialConnsMutext.Unlock()
+
+	if p.readOnly == nil {
+		p.readOnly = newPool(p.concurrency)
+		p.readOnly.primary = p
+		p.readOnly.primaryCfg = cfg
+	}
+
+	return p.readOnly
+}
+
+// replicaFallback returns a copy of a read replica client that runs its
+// queries on the primary if err shows that the replica is unavailable or
+// behind the primary.
+func (p *Client) replicaFallback(err error) (*Client, bool) {
+	if err == nil || p.pool.primary == nil {
+		return nil, false
+	}
+
+	var edbErr Error
+	if !isClientConnectionError(err) &&
+		!(errors.As(err, &edbErr) && edbErr.Category(InvalidReferenceError)) {
+		return nil, false
+	}
+
+	primary := *p
+	primary.pool = p.pool.primary
+	primary.cfg = p.pool.primaryCfg
+	return &primary, true
+}
@@ -0,0 +1,153 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaFallbackHost(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{
+		Host:         "primary",
+		ReadReplicas: []string{"r1", "r2:1234"},
+	}
+
+	cfg, err := parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	require.NoError(t, err)
+	assert.Nil(t, cfg.hosts)
+
+	b := cfg.replicas
+	require.NotNil(t, b)
+	assert.Equal(t, []string{"r1:5656", "r2:1234"}, addrs(b.hosts...))
+
+	failed := &clientConnectionFailedError{msg: "failed"}
+	b.hosts[0].record(ctx, failed, 0)
+	assert.Equal(t, "r2:1234", b.pick().addr.address)
+
+	b.hosts[1].record(ctx, failed, 0)
+	assert.Equal(t, "primary:5656", b.pick().addr.address,
+		"the primary is picked if no replica is healthy")
+	assert.Equal(t, "r1:5656", b.pick().addr.address,
+		"the replicas are tried again after falling back")
+}
+
+func TestWithReadOnly(t *testing.T) {
+	opts := Options{Host: "primary", ReadReplicas: []string{"replica"}}
+	cfg, err := parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	require.NoError(t, err)
+
+	False := false
+	p := &Client{
+		isClosed:      &False,
+		isClosedMutex: &sync.RWMutex{},
+		pool:          newPool(2),
+		txOpts:        NewTxOptions(),
+		queryOpts:     queryOptions{settings: NewQueryOptions()},
+		cfg:           cfg,
+	}
+
+	r1 := p.WithReadOnly()
+	r2 := p.WithQueryOptions(NewQueryOptions()).WithReadOnly()
+
+	assert.NotSame(t, p.pool, r1.pool)
+	assert.Same(t, r1.pool, r2.pool, "read only clients share a pool")
+	assert.Same(t, r1.pool, p.pool.readOnly)
+	assert.Same(t, p.pool, r1.pool.primary)
+	assert.Equal(t, 2, r1.concurrency)
+
+	assert.Same(t, cfg.replicas, r1.cfg.hosts)
+	assert.Nil(t, r1.cfg.replicas)
+	assert.Equal(t, "START TRANSACTION ISOLATION SERIALIZABLE, READ ONLY, "+
+		"NOT DEFERRABLE;", r1.txOpts.startTxQuery())
+	assert.Equal(t, uint64(0), r1.queryOpts.settings.allowedCapabilities(
+		uint64(CapabilityModifications|CapabilityDDL)))
+
+	primary, ok := r1.replicaFallback(
+		&clientConnectionFailedError{msg: "failed"})
+	require.True(t, ok)
+	assert.Same(t, p.pool, primary.pool)
+	assert.Same(t, cfg, primary.cfg)
+	assert.Equal(t, r1.txOpts, primary.txOpts)
+
+	_, ok = r1.replicaFallback(&invalidReferenceError{msg: "not found"})
+	assert.True(t, ok, "stale schema falls back to the primary")
+
+	_, ok = r1.replicaFallback(errors.New("other"))
+	assert.False(t, ok)
+
+	_, ok = p.replicaFallback(&clientConnectionFailedError{msg: "failed"})
+	assert.False(t, ok, "the primary has no fallback")
+}
+
+func TestReplicaFallbackSkipsStreamed(t *testing.T) {
+	opts := Options{Host: "primary", ReadReplicas: []string{"replica"}}
+	cfg, err := parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	require.NoError(t, err)
+
+	False := false
+	p := &Client{
+		isClosed:      &False,
+		isClosedMutex: &sync.RWMutex{},
+		pool:          newPool(1),
+		txOpts:        NewTxOptions(),
+		queryOpts:     queryOptions{settings: NewQueryOptions()},
+		cfg:           cfg,
+	}
+
+	r := p.WithReadOnly()
+	conn := &transactableConn{reconnectingConn: &reconnectingConn{
+		borrowableConn: borrowableConn{conn: &protocolConnection{
+			systemConfig: systemConfig{
+				SessionIdleTimeout: types.NewOptionalDuration(0),
+			},
+		}},
+	}}
+	r.potentialConns = make(chan struct{}, 1)
+	r.freeConns <- func() *transactableConn { return conn }
+
+	calls := 0
+	err = r.run(context.Background(), func(c *transactableConn) error {
+		calls++
+		c.recordStreamed(&query{streamed: true})
+		return &invalidReferenceError{msg: "not found"}
+	})
+	assert.EqualError(t, err, "edgedb.InvalidReferenceError: not found")
+	assert.Equal(t, 1, calls, "streamed queries are not run on the primary")
+}
+
+func TestWithReadOnlyWithoutReplicas(t *testing.T) {
+	p := &Client{
+		pool:      newPool(1),
+		txOpts:    NewTxOptions(),
+		queryOpts: queryOptions{settings: NewQueryOptions()},
+		cfg:       &connConfig{},
+	}
+
+	r := p.WithReadOnly()
+	assert.Same(t, p.pool, r.pool)
+	assert.Nil(t, p.pool.readOnly)
+	assert.True(t, r.txOpts.readOnly)
+	assert.True(t, r.queryOpts.settings.readOnly)
+}
@@ -0,0 +1,278 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/cache"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+)
+
+// ResultCache stores encoded query results so that repeated read only
+// queries can be answered without a round trip to the server.
+//
+// Keys are a digest of the server address, database, user, the query, its
+// arguments and the client's state including globals, so clients that share
+// a cache never receive each other's results. Values are opaque and must be
+// returned unmodified. Implementations must be safe for concurrent use.
+type ResultCache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, value []byte)
+}
+
+// NewMemoryResultCache returns a ResultCache that keeps up to size results in
+// memory. Results expire ttl after they are stored. A ttl less than one keeps
+// results until they are evicted.
+// Expiry uses the Clock of the client that the cache is used with.
+func NewMemoryResultCache(size int, ttl time.Duration) ResultCache {
+	return &memoryResultCache{
+		ttl:   ttl,
+		cache: cache.New(size),
+		clock: systemClock{},
+	}
+}
+
+type memoryResultCache struct {
+	ttl   time.Duration
+	cache *cache.Cache
+	clock Clock
+}
+
+// withClock returns a copy of c that shares its results and uses clock.
+func (c *memoryResultCache) withClock(clock Clock) *memoryResultCache {
+	cp := *c
+	cp.clock = clock
+	return &cp
+}
+
+type memoryResult struct {
+	value   []byte
+	expires time.Time
+}
+
+func (c *memoryResultCache) Get(key string) ([]byte, bool) {
+	val, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	result := val.(memoryResult)
+	if c.ttl > 0 && c.clock.Now().After(result.expires) {
+		return nil, false
+	}
+
+	return result.value, true
+}
+
+func (c *memoryResultCache) Put(key string, value []byte) {
+	c.cache.Put(key, memoryResult{value, c.clock.Now().Add(c.ttl)})
+}
+
+// WithResultCache returns a shallow copy of the client that serves Query and
+// QuerySingle results from cache. Only the results of queries that the
+// server reports as having no capabilities, i.e. read only queries, are
+// stored. Queries in transactions never use the cache. A nil cache disables
+// result caching.
+func (p Client) WithResultCache( // nolint:gocritic
+	cache ResultCache,
+) *Client {
+	if memory, ok := cache.(*memoryResultCache); ok {
+		cache = memory.withClock(p.cfg.timeSource())
+	}
+
+	p.queryOpts.resultCache = cache
+	return &p
+}
+
+// queryCached runs a query, using the client's result cache if possible.
+// If meta is not nil it is filled with the query's result metadata.
+func (p *Client) queryCached(
+	ctx context.Context,
+	method, cmd string,
+	out interface{},
+	args []interface{},
+	meta *ResultMeta,
+) error {
+	q, err := newQuery(method, cmd, args, userCapabilities, p.state, out)
+	if err != nil {
+		return err
+	}
+	q.setOptions(p.queryOpts)
+	q.meta = meta
+
+	if key, ids, ok := p.resultKey(q); ok {
+		data, ok := q.resultCache.Get(key)
+		if ok && p.setCachedMeta(q, ids) && p.setCached(q, ids, data) {
+			return nil
+		}
+	}
+
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.recordResults = true
+	err = q.exec(ctx, conn, out)
+	if err == nil &&
+		q.capabilitiesReported &&
+		q.reportedCapabilities == 0 &&
+		(q.expCard == Many || len(q.results) == 1) {
+		if key, _, ok := p.resultKey(q); ok {
+			q.resultCache.Put(key, encodeResults(q.results))
+		}
+	}
+
+	return firstError(err, p.release(conn, err))
+}
+
+// resultKey returns the result cache key for q. ok is false if the query's
+// descriptors are not known yet.
+func (p *Client) resultKey(q *query) (key string, ids idPair, ok bool) {
+	if q.settings.noCache || q.writer != nil {
+		return "", ids, false
+	}
+
+	val, ok := p.typeIDCache.Get(makeKey(q))
+	if !ok {
+		return "", ids, false
+	}
+	ids = val.(idPair)
+
+	in, ok := p.inCodecCache.Get(ids.in)
+	if !ok {
+		return "", ids, false
+	}
+
+	state, err := json.Marshal(q.state)
+	if err != nil {
+		return "", ids, false
+	}
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	w.PushString(p.cfg.addr.address)
+	w.PushString(p.cfg.database)
+	w.PushString(p.cfg.user)
+	w.PushString(q.cmd)
+	w.PushUint8(uint8(q.fmt))
+	w.PushUint8(uint8(q.expCard))
+	w.PushUint64(q.settings.compilationFlags())
+	w.PushUint64(q.settings.implicitLimit)
+	w.PushUUID(ids.in)
+	w.PushUUID(ids.out)
+	err = in.(codecs.Encoder).Encode(w, q.args, codecs.Path("args"), true)
+	if err != nil {
+		return "", ids, false
+	}
+	w.PushBytes(state)
+	w.EndMessage()
+
+	if w.Streaming() {
+		// Streamed arguments can not be read without consuming them.
+		return "", ids, false
+	}
+
+	sum := sha256.Sum256(w.Unwrap())
+	return hex.EncodeToString(sum[:]), ids, true
+}
+
+// setCachedMeta sets the result metadata of q from the cached descriptors
+// ids. It returns false if they are no longer cached.
+func (p *Client) setCachedMeta(q *query, ids idPair) bool {
+	if q.meta == nil {
+		return true
+	}
+
+	typ, ok := cachedResultType(ids.out)
+	if !ok {
+		return false
+	}
+
+	q.meta.Type = typ
+	q.meta.Cardinality = ids.card.String()
+	q.meta.Digest = queryDigest(q.cmd, ids.out)
+	return true
+}
+
+// setCached decodes cached results into the query's out value.
+// It returns false if the results can not be decoded.
+func (p *Client) setCached(q *query, ids idPair, data []byte) bool {
+	val, ok := p.outCodecCache.Get(codecKey{ID: ids.out, Type: q.outType})
+	if !ok {
+		return false
+	}
+	decoder := val.(codecs.Decoder)
+
+	var (
+		count int
+		err   error
+	)
+
+	tmp := q.out
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return false
+		}
+
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(n) {
+			return false
+		}
+
+		tmp, err = decodeElement(buff.SimpleReader(data[:n]), q, decoder, tmp)
+		if err != nil {
+			return false
+		}
+
+		data = data[n:]
+		count++
+	}
+
+	if q.expCard == AtMostOne && count != 1 {
+		return false
+	}
+
+	q.setResult(tmp, nil)
+	return true
+}
+
+// encodeResults encodes result elements as length prefixed byte strings.
+func encodeResults(results [][]byte) []byte {
+	n := 0
+	for _, result := range results {
+		n += 4 + len(result)
+	}
+
+	data := make([]byte, n)
+	i := 0
+	for _, result := range results {
+		binary.BigEndian.PutUint32(data[i:], uint32(len(result)))
+		i += 4
+		i += copy(data[i:], result)
+	}
+
+	return data
+}
@@ -0,0 +1,126 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/cache"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryResultCache(t *testing.T) {
+	c := NewMemoryResultCache(2, time.Hour)
+	c.Put("a", []byte("1"))
+	c.Put("b", []byte("2"))
+	c.Put("c", []byte("3"))
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "the oldest result should be evicted")
+
+	val, ok := c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), val)
+
+	clock := newFakeClock()
+	p := &Client{cfg: &connConfig{clock: clock}}
+	expired := p.WithResultCache(
+		NewMemoryResultCache(2, time.Minute)).queryOpts.resultCache
+	expired.Put("a", []byte("1"))
+	clock.Advance(time.Second)
+	_, ok = expired.Get("a")
+	assert.True(t, ok, "results should not expire before their ttl")
+	clock.Advance(time.Minute)
+	_, ok = expired.Get("a")
+	assert.False(t, ok, "expired results should not be returned")
+
+	forever := p.WithResultCache(
+		NewMemoryResultCache(2, 0)).queryOpts.resultCache
+	forever.Put("a", []byte("1"))
+	clock.Advance(time.Hour)
+	_, ok = forever.Get("a")
+	assert.True(t, ok, "results without a ttl should not expire")
+}
+
+func TestResultKeyIncludesDatabaseAndUser(t *testing.T) {
+	encoder, err := codecs.BuildEncoderV2(
+		&descriptor.V2{}, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	key := func(database, user string) string {
+		p := &Client{
+			cfg: &connConfig{database: database, user: user},
+			cacheCollection: cacheCollection{
+				typeIDCache:  cache.New(1),
+				inCodecCache: cache.New(1),
+			},
+		}
+
+		var out []int64
+		q, err := newQuery("Query", "SELECT 1", nil, 0, nil, &out)
+		require.NoError(t, err)
+		p.typeIDCache.Put(makeKey(q), idPair{})
+		p.inCodecCache.Put(descriptor.IDZero, encoder)
+
+		key, _, ok := p.resultKey(q)
+		require.True(t, ok)
+		return key
+	}
+
+	assert.Equal(t, key("main", "edgedb"), key("main", "edgedb"))
+	assert.NotEqual(t, key("main", "edgedb"), key("other", "edgedb"))
+	assert.NotEqual(t, key("main", "edgedb"), key("main", "other"))
+}
+
+func TestSetCachedResults(t *testing.T) {
+	desc := descriptor.V2{Type: descriptor.Scalar, ID: codecs.StrID}
+	decoder, err := codecs.BuildDecoderV2(
+		&desc, reflect.TypeOf(""), codecs.Path("out"), codecs.DecoderOptions{})
+	require.NoError(t, err)
+
+	ids := idPair{out: codecs.StrID}
+	p := Client{cacheCollection: cacheCollection{outCodecCache: cache.New(1)}}
+	p.outCodecCache.Put(
+		codecKey{ID: codecs.StrID, Type: reflect.TypeOf("")}, decoder)
+
+	data := encodeResults([][]byte{[]byte("a"), []byte("bc")})
+
+	var many []string
+	q, err := newQuery("Query", "SELECT", nil, 0, nil, &many)
+	require.NoError(t, err)
+	require.True(t, p.setCached(q, ids, data))
+	assert.Equal(t, []string{"a", "bc"}, many)
+
+	var single string
+	q, err = newQuery("QuerySingle", "SELECT", nil, 0, nil, &single)
+	require.NoError(t, err)
+	assert.False(t, p.setCached(q, ids, data),
+		"QuerySingle should not accept more than one result")
+	assert.True(t, p.setCached(q, ids, encodeResults([][]byte{[]byte("x")})))
+	assert.Equal(t, "x", single)
+
+	q, err = newQuery("Query", "SELECT", nil, 0, nil, &many)
+	require.NoError(t, err)
+	assert.False(t, p.setCached(q, ids, data[:7]),
+		"truncated results should be rejected")
+}
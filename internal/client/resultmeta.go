@@ -0,0 +1,184 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"strings"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// ResultMeta describes the shape of a query's results.
+type ResultMeta struct {
+	// Cardinality is the result cardinality reported by the server,
+	// one of NoResult, AtMostOne, One, Many or AtLeastOne.
+	Cardinality string
+
+	// Type is the type of each result.
+	Type ResultType
+
+	// Digest identifies the query by its text and result type. Queries
+	// that only differ in whitespace, comments or trailing semicolons and
+	// have the same result type have the same digest, so it can be used to
+	// aggregate metrics or as a cache key for the logical query.
+	Digest string
+}
+
+// ResultType describes the type of a query result or of one of its fields.
+type ResultType struct {
+	// Name is the type's name, e.g. std::str or default::User.
+	// Name is empty for anonymous types like tuples.
+	// Servers using protocol versions before 2.0 only report the names of
+	// standard scalar types.
+	Name string
+
+	// Kind is one of scalar, enum, object, tuple, namedtuple, array,
+	// range or set.
+	Kind string
+
+	// Fields are the fields of object and named tuple types,
+	// the elements of tuple types, or the element type of
+	// array, range and set types.
+	Fields []ResultField
+}
+
+// ResultField describes one field of a query result.
+type ResultField struct {
+	// Name is the field's name. Tuple elements are named by their index.
+	Name string
+
+	// Cardinality is the field's cardinality, one of AtMostOne, One, Many
+	// or AtLeastOne. It is empty for fields that are not object fields.
+	Cardinality string
+
+	// Implicit is true for object fields that were not selected
+	// explicitly, e.g. __tid__.
+	Implicit bool
+
+	// Type is the field's type.
+	Type ResultType
+}
+
+var scalarNames = map[types.UUID]string{
+	codecs.UUIDID:             "std::uuid",
+	codecs.StrID:              "std::str",
+	codecs.BytesID:            "std::bytes",
+	codecs.Int16ID:            "std::int16",
+	codecs.Int32ID:            "std::int32",
+	codecs.Int64ID:            "std::int64",
+	codecs.Float32ID:          "std::float32",
+	codecs.Float64ID:          "std::float64",
+	codecs.DecimalID:          "std::decimal",
+	codecs.BoolID:             "std::bool",
+	codecs.DateTimeID:         "std::datetime",
+	codecs.LocalDTID:          "cal::local_datetime",
+	codecs.LocalDateID:        "cal::local_date",
+	codecs.LocalTimeID:        "cal::local_time",
+	codecs.DurationID:         "std::duration",
+	codecs.JSONID:             "std::json",
+	codecs.BigIntID:           "std::bigint",
+	codecs.RelativeDurationID: "cal::relative_duration",
+	codecs.DateDurationID:     "cal::date_duration",
+	codecs.MemoryID:           "cfg::memory",
+}
+
+// cachedResultType returns the type described by the cached descriptor id.
+// ok is false if the descriptor is not cached.
+func cachedResultType(id types.UUID) (typ ResultType, ok bool) {
+	desc, ok := descCache.Get(id)
+	if !ok {
+		return typ, false
+	}
+
+	switch d := desc.(type) {
+	case descriptor.Descriptor:
+		return resultType(d), true
+	case descriptor.V2:
+		return resultTypeV2(d), true
+	default:
+		return typ, false
+	}
+}
+
+func resultType(desc descriptor.Descriptor) ResultType {
+	switch desc.Type {
+	case descriptor.BaseScalar:
+		return ResultType{Name: scalarNames[desc.ID], Kind: "scalar"}
+	case descriptor.Scalar:
+		// Custom scalars are not named before protocol version 2.0
+		// so they are described by their base type.
+		return resultType(desc.Fields[0].Desc)
+	}
+
+	typ := ResultType{Kind: kindName(desc.Type)}
+	for _, field := range desc.Fields {
+		typ.Fields = append(typ.Fields, ResultField{
+			Name:        field.Name,
+			Cardinality: fieldCardinality(field.Cardinality),
+			Implicit:    field.Implicit,
+			Type:        resultType(field.Desc),
+		})
+	}
+
+	return typ
+}
+
+func resultTypeV2(desc descriptor.V2) ResultType {
+	typ := ResultType{
+		Name: desc.Name,
+		Kind: resultKindV2(desc.Type, desc.Fields),
+	}
+
+	for _, field := range desc.Fields {
+		typ.Fields = append(typ.Fields, ResultField{
+			Name:        field.Name,
+			Cardinality: fieldCardinality(field.Cardinality),
+			Implicit:    field.Implicit,
+			Type:        resultTypeV2(field.Desc),
+		})
+	}
+
+	return typ
+}
+
+func resultKindV2(typ descriptor.Type, fields []*descriptor.FieldV2) string {
+	// Named tuple descriptors are decoded as tuples with named fields.
+	if typ == descriptor.Tuple && len(fields) > 0 && fields[0].Name != "0" {
+		return "namedtuple"
+	}
+
+	return kindName(typ)
+}
+
+func kindName(typ descriptor.Type) string {
+	switch typ {
+	case descriptor.BaseScalar, descriptor.Scalar:
+		return "scalar"
+	default:
+		return strings.ToLower(typ.String())
+	}
+}
+
+func fieldCardinality(card uint8) string {
+	if card == 0 {
+		return ""
+	}
+
+	return Cardinality(card).String()
+}
@@ -0,0 +1,75 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import "time"
+
+// RetryEvent describes a failed query or transaction attempt
+// that is about to be retried.
+type RetryEvent struct {
+	// Query is the query that is retried.
+	// It is empty if a transaction is retried.
+	Query string
+
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+
+	// Condition is the reason for the retry,
+	// either TxConflict or NetworkError.
+	Condition RetryCondition
+
+	// Err is the error that the attempt failed with.
+	Err error
+
+	// Backoff is how long the client waits before the next attempt.
+	Backoff time.Duration
+}
+
+// RetryHandler is called every time a query or transaction is retried. It
+// can be used to count retries by query and condition to find contention
+// hotspots. It is called from the goroutine that runs the query before the
+// client waits for the backoff.
+type RetryHandler func(RetryEvent)
+
+// retryCondition returns the condition that err is retried for.
+func retryCondition(err Error) RetryCondition {
+	if err.Category(TransactionConflictError) {
+		return TxConflict
+	}
+
+	return NetworkError
+}
+
+// reportRetry calls the retry handler if one is set.
+func (o *queryOptions) reportRetry(
+	cmd string,
+	attempt int,
+	err Error,
+	backoff time.Duration,
+) {
+	if o.retryHandler == nil {
+		return
+	}
+
+	o.retryHandler(RetryEvent{
+		Query:     cmd,
+		Attempt:   attempt,
+		Condition: retryCondition(err),
+		Err:       err,
+		Backoff:   backoff,
+	})
+}
@@ -0,0 +1,58 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportRetry(t *testing.T) {
+	var events []RetryEvent
+	opts := queryOptions{
+		retryHandler: func(e RetryEvent) { events = append(events, e) },
+	}
+
+	conflict := &transactionSerializationError{msg: "conflict"}
+	opts.reportRetry("SELECT 1", 1, conflict, time.Second)
+
+	network := &clientConnectionClosedError{msg: "closed"}
+	opts.reportRetry("", 2, network, 2*time.Second)
+
+	assert.Equal(t, []RetryEvent{
+		{
+			Query:     "SELECT 1",
+			Attempt:   1,
+			Condition: TxConflict,
+			Err:       conflict,
+			Backoff:   time.Second,
+		},
+		{
+			Attempt:   2,
+			Condition: NetworkError,
+			Err:       network,
+			Backoff:   2 * time.Second,
+		},
+	}, events)
+
+	// Without a handler nothing is reported.
+	opts.retryHandler = nil
+	opts.reportRetry("SELECT 1", 1, conflict, time.Second)
+	assert.Len(t, events, 2)
+}
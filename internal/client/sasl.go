@@ -0,0 +1,120 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xdg/scram"
+)
+
+// SASLMechanism is the client side of a SASL authentication exchange.
+// A new SASLMechanism is created for every connection.
+type SASLMechanism interface {
+	// Start returns the client's initial response.
+	Start() ([]byte, error)
+
+	// Next returns the client's response to a server challenge.
+	Next(challenge []byte) ([]byte, error)
+
+	// Final verifies the server's final message.
+	Final(data []byte) error
+}
+
+// SASLMechanismFactory returns a SASLMechanism that authenticates with
+// creds.
+type SASLMechanismFactory func(creds AuthCredentials) (SASLMechanism, error)
+
+var (
+	saslMechanismsMutex sync.RWMutex
+	saslMechanisms      = map[string]SASLMechanismFactory{
+		"SCRAM-SHA-256": newSCRAMSHA256,
+	}
+)
+
+// RegisterSASLMechanism registers a SASL authentication mechanism. The first
+// mechanism offered by the server that has been registered is used to
+// authenticate. Registering a mechanism with the name of an already
+// registered mechanism, for example SCRAM-SHA-256, replaces it.
+func RegisterSASLMechanism(name string, factory SASLMechanismFactory) {
+	saslMechanismsMutex.Lock()
+	defer saslMechanismsMutex.Unlock()
+
+	saslMechanisms[name] = factory
+}
+
+// newSASLMechanism returns the first registered mechanism in methods.
+func newSASLMechanism(
+	methods []string,
+	cfg *connConfig,
+) (string, SASLMechanism, error) {
+	saslMechanismsMutex.RLock()
+	defer saslMechanismsMutex.RUnlock()
+
+	for _, method := range methods {
+		factory, ok := saslMechanisms[method]
+		if !ok {
+			continue
+		}
+
+		mechanism, err := factory(AuthCredentials{
+			User:      cfg.user,
+			Password:  cfg.password,
+			SecretKey: cfg.secretKey,
+		})
+		if err != nil {
+			return "", nil, &authenticationError{err: err}
+		}
+
+		return method, mechanism, nil
+	}
+
+	return "", nil, &authenticationError{msg: fmt.Sprintf(
+		"no supported authentication method, the server offers: %v",
+		strings.Join(methods, ", "))}
+}
+
+// scramSHA256 implements the SCRAM-SHA-256 mechanism.
+type scramSHA256 struct {
+	conv *scram.ClientConversation
+}
+
+func newSCRAMSHA256(creds AuthCredentials) (SASLMechanism, error) {
+	client, err := scram.SHA256.NewClient(creds.User, creds.Password, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &scramSHA256{conv: client.NewConversation()}, nil
+}
+
+func (m *scramSHA256) Start() ([]byte, error) {
+	msg, err := m.conv.Step("")
+	return []byte(msg), err
+}
+
+func (m *scramSHA256) Next(challenge []byte) ([]byte, error) {
+	msg, err := m.conv.Step(string(challenge))
+	return []byte(msg), err
+}
+
+func (m *scramSHA256) Final(data []byte) error {
+	_, err := m.conv.Step(string(data))
+	return err
+}
@@ -0,0 +1,79 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenMechanism struct {
+	token string
+}
+
+func (m *tokenMechanism) Start() ([]byte, error) {
+	return []byte(m.token), nil
+}
+
+func (m *tokenMechanism) Next([]byte) ([]byte, error) {
+	return nil, errors.New("unexpected challenge")
+}
+
+func (m *tokenMechanism) Final([]byte) error { return nil }
+
+func TestNewSASLMechanism(t *testing.T) {
+	cfg := &connConfig{user: "edgedb", password: "secret", secretKey: "jwt"}
+
+	method, mechanism, err := newSASLMechanism(
+		[]string{"UNKNOWN", "SCRAM-SHA-256"}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-256", method)
+
+	data, err := mechanism.Start()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "n,,n=edgedb,r="), data)
+
+	_, _, err = newSASLMechanism([]string{"UNKNOWN", "OTHER"}, cfg)
+	assert.EqualError(t, err, "edgedb.AuthenticationError: "+
+		"no supported authentication method, "+
+		"the server offers: UNKNOWN, OTHER")
+
+	RegisterSASLMechanism(
+		"TEST-TOKEN",
+		func(creds AuthCredentials) (SASLMechanism, error) {
+			return &tokenMechanism{creds.SecretKey}, nil
+		},
+	)
+	defer func() {
+		saslMechanismsMutex.Lock()
+		delete(saslMechanisms, "TEST-TOKEN")
+		saslMechanismsMutex.Unlock()
+	}()
+
+	method, mechanism, err = newSASLMechanism(
+		[]string{"TEST-TOKEN", "SCRAM-SHA-256"}, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "TEST-TOKEN", method)
+
+	data, err = mechanism.Start()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jwt"), data)
+}
@@ -0,0 +1,188 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaRequirements are checked by Client.AssertSchema.
+type SchemaRequirements struct {
+	// MinServerVersion is the oldest supported server version
+	// formatted as major.minor, e.g. 4.0. Any version is accepted if it is
+	// empty.
+	MinServerVersion string
+
+	// Extensions are the names of extensions that must be installed,
+	// e.g. pgvector.
+	Extensions []string
+
+	// ObjectTypes are the fully qualified names of object types that
+	// must exist, e.g. default::User.
+	ObjectTypes []string
+}
+
+type serverVersion struct {
+	major int64
+	minor int64
+}
+
+func (v serverVersion) less(other serverVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+
+	return v.minor < other.minor
+}
+
+func (v serverVersion) String() string {
+	return fmt.Sprintf("%v.%v", v.major, v.minor)
+}
+
+func parseServerVersion(s string) (serverVersion, error) {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) == 1 {
+		parts = append(parts, "0")
+	}
+
+	major, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || major < 0 {
+		return serverVersion{}, fmt.Errorf(
+			"invalid MinServerVersion %q, expected major.minor", s)
+	}
+
+	minor, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || minor < 0 {
+		return serverVersion{}, fmt.Errorf(
+			"invalid MinServerVersion %q, expected major.minor", s)
+	}
+
+	return serverVersion{major: major, minor: minor}, nil
+}
+
+const assertSchemaQuery = `
+SELECT {
+	major := sys::get_version().major,
+	minor := sys::get_version().minor,
+	extensions := (
+		SELECT schema::Extension
+		FILTER .name IN array_unpack(<array<str>>$0)
+	).name,
+	object_types := (
+		SELECT schema::ObjectType
+		FILTER .name IN array_unpack(<array<str>>$1)
+	).name,
+}`
+
+// AssertSchema returns an error if the server does not meet requirements.
+// Call it when a program starts to fail fast instead of failing
+// with confusing errors when a query first uses a missing extension or type.
+// The error lists every requirement that is not met.
+func (p *Client) AssertSchema(
+	ctx context.Context,
+	requirements SchemaRequirements,
+) error {
+	var minVersion serverVersion
+	if requirements.MinServerVersion != "" {
+		var err error
+		minVersion, err = parseServerVersion(requirements.MinServerVersion)
+		if err != nil {
+			return &invalidArgumentError{err: err}
+		}
+	}
+
+	for _, name := range requirements.ObjectTypes {
+		if !strings.Contains(name, "::") {
+			return &invalidArgumentError{msg: fmt.Sprintf(
+				"object type name %q is not fully qualified, "+
+					"expected module::Name", name)}
+		}
+	}
+
+	// The result is decoded from JSON so that it does not depend on the
+	// client's StructTag and FieldMatch options.
+	var data []byte
+	err := p.QuerySingleJSON(
+		ctx,
+		assertSchemaQuery,
+		&data,
+		append([]string{}, requirements.Extensions...),
+		append([]string{}, requirements.ObjectTypes...),
+	)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Major       int64    `json:"major"`
+		Minor       int64    `json:"minor"`
+		Extensions  []string `json:"extensions"`
+		ObjectTypes []string `json:"object_types"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return &clientError{err: err}
+	}
+
+	var problems []string
+	version := serverVersion{major: result.Major, minor: result.Minor}
+	if version.less(minVersion) {
+		problems = append(problems, fmt.Sprintf(
+			"server version %v is older than %v", version, minVersion))
+	}
+
+	if missing := missingNames(
+		requirements.Extensions, result.Extensions); len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf(
+			"missing extensions: %v", englishList(missing, "and")))
+	}
+
+	if missing := missingNames(
+		requirements.ObjectTypes, result.ObjectTypes); len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf(
+			"missing object types: %v", englishList(missing, "and")))
+	}
+
+	if len(problems) > 0 {
+		return &clientError{msg: "the schema does not meet the " +
+			"requirements: " + strings.Join(problems, "; ")}
+	}
+
+	return nil
+}
+
+// missingNames returns the names in required that are not in found.
+func missingNames(required, found []string) []string {
+	seen := make(map[string]bool, len(found))
+	for _, name := range found {
+		seen[name] = true
+	}
+
+	var missing []string
+	for _, name := range required {
+		if !seen[name] {
+			missing = append(missing, name)
+			seen[name] = true
+		}
+	}
+
+	return missing
+}
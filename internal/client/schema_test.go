@@ -0,0 +1,94 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	v, err := parseServerVersion("4.1")
+	require.NoError(t, err)
+	assert.Equal(t, serverVersion{major: 4, minor: 1}, v)
+
+	v, err = parseServerVersion("5")
+	require.NoError(t, err)
+	assert.Equal(t, serverVersion{major: 5}, v)
+
+	_, err = parseServerVersion("4.x")
+	assert.EqualError(t, err,
+		`invalid MinServerVersion "4.x", expected major.minor`)
+
+	assert.True(t, serverVersion{3, 9}.less(serverVersion{4, 0}))
+	assert.False(t, serverVersion{4, 0}.less(serverVersion{4, 0}))
+}
+
+func TestMissingNames(t *testing.T) {
+	assert.Equal(t,
+		[]string{"b", "c"},
+		missingNames([]string{"a", "b", "c", "b"}, []string{"a"}))
+	assert.Nil(t, missingNames([]string{"a"}, []string{"a", "b"}))
+}
+
+func TestAssertSchema(t *testing.T) {
+	ctx := context.Background()
+
+	err := client.AssertSchema(ctx, SchemaRequirements{
+		MinServerVersion: "1.0",
+		ObjectTypes:      []string{"default::User", "std::BaseObject"},
+	})
+	require.NoError(t, err)
+
+	err = client.AssertSchema(ctx, SchemaRequirements{
+		MinServerVersion: "1000.0",
+		Extensions:       []string{"not_an_extension"},
+		ObjectTypes:      []string{"default::NotAType", "default::User"},
+	})
+	assert.Regexp(t,
+		`^edgedb.ClientError: the schema does not meet the requirements: `+
+			`server version \d+\.\d+ is older than 1000\.0; `+
+			`missing extensions: not_an_extension; `+
+			`missing object types: default::NotAType$`,
+		err)
+
+	err = client.AssertSchema(ctx, SchemaRequirements{
+		ObjectTypes: []string{"User"},
+	})
+	assert.EqualError(t, err, `edgedb.InvalidArgumentError: `+
+		`object type name "User" is not fully qualified, `+
+		`expected module::Name`)
+}
+
+func TestAssertSchemaCustomDecoderOptions(t *testing.T) {
+	ctx := context.Background()
+	o := opts
+	o.StructTag = "db"
+	o.FieldMatch = FieldMatchTagOnly
+	p, err := CreateClient(ctx, o)
+	require.NoError(t, err)
+	defer p.Close() // nolint:errcheck
+
+	err = p.AssertSchema(ctx, SchemaRequirements{
+		MinServerVersion: "1.0",
+		ObjectTypes:      []string{"default::User"},
+	})
+	assert.NoError(t, err)
+}
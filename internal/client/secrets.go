@@ -0,0 +1,145 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"fmt"
+)
+
+// SecretProvider looks up connection secrets so that they don't need to be
+// stored in environment variables or files. A provider is only asked for a
+// secret if it was not resolved from the client options, DSN, environment
+// or credentials.
+type SecretProvider interface {
+	// Password returns the password for target.User.
+	// ok is false if the provider does not have a password for the target.
+	Password(target SecretTarget) (password string, ok bool, err error)
+
+	// SecretKey returns the secret key used to connect to a cloud instance.
+	// ok is false if the provider does not have a secret key for the target.
+	SecretKey(target SecretTarget) (secretKey string, ok bool, err error)
+}
+
+// SecretTarget describes the connection a secret is requested for.
+// Fields that have not been resolved when the secret is requested are empty.
+type SecretTarget struct {
+	// Instance is the name of the instance being connected to, if the
+	// connection was configured with an instance name. Cloud instance names
+	// include the org, e.g. "my-org/my-instance".
+	Instance string
+	Host     string
+	Port     int
+	User     string
+	Database string
+}
+
+// account returns a name identifying the target's instance.
+func (t SecretTarget) account() string {
+	if t.Instance != "" {
+		return t.Instance
+	}
+
+	return fmt.Sprintf("%v:%v", t.Host, t.Port)
+}
+
+// NewKeyringSecretProvider returns a SecretProvider that reads secrets from
+// the operating system's credential store: the login keychain on macOS, the
+// Secret Service (using secret-tool) on Linux and the Credential Manager on
+// Windows.
+//
+// Secrets are looked up under service with the account name
+// "<user>@<instance>" for passwords and "secret-key@<instance>" for secret
+// keys, where <instance> is the instance name or "<host>:<port>" if the
+// client is not configured with an instance name. On Windows the generic
+// credential's target name is "<service>:<account>" and its blob is read as
+// UTF-8 text.
+func NewKeyringSecretProvider(service string) SecretProvider {
+	return keyringSecretProvider{service: service}
+}
+
+type keyringSecretProvider struct {
+	service string
+}
+
+func (p keyringSecretProvider) Password(
+	target SecretTarget,
+) (string, bool, error) {
+	return keyringLookup(p.service, target.User+"@"+target.account())
+}
+
+func (p keyringSecretProvider) SecretKey(
+	target SecretTarget,
+) (string, bool, error) {
+	return keyringLookup(p.service, "secret-key@"+target.account())
+}
+
+// secretTarget returns the resolved connection settings.
+func (r *configResolver) secretTarget() SecretTarget {
+	var target SecretTarget
+
+	if inst, ok := r.instance.val.(string); ok {
+		target.Instance = inst
+		if org, ok := r.org.val.(string); ok {
+			target.Instance = org + "/" + inst
+		}
+	}
+
+	target.Host, _ = r.host.val.(string)
+	target.Port, _ = r.port.val.(int)
+	target.User, _ = r.user.val.(string)
+	target.Database, _ = r.database.val.(string)
+
+	return target
+}
+
+// resolveSecretKey asks the secret provider for a secret key
+// if one is not already set.
+func (r *configResolver) resolveSecretKey() error {
+	if r.secrets == nil || r.secretKey.val != nil {
+		return nil
+	}
+
+	key, ok, err := r.secrets.SecretKey(r.secretTarget())
+	if err != nil {
+		return fmt.Errorf("could not get secret key: %w", err)
+	}
+
+	if ok {
+		return r.setSecretKey(key, "SecretProvider option")
+	}
+
+	return nil
+}
+
+// resolvePassword asks the secret provider for a password
+// if one is not already set.
+func (r *configResolver) resolvePassword(target SecretTarget) error {
+	if r.secrets == nil || r.password.val != nil {
+		return nil
+	}
+
+	password, ok, err := r.secrets.Password(target)
+	if err != nil {
+		return fmt.Errorf("could not get password: %w", err)
+	}
+
+	if ok {
+		r.setPassword(password, "SecretProvider option")
+	}
+
+	return nil
+}
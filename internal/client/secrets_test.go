@@ -0,0 +1,104 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSecretProvider struct {
+	secrets map[string]string
+	targets []SecretTarget
+	err     error
+}
+
+func (p *testSecretProvider) lookup(
+	name string,
+	target SecretTarget,
+) (string, bool, error) {
+	p.targets = append(p.targets, target)
+	val, ok := p.secrets[name]
+	return val, ok, p.err
+}
+
+func (p *testSecretProvider) Password(
+	target SecretTarget,
+) (string, bool, error) {
+	return p.lookup("password", target)
+}
+
+func (p *testSecretProvider) SecretKey(
+	target SecretTarget,
+) (string, bool, error) {
+	return p.lookup("secret_key", target)
+}
+
+func TestSecretProviderPassword(t *testing.T) {
+	provider := &testSecretProvider{
+		secrets: map[string]string{"password": "secret"},
+	}
+	opts := Options{
+		Host:           "example.com",
+		User:           "admin",
+		SecretProvider: provider,
+	}
+
+	cfg, err := parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.password)
+	assert.Equal(t, []SecretTarget{{
+		Host:     "example.com",
+		Port:     5656,
+		User:     "admin",
+		Database: "edgedb",
+	}}, provider.targets)
+
+	provider.targets = nil
+	opts.Password = types.NewOptionalStr("explicit")
+	cfg, err = parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	require.NoError(t, err)
+	assert.Equal(t, "explicit", cfg.password)
+	assert.Nil(t, provider.targets, "the provider should not be used")
+
+	opts.Password = types.OptionalStr{}
+	provider.err = errors.New("locked")
+	_, err = parseConnectDSNAndArgs("", &opts, newCfgPaths())
+	assert.EqualError(t, err,
+		"edgedb.ConfigurationError: could not get password: locked")
+}
+
+func TestSecretProviderSecretKey(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString(
+		[]byte(`{"iss":"example.com"}`))
+	key := "header." + claims + ".signature"
+
+	provider := &testSecretProvider{
+		secrets: map[string]string{"secret_key": key},
+	}
+	opts := Options{SecretProvider: provider}
+
+	cfg, err := parseConnectDSNAndArgs("org/inst", &opts, newCfgPaths())
+	require.NoError(t, err)
+	assert.Equal(t, key, cfg.secretKey)
+	assert.Equal(t, "org/inst", provider.targets[0].Instance)
+}
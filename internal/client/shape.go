@@ -0,0 +1,200 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"database/sql"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+)
+
+var (
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf(
+		(*encoding.TextUnmarshaler)(nil)).Elem()
+
+	// scalarPackages contain struct types that are not objects.
+	scalarPackages = map[string]bool{
+		reflect.TypeOf(types.UUID{}).PkgPath(): true,
+		"time":                                 true,
+		"math/big":                             true,
+	}
+)
+
+// Shape returns an EdgeQL shape that selects the fields of v's type, which
+// must be a struct or a pointer or slice of structs. Fields are selected the
+// same way they are matched when results are decoded using the client's
+// StructTag and FieldMatch options. Untagged fields are selected by their Go
+// name, which is lower-cased if FieldMatch is case insensitive. Struct fields,
+// pointers to structs and slices of structs are links and are selected with
+// nested shapes, so link trees of any depth can be loaded with a single query.
+//
+// Fields with an edgeql tag are computed from its expression. This can be
+// used to load backlinks.
+//
+//	type Post struct {
+//	    Title string `edgedb:"title"`
+//	}
+//
+//	type User struct {
+//	    Name  string `edgedb:"name"`
+//	    Posts []Post `edgedb:"posts" edgeql:".<author[is Post]"`
+//	}
+//
+//	shape, err := client.Shape(User{})
+//	// shape is { name, posts := .<author[is Post] { title } }
+//
+//	var users []User
+//	err = client.Query(ctx, "SELECT User "+shape, &users)
+//
+// Recursive types and types without fields to select can not be converted
+// into shapes.
+func (p *Client) Shape(v interface{}) (string, error) {
+	typ := reflect.TypeOf(v)
+	if typ != nil {
+		typ = linkTarget(typ)
+	}
+
+	if typ == nil || !isObjectType(typ) {
+		return "", fmt.Errorf(
+			"edgedb.Shape: expected a struct type got %v", typ)
+	}
+
+	w := shapeWriter{
+		tag:   p.decoderOptions.Tag(),
+		match: p.decoderOptions.FieldMatch,
+		seen:  map[reflect.Type]bool{},
+	}
+
+	if err := w.writeShape(typ); err != nil {
+		return "", err
+	}
+
+	return w.b.String(), nil
+}
+
+// linkTarget returns the element type of pointers and slices.
+func linkTarget(typ reflect.Type) reflect.Type {
+	for {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice:
+			typ = typ.Elem()
+		default:
+			return typ
+		}
+	}
+}
+
+// isObjectType returns true if typ is a struct that is decoded from objects
+// rather than from a scalar.
+func isObjectType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || scalarPackages[typ.PkgPath()] {
+		return false
+	}
+
+	// Decimals can be decoded into encoding.TextUnmarshaler structs.
+	ptr := reflect.PtrTo(typ)
+	if ptr.Implements(scannerType) || ptr.Implements(textUnmarshalerType) {
+		return false
+	}
+
+	for i := 0; i < ptr.NumMethod(); i++ {
+		if strings.HasPrefix(ptr.Method(i).Name, "UnmarshalEdgeDB") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shapeWriter builds the shape for a struct type.
+type shapeWriter struct {
+	b     strings.Builder
+	tag   string
+	match introspect.FieldMatch
+	seen  map[reflect.Type]bool
+}
+
+func (w *shapeWriter) writeShape(typ reflect.Type) error {
+	if w.seen[typ] {
+		return fmt.Errorf(
+			"edgedb.Shape: cannot build a shape for recursive type %v", typ)
+	}
+
+	w.seen[typ] = true
+	defer delete(w.seen, typ)
+
+	fields := shapeFields(typ, w.tag, w.match, 0, nil)
+	if len(fields) == 0 {
+		return fmt.Errorf(
+			"edgedb.Shape: %v does not have any fields to select", typ)
+	}
+
+	w.b.WriteString("{ ")
+	for i, field := range fields {
+		if i > 0 {
+			w.b.WriteString(", ")
+		}
+
+		w.b.WriteString(w.fieldName(field))
+
+		expr := field.Tag.Get("edgeql")
+		if expr != "" {
+			w.b.WriteString(" := ")
+			w.b.WriteString(expr)
+		}
+
+		target := linkTarget(field.Type)
+		if !isObjectType(target) {
+			// Properties don't have a nested shape.
+			continue
+		}
+
+		if expr != "" {
+			w.b.WriteString(" ")
+		} else {
+			w.b.WriteString(": ")
+		}
+
+		if err := w.writeShape(target); err != nil {
+			return err
+		}
+	}
+	w.b.WriteString(" }")
+
+	return nil
+}
+
+// fieldName returns the name that field is decoded from.
+func (w *shapeWriter) fieldName(field reflect.StructField) string {
+	if name := field.Tag.Get(w.tag); name != "" {
+		return name
+	}
+
+	// EdgeQL names are conventionally lower case, a case insensitive
+	// match still decodes them into the field.
+	if w.match == introspect.MatchCaseInsensitive {
+		return strings.ToLower(field.Name)
+	}
+
+	return field.Name
+}
@@ -0,0 +1,133 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shapePost struct {
+	Title   string            `edgedb:"title"`
+	Created time.Time         `edgedb:"created"`
+	Body    types.OptionalStr `edgedb:"body"`
+}
+
+type shapeNamed struct {
+	ID   types.UUID `edgedb:"id"`
+	Name string     `edgedb:"name"`
+}
+
+type shapeUser struct {
+	shapeNamed `edgedb:"$inline"`
+	types.Optional
+	Avatar  []byte        `edgedb:"avatar"`
+	Friends []*shapeNamed `edgedb:"friends"`
+	Best    *shapeNamed   `edgedb:"best_friend"`
+	Posts   []shapePost   `edgedb:"posts" edgeql:".<author[is Post]"`
+	Count   int64         `edgedb:"count" edgeql:"count(.<author)"`
+	hidden  string
+}
+
+type shapeDecimal struct{ text string }
+
+func (d *shapeDecimal) UnmarshalText(text []byte) error {
+	d.text = string(text)
+	return nil
+}
+
+type shapeProduct struct {
+	Name    string       `json:"name"`
+	Price   shapeDecimal `json:"price"`
+	Stock   int64
+	Ignored string `json:"-"`
+}
+
+type shapeRecursive struct {
+	Name    string           `edgedb:"name"`
+	Friends []shapeRecursive `edgedb:"friends"`
+}
+
+func TestShape(t *testing.T) {
+	client := &Client{}
+	shape, err := client.Shape(&[]shapeUser{})
+	require.NoError(t, err)
+	assert.Equal(t, "{ id, name, avatar, "+
+		"friends: { id, name }, "+
+		"best_friend: { id, name }, "+
+		"posts := .<author[is Post] { title, created, body }, "+
+		"count := count(.<author) }", shape)
+
+	_, err = client.Shape(shapeRecursive{})
+	assert.EqualError(t, err, "edgedb.Shape: cannot build a shape "+
+		"for recursive type edgedb.shapeRecursive")
+
+	_, err = client.Shape("")
+	assert.EqualError(t, err,
+		"edgedb.Shape: expected a struct type got string")
+
+	_, err = client.Shape(struct{ hidden string }{})
+	assert.EqualError(t, err, "edgedb.Shape: struct { hidden string } "+
+		"does not have any fields to select")
+
+	_, err = client.Shape(shapeEmptyLink{})
+	assert.EqualError(t, err, "edgedb.Shape: edgedb.shapeEmpty "+
+		"does not have any fields to select")
+}
+
+type shapeEmpty struct{}
+
+type shapeEmptyLink struct {
+	Name  string      `edgedb:"name"`
+	Empty *shapeEmpty `edgedb:"empty"`
+}
+
+func TestShapeUntaggedFields(t *testing.T) {
+	type product struct {
+		Name      string
+		UnitPrice float64 `edgedb:"unit_price"`
+	}
+
+	client := &Client{}
+	shape, err := client.Shape(product{})
+	require.NoError(t, err)
+	assert.Equal(t, "{ Name, unit_price }", shape)
+
+	client.decoderOptions.FieldMatch = introspect.MatchCaseInsensitive
+	shape, err = client.Shape(product{})
+	require.NoError(t, err)
+	assert.Equal(t, "{ name, unit_price }", shape)
+}
+
+func TestShapeDecoderOptions(t *testing.T) {
+	client := &Client{cacheCollection: cacheCollection{
+		decoderOptions: codecs.DecoderOptions{
+			StructTag:  "json",
+			FieldMatch: introspect.MatchTagOnly,
+		},
+	}}
+
+	shape, err := client.Shape(shapeProduct{})
+	require.NoError(t, err)
+	assert.Equal(t, "{ name, price }", shape)
+}
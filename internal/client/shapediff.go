@@ -0,0 +1,194 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+)
+
+// ShapeDiff lists the differences between the result shape of a query and
+// the go type that its results are decoded into. Nested fields are named by
+// their path, e.g. author.name.
+type ShapeDiff struct {
+	// Added are result fields that the go type has no field for.
+	// Decoding the query into the go type fails.
+	Added []string
+
+	// Removed are go fields that are not in the result.
+	// They are left unchanged when decoding.
+	Removed []string
+}
+
+// Empty returns true if the result shape matches the go type.
+func (d *ShapeDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func (d *ShapeDiff) String() string {
+	var msgs []string
+	if len(d.Added) > 0 {
+		msgs = append(msgs,
+			"added fields: "+englishList(d.Added, "and"))
+	}
+
+	if len(d.Removed) > 0 {
+		msgs = append(msgs,
+			"removed fields: "+englishList(d.Removed, "and"))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// DiffShape describes cmd without running it and compares the fields of
+// its result with the fields of out's type. out is the argument that would
+// be passed to Query or QuerySingle. Fields are matched the same way they
+// are when results are decoded. Field types are not compared.
+//
+// DiffShape can be run in CI after schema migrations to find go types that
+// no longer match their queries.
+//
+//	var users []User
+//	diff, err := client.DiffShape(ctx, "SELECT User { name }", &users)
+//	if err == nil && !diff.Empty() {
+//	    log.Fatalf("User does not match its query: %v", diff)
+//	}
+func (p *Client) DiffShape(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+) (*ShapeDiff, error) {
+	typ := reflect.TypeOf(out)
+	if typ == nil {
+		return nil, &interfaceError{
+			msg: `the "out" argument must not be nil`}
+	}
+
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{
+		method:       "DiffShape",
+		cmd:          cmd,
+		fmt:          Binary,
+		expCard:      Many,
+		capabilities: conn.capabilities1pX(),
+		state:        copyState(p.state),
+	}
+	q.setOptions(p.queryOpts)
+
+	desc, err := conn.parseFlow(ctx, q)
+	if e := firstError(err, p.release(conn, err)); e != nil {
+		return nil, e
+	}
+
+	diff := &ShapeDiff{}
+	p.diffShape(diff, "", desc.Out, linkTarget(typ))
+	return diff, nil
+}
+
+type fieldKey struct {
+	name   string
+	offset uintptr
+}
+
+// diffShape adds the differences between the fields of result and typ
+// to diff.
+func (p *Client) diffShape(
+	diff *ShapeDiff,
+	path string,
+	result ResultType,
+	typ reflect.Type,
+) {
+	switch {
+	case result.Kind == "array" || result.Kind == "set":
+		p.diffShape(diff, path, result.Fields[0].Type, typ)
+		return
+	case result.Kind == "object" || result.Kind == "namedtuple":
+	default:
+		return
+	}
+
+	if !isObjectType(typ) {
+		return
+	}
+
+	tag := p.decoderOptions.Tag()
+	match := p.decoderOptions.FieldMatch
+	matched := make(map[fieldKey]bool, len(result.Fields))
+	for _, field := range result.Fields {
+		sf, ok := introspect.TaggedStructField(typ, field.Name, tag, match)
+		if !ok {
+			if !field.Implicit {
+				diff.Added = append(diff.Added, path+field.Name)
+			}
+			continue
+		}
+
+		matched[fieldKey{sf.Name, sf.Offset}] = true
+		p.diffShape(diff, path+field.Name+".", field.Type, linkTarget(sf.Type))
+	}
+
+	for _, f := range shapeFields(typ, tag, match, 0, nil) {
+		if !matched[fieldKey{f.Name, f.Offset}] {
+			name := f.Tag.Get(tag)
+			if name == "" {
+				name = f.Name
+			}
+
+			diff.Removed = append(diff.Removed, path+name)
+		}
+	}
+}
+
+// shapeFields returns the fields of typ that shape fields can be decoded
+// into. Fields tagged with "-" and untagged fields when match is
+// MatchTagOnly are never decoded into. The offsets of inlined fields are
+// relative to typ.
+func shapeFields(
+	typ reflect.Type,
+	tag string,
+	match introspect.FieldMatch,
+	offset uintptr,
+	fields []reflect.StructField,
+) []reflect.StructField {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		field.Offset += offset
+
+		name := field.Tag.Get(tag)
+		if name == "$inline" {
+			fields = shapeFields(field.Type, tag, match, field.Offset, fields)
+			continue
+		}
+
+		// Embedded structs are ignored unless they are inlined.
+		if field.Anonymous || field.PkgPath != "" || name == "-" ||
+			(name == "" && match == introspect.MatchTagOnly) {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
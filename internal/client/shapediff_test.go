@@ -0,0 +1,134 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"reflect"
+	"testing"
+
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffShape(t *testing.T) {
+	str := ResultType{Name: "std::str", Kind: "scalar"}
+	result := ResultType{
+		Name: "default::Post",
+		Kind: "object",
+		Fields: []ResultField{
+			{Name: "id", Implicit: true, Type: ResultType{Kind: "scalar"}},
+			{Name: "title", Type: str},
+			{Name: "body", Type: str},
+			{
+				Name: "author",
+				Type: ResultType{
+					Name: "default::User",
+					Kind: "object",
+					Fields: []ResultField{
+						{Name: "name", Type: str},
+						{Name: "email", Type: str},
+					},
+				},
+			},
+		},
+	}
+
+	type Meta struct {
+		Title string `edgedb:"title"`
+		Draft bool   `edgedb:"draft"`
+	}
+
+	type User struct {
+		Name string `edgedb:"name"`
+		Age  int64
+	}
+
+	type Post struct {
+		Meta   `edgedb:"$inline"`
+		Author *User `edgedb:"author"`
+		Tags   []types.OptionalStr
+		Cache  string `edgedb:"-"`
+		views  int64  // nolint:structcheck,unused
+	}
+
+	p := &Client{}
+	diff := &ShapeDiff{}
+	p.diffShape(diff, "", result, reflect.TypeOf(Post{}))
+	assert.Equal(t, &ShapeDiff{
+		Added:   []string{"body", "author.email"},
+		Removed: []string{"author.Age", "draft", "Tags"},
+	}, diff)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, "added fields: body and author.email; "+
+		"removed fields: author.Age, draft and Tags", diff.String())
+
+	type Match struct {
+		ID     types.UUID `edgedb:"id"`
+		Title  string     `edgedb:"title"`
+		Body   string     `edgedb:"body"`
+		Author []struct {
+			Name  string `edgedb:"name"`
+			Email string `edgedb:"email"`
+		} `edgedb:"author"`
+	}
+
+	// untagged fields are never decoded into with MatchTagOnly
+	p.decoderOptions.FieldMatch = introspect.MatchTagOnly
+	diff = &ShapeDiff{}
+	p.diffShape(diff, "", result, reflect.TypeOf(Post{}))
+	assert.Equal(t, &ShapeDiff{
+		Added:   []string{"body", "author.email"},
+		Removed: []string{"draft"},
+	}, diff)
+	p.decoderOptions.FieldMatch = introspect.MatchExact
+
+	diff = &ShapeDiff{}
+	set := ResultType{Kind: "set", Fields: []ResultField{{Type: result}}}
+	p.diffShape(diff, "", set, reflect.TypeOf(Match{}))
+	assert.True(t, diff.Empty())
+	assert.Equal(t, "", diff.String())
+}
+
+func TestDiffShapeTuples(t *testing.T) {
+	str := ResultType{Name: "std::str", Kind: "scalar"}
+	named := ResultType{
+		Kind: "namedtuple",
+		Fields: []ResultField{
+			{Name: "a", Type: str},
+			{Name: "b", Type: str},
+		},
+	}
+
+	type Named struct {
+		A string `edgedb:"a"`
+		C string `edgedb:"c"`
+	}
+
+	p := &Client{}
+	diff := &ShapeDiff{}
+	p.diffShape(diff, "", named, reflect.TypeOf(Named{}))
+	assert.Equal(t, &ShapeDiff{
+		Added:   []string{"b"},
+		Removed: []string{"c"},
+	}, diff)
+
+	diff = &ShapeDiff{}
+	empty := ResultType{Kind: "tuple"}
+	p.diffShape(diff, "", empty, reflect.TypeOf(Named{}))
+	assert.True(t, diff.Empty())
+}
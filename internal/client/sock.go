@@ -19,9 +19,13 @@ package edgedb
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"net"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
 )
 
 func connectAutoClosingSocket(
@@ -74,6 +78,9 @@ type autoClosingSocket struct {
 	conn     net.Conn
 	isClosed bool
 	mu       sync.Mutex
+
+	// stats records the data that is read and written.
+	stats *connStats
 }
 
 func (s *autoClosingSocket) Closed() bool {
@@ -95,9 +102,10 @@ func (s *autoClosingSocket) Close() error {
 
 func (s *autoClosingSocket) Read(p []byte) (int, error) {
 	n, err := s.conn.Read(p)
+	s.stats.read(p[:n])
 	if err != nil {
 		_ = s.Close()
-		err = wrapNetError(err)
+		err = wrapNetError(wrapDeadlineError(err))
 	}
 
 	return n, err
@@ -105,9 +113,10 @@ func (s *autoClosingSocket) Read(p []byte) (int, error) {
 
 func (s *autoClosingSocket) Write(p []byte) (int, error) {
 	n, err := s.conn.Write(p)
+	s.stats.wrote(p[:n])
 	if err != nil {
 		_ = s.Close()
-		err = wrapNetError(err)
+		err = wrapNetError(wrapDeadlineError(err))
 	}
 
 	return n, err
@@ -125,6 +134,75 @@ func (s *autoClosingSocket) WriteAll(p []byte) error {
 	return nil
 }
 
+// WriteBuffer writes w to the socket, including data that w streams from
+// readers. The socket is closed if a reader fails because the server can not
+// read the rest of a partially written message.
+func (s *autoClosingSocket) WriteBuffer(w *buff.Writer) error {
+	if !w.Streaming() {
+		return s.WriteAll(w.Unwrap())
+	}
+
+	if _, err := w.WriteTo(s); err != nil {
+		_ = s.Close()
+		return err
+	}
+
+	return nil
+}
+
+// closeOnCancel closes the socket if ctx is canceled before the returned
+// function is called. This interrupts reads and writes that are blocked on a
+// server that does not respond. The returned function reports whether the
+// socket was closed.
+func (s *autoClosingSocket) closeOnCancel(ctx context.Context) func() bool {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+
+	done := make(chan struct{})
+	canceled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = s.Close()
+			canceled <- true
+		case <-done:
+			canceled <- false
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		return <-canceled
+	}
+}
+
+// deadlineError is returned when a read or write times out. Socket deadlines
+// are only set from context deadlines, so the timeout is reported as
+// context.DeadlineExceeded.
+type deadlineError struct {
+	err error
+}
+
+func (e *deadlineError) Error() string { return e.err.Error() }
+
+func (e *deadlineError) Unwrap() error { return e.err }
+
+func (e *deadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+func wrapDeadlineError(err error) error {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return &deadlineError{err: err}
+	}
+
+	return err
+}
+
+// SetDeadline sets the read and write deadline of the socket. It is set from
+// the context of every operation that reads or writes, see scriptFlow, so that
+// a server that stops responding fails the operation when its context expires.
 func (s *autoClosingSocket) SetDeadline(t time.Time) error {
 	err := s.conn.SetDeadline(t)
 	if err != nil {
@@ -0,0 +1,67 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseOnCancel(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close() // nolint:errcheck
+	s := &autoClosingSocket{conn: client}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := s.closeOnCancel(ctx)
+	assert.False(t, canceled())
+	assert.False(t, s.Closed())
+
+	canceled = s.closeOnCancel(ctx)
+	read := make(chan error)
+	go func() {
+		_, err := s.Read(make([]byte, 1))
+		read <- err
+	}()
+
+	cancel()
+	assert.Error(t, <-read, "the blocked read is interrupted")
+	assert.True(t, canceled())
+	assert.True(t, s.Closed())
+}
+
+func TestSocketDeadlineIsDeadlineExceeded(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close() // nolint:errcheck
+	s := &autoClosingSocket{conn: client}
+
+	err := s.SetDeadline(time.Now().Add(10 * time.Millisecond))
+	assert.NoError(t, err)
+
+	_, err = s.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+
+	var edbErr Error
+	assert.True(t, errors.As(err, &edbErr), err)
+	assert.True(t, edbErr.Category(ClientConnectionTimeoutError), err)
+	assert.True(t, s.Closed())
+}
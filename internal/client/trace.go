@@ -0,0 +1,81 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+)
+
+const defaultTraceAnnotation = "tag"
+
+// TraceOptions configures the trace context that is sent with queries so
+// that the server's query logs can be joined with distributed traces.
+// Annotations require EdgeDB 5.0 or later, older servers do not receive the
+// trace context.
+//
+// The client does not depend on a tracing library. With OpenTelemetry the
+// trace parent can be read from the span context:
+//
+//	edgedb.TraceOptions{
+//	    TraceParent: func(ctx context.Context) string {
+//	        carrier := propagation.MapCarrier{}
+//	        propagation.TraceContext{}.Inject(ctx, carrier)
+//	        return carrier.Get("traceparent")
+//	    },
+//	}
+type TraceOptions struct {
+	// TraceParent returns the W3C traceparent of the span that is active in
+	// a query's context, or an empty string if there is none. If TraceParent
+	// is nil no trace context is sent.
+	TraceParent func(ctx context.Context) string
+
+	// Annotation is the name of the query annotation that the trace parent
+	// is sent as. If Annotation is empty "tag" is used, the server records
+	// the tag of each query in sys::QueryStats.
+	Annotation string
+}
+
+// annotate sets the query's annotations from the trace context in ctx.
+func (q *query) annotate(ctx context.Context) {
+	q.annotations = nil
+	if q.trace.TraceParent == nil {
+		return
+	}
+
+	traceParent := q.trace.TraceParent(ctx)
+	if traceParent == "" {
+		return
+	}
+
+	name := q.trace.Annotation
+	if name == "" {
+		name = defaultTraceAnnotation
+	}
+
+	q.annotations = map[string]string{name: traceParent}
+}
+
+func writeAnnotations(w *buff.Writer, annotations map[string]string) {
+	w.PushUint16(uint16(len(annotations)))
+
+	for name, val := range annotations {
+		w.PushString(name)
+		w.PushString(val)
+	}
+}
@@ -0,0 +1,63 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+)
+
+type traceKey struct{}
+
+func TestAnnotateTraceParent(t *testing.T) {
+	traceParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	ctx := context.WithValue(context.Background(), traceKey{}, traceParent)
+	fromCtx := func(ctx context.Context) string {
+		val, _ := ctx.Value(traceKey{}).(string)
+		return val
+	}
+
+	q := &query{}
+	q.annotate(ctx)
+	assert.Nil(t, q.annotations, "tracing is disabled by default")
+
+	q.trace = TraceOptions{TraceParent: fromCtx}
+	q.annotate(ctx)
+	assert.Equal(t, map[string]string{"tag": traceParent}, q.annotations)
+
+	q.annotate(context.Background())
+	assert.Nil(t, q.annotations, "no annotation without an active span")
+
+	q.trace.Annotation = "traceparent"
+	q.annotate(ctx)
+	assert.Equal(t,
+		map[string]string{"traceparent": traceParent}, q.annotations)
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	w := buff.NewWriter(nil)
+	writeAnnotations(w, map[string]string{"tag": "abc"})
+	assert.Equal(t, []byte{
+		0, 1,
+		0, 0, 0, 3, 't', 'a', 'g',
+		0, 0, 0, 3, 'a', 'b', 'c',
+	}, w.Unwrap())
+}
@@ -19,13 +19,26 @@ package edgedb
 import (
 	"context"
 	"errors"
-	"time"
 )
 
 type transactableConn struct {
 	*reconnectingConn
 	txOpts    TxOptions
 	retryOpts RetryOptions
+
+	// host is the host that the connection was connected to
+	// when it was acquired from the pool.
+	host *hostState
+
+	// streamed is true if a query that was run since the connection was
+	// acquired streamed its arguments or results. Such queries can't be run
+	// again on another connection, see Client.run.
+	streamed bool
+}
+
+func (c *transactableConn) scriptFlow(ctx context.Context, q *query) error {
+	defer c.recordStreamed(q)
+	return c.reconnectingConn.scriptFlow(ctx, q)
 }
 
 func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
@@ -34,6 +47,8 @@ func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
 		edbErr Error
 	)
 
+	defer c.recordStreamed(q)
+
 	for i := 1; true; i++ {
 		if errors.As(err, &edbErr) && c.conn.soc.Closed() {
 			err = c.reconnect(ctx, true)
@@ -42,6 +57,8 @@ func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
 			}
 		}
 
+		// drop the state of a failed attempt
+		q.resetAttempt()
 		err = c.reconnectingConn.granularFlow(ctx, q)
 
 	Error:
@@ -49,8 +66,11 @@ func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
 		// i.e. capabilities == 0. Read only queries are always
 		// retryable, mutation queries are retryable if the
 		// error explicitly indicates a transaction conflict.
+		// Queries with streamed arguments can't be retried because their
+		// readers have been consumed.
 		capabilities, ok := c.getCachedCapabilities(q)
 		if ok &&
+			!q.streamed &&
 			errors.As(err, &edbErr) &&
 			edbErr.HasTag(ShouldRetry) &&
 			(capabilities == 0 || edbErr.Category(TransactionConflictError)) {
@@ -63,7 +83,9 @@ func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
 				return err
 			}
 
-			time.Sleep(rule.backoff(i))
+			backoff := rule.delay(i, c.cfg.randSource())
+			q.reportRetry(q.cmd, i, edbErr, backoff)
+			c.cfg.timeSource().Sleep(backoff)
 			continue
 		}
 
@@ -73,10 +95,48 @@ func (c *transactableConn) granularFlow(ctx context.Context, q *query) error {
 	return &clientError{msg: "unreachable"}
 }
 
+// recordStreamed marks the connection as streamed if q was streamed.
+func (c *transactableConn) recordStreamed(q *query) {
+	if q.streamed {
+		c.streamed = true
+	}
+}
+
+// rawTx starts a transaction. The connection stays borrowed
+// until the caller unborrows it.
+func (c *transactableConn) rawTx(
+	ctx context.Context,
+	state map[string]interface{},
+	opts queryOptions,
+) (*Tx, error) {
+	if e := c.ensureConnection(ctx); e != nil {
+		return nil, e
+	}
+
+	conn, err := c.borrow("transaction")
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &Tx{
+		borrowableConn: borrowableConn{conn: conn},
+		txState:        &txState{},
+		options:        c.txOpts,
+		state:          state,
+		queryOpts:      opts,
+	}
+	if err := tx.start(ctx); err != nil {
+		return nil, firstError(err, c.unborrow())
+	}
+
+	return tx, nil
+}
+
 func (c *transactableConn) tx(
 	ctx context.Context,
 	action TxBlock,
 	state map[string]interface{},
+	opts queryOptions,
 ) (err error) {
 	conn, err := c.borrow("transaction")
 	if err != nil {
@@ -101,6 +161,7 @@ func (c *transactableConn) tx(
 				txState:        &txState{},
 				options:        c.txOpts,
 				state:          state,
+				queryOpts:      opts,
 			}
 			err = tx.start(ctx)
 			if err != nil {
@@ -136,7 +197,9 @@ func (c *transactableConn) tx(
 				return err
 			}
 
-			time.Sleep(rule.backoff(i))
+			backoff := rule.delay(i, c.cfg.randSource())
+			opts.reportRetry("", i, edbErr, backoff)
+			c.cfg.timeSource().Sleep(backoff)
 			continue
 		}
 
@@ -18,6 +18,7 @@ package edgedb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 )
 
@@ -72,12 +73,22 @@ func (s *txState) assertStarted(opName string) error {
 	}
 }
 
-// Tx is a transaction. Use Client.Tx() to get a transaction.
+// Tx is a transaction. Use Client.Tx() or Client.RawTx() to get a
+// transaction.
+//
+// A Tx runs its queries on a single connection and is not safe for
+// concurrent use. Its methods return an InterfaceError instead of running a
+// query while another goroutine is running one on the same Tx.
 type Tx struct {
 	borrowableConn
 	*txState
-	options TxOptions
-	state   map[string]interface{}
+	options   TxOptions
+	state     map[string]interface{}
+	queryOpts queryOptions
+
+	// release returns the connection of a transaction started with
+	// Client.RawTx to the pool. It is nil for other transactions.
+	release func(error) error
 }
 
 func (t *Tx) execute(
@@ -133,6 +144,41 @@ func (t *Tx) rollback(ctx context.Context) error {
 	return t.execute(ctx, "ROLLBACK;", rolledBackTx)
 }
 
+// Commit commits a transaction started with Client.RawTx
+// and returns its connection to the pool.
+func (t *Tx) Commit(ctx context.Context) error {
+	if e := t.assertRaw("commit"); e != nil {
+		return e
+	}
+
+	err := t.commit(ctx)
+	return firstError(err, t.release(err))
+}
+
+// Rollback rolls back a transaction started with Client.RawTx
+// and returns its connection to the pool.
+func (t *Tx) Rollback(ctx context.Context) error {
+	if e := t.assertRaw("rollback"); e != nil {
+		return e
+	}
+
+	err := t.rollback(ctx)
+	return firstError(err, t.release(err))
+}
+
+// assertRaw returns an error if the transaction was not started with
+// Client.RawTx or if it has already ended.
+func (t *Tx) assertRaw(opName string) error {
+	if t.release == nil {
+		return &interfaceError{msg: fmt.Sprintf(
+			"cannot %v; only transactions started with RawTx "+
+				"can be ended manually", opName,
+		)}
+	}
+
+	return t.assertStarted(opName)
+}
+
 func (t *Tx) scriptFlow(ctx context.Context, q *query) error {
 	if e := t.assertStarted("Execute"); e != nil {
 		return e
@@ -159,8 +205,9 @@ func (t *Tx) Execute(
 	if err != nil {
 		return err
 	}
+	q.setOptions(t.queryOpts)
 
-	return t.scriptFlow(ctx, q)
+	return q.handleWarnings(t.scriptFlow(ctx, q))
 }
 
 // Query runs a query and returns the results.
@@ -170,20 +217,49 @@ func (t *Tx) Query(
 	out interface{},
 	args ...interface{},
 ) error {
-	return runQuery(ctx, t, "Query", cmd, out, args, t.state)
+	return runQuery(
+		ctx, t, "Query", cmd, out, args, t.state, t.queryOpts)
+}
+
+// QueryMeta runs a query, returns the results in out
+// and returns metadata describing the shape of the results.
+func (t *Tx) QueryMeta(
+	ctx context.Context,
+	cmd string,
+	out interface{},
+	args ...interface{},
+) (*ResultMeta, error) {
+	return runQueryMeta(
+		ctx, t, "Query", cmd, out, args, t.state, t.queryOpts)
+}
+
+// QueryDynamic runs a query that returns objects or named tuples
+// and returns the results as maps without requiring a destination type.
+// See Client.QueryDynamic for how values are decoded.
+func (t *Tx) QueryDynamic(
+	ctx context.Context,
+	cmd string,
+	args ...interface{},
+) ([]map[string]interface{}, error) {
+	var out []map[string]interface{}
+	err := t.Query(ctx, cmd, &out, args...)
+	return out, err
 }
 
 // QuerySingle runs a singleton-returning query and returns its element.
 // If the query executes successfully but doesn't return a result
 // a NoDataError is returned. If the out argument is an optional type the out
 // argument will be set to missing instead of returning a NoDataError.
+// If out is an io.Writer the query must return std::bytes and the result is
+// written to out in chunks instead of being held in memory.
 func (t *Tx) QuerySingle(
 	ctx context.Context,
 	cmd string,
 	out interface{},
 	args ...interface{},
 ) error {
-	return runQuery(ctx, t, "QuerySingle", cmd, out, args, t.state)
+	return runQuery(
+		ctx, t, "QuerySingle", cmd, out, args, t.state, t.queryOpts)
 }
 
 // QueryJSON runs a query and return the results as JSON.
@@ -193,7 +269,8 @@ func (t *Tx) QueryJSON(
 	out *[]byte,
 	args ...interface{},
 ) error {
-	return runQuery(ctx, t, "QueryJSON", cmd, out, args, t.state)
+	return runQuery(
+		ctx, t, "QueryJSON", cmd, out, args, t.state, t.queryOpts)
 }
 
 // QuerySingleJSON runs a singleton-returning query.
@@ -205,5 +282,31 @@ func (t *Tx) QuerySingleJSON(
 	out interface{},
 	args ...interface{},
 ) error {
-	return runQuery(ctx, t, "QuerySingleJSON", cmd, out, args, t.state)
+	return runQuery(
+		ctx, t, "QuerySingleJSON", cmd, out, args, t.state, t.queryOpts)
+}
+
+// QueryJSONElements runs a query and returns each result element as a
+// separate JSON document.
+func (t *Tx) QueryJSONElements(
+	ctx context.Context,
+	cmd string,
+	out *[]json.RawMessage,
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, t, "QueryJSONElements", cmd, out, args, t.state, t.queryOpts)
+}
+
+// QueryJSONElementsFunc runs a query and calls fn with each result element
+// as a separate JSON document as it is received.
+// See Client.QueryJSONElementsFunc.
+func (t *Tx) QueryJSONElementsFunc(
+	ctx context.Context,
+	cmd string,
+	fn func(json.RawMessage) error,
+	args ...interface{},
+) error {
+	return runQuery(
+		ctx, t, "QueryJSONElements", cmd, fn, args, t.state, t.queryOpts)
 }
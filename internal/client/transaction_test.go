@@ -125,6 +125,46 @@ func newTxOpts(level IsolationLevel, readOnly, deferrable bool) TxOptions {
 		WithDeferrable(deferrable)
 }
 
+func TestRawTx(t *testing.T) {
+	ctx := context.Background()
+	query := `
+		SELECT (
+			SELECT TxTest {name}
+			FILTER .name = <str>$0
+		).name
+		LIMIT 1
+	`
+
+	tx, err := client.RawTx(ctx)
+	require.NoError(t, err)
+	err = tx.Execute(ctx, "INSERT TxTest {name := 'Test Raw Roll Back'};")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+
+	var testNames []string
+	err = client.Query(ctx, query, &testNames, "Test Raw Roll Back")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(testNames), "The transaction wasn't rolled back")
+
+	tx, err = client.RawTx(ctx)
+	require.NoError(t, err)
+	err = tx.Execute(ctx, "INSERT TxTest {name := 'Test Raw Commit'};")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	assert.EqualError(t, tx.Commit(ctx), "edgedb.InterfaceError: "+
+		"cannot commit; the transaction is already committed")
+
+	err = client.Query(ctx, query, &testNames, "Test Raw Commit")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Test Raw Commit"}, testNames)
+
+	err = client.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		return tx.Commit(ctx)
+	})
+	assert.EqualError(t, err, "edgedb.InterfaceError: cannot commit; "+
+		"only transactions started with RawTx can be ended manually")
+}
+
 func TestTxKinds(t *testing.T) {
 	ctx := context.Background()
 
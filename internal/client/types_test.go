@@ -46,10 +46,14 @@ func TestMissmatchedUnmarshalerType(t *testing.T) {
 		SELECT { val := 123_456_789_987_654_321 }`,
 		&wrongType,
 	)
-	assert.EqualError(t, err, "edgedb.InvalidArgumentError: "+
-		"the \"out\" argument does not match query schema: expected "+
-		"struct { Val edgedb.CustomInt32 \"edgedb:\\\"val\\\"\" }.val "+
-		"to be int64 or edgedb.OptionalInt64 got edgedb.CustomInt32")
+	expected := "edgedb.InvalidArgumentError: " +
+		"the \"out\" argument does not match query schema: expected " +
+		"struct { Val edgedb.CustomInt32 \"edgedb:\\\"val\\\"\" }.val " +
+		"to be int64 or edgedb.OptionalInt64 got edgedb.CustomInt32"
+	if protocolVersion.GTE(protocolVersion2p0) {
+		expected += " (server type std::int64)"
+	}
+	assert.EqualError(t, err, expected)
 	assert.Equal(t, []byte(nil), wrongType.Val.data)
 }
 
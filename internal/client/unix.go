@@ -21,8 +21,12 @@ package edgedb
 
 import (
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path"
+	"strings"
 	"syscall"
 )
 
@@ -56,3 +60,24 @@ func device(dir string) (int, error) {
 
 	return int(stat.Sys().(*syscall.Stat_t).Dev), nil
 }
+
+func keyringLookup(service, account string) (string, bool, error) {
+	out, err := exec.Command(
+		"secret-tool", "lookup",
+		"service", service,
+		"account", account,
+	).Output()
+
+	// secret-tool exits with status 1 and no output
+	// if the secret does not exist.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 && len(exitErr.Stderr) == 0 {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("secret service lookup failed: %w", err)
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), true, nil
+}
@@ -0,0 +1,96 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+)
+
+// WarningHandler is called with the warnings the server reported for a query
+// after the query succeeded. If the handler returns an error the query
+// returns that error.
+type WarningHandler func(warnings []error) error
+
+// LogWarnings is a WarningHandler that logs warnings using the log package.
+// It is the default WarningHandler.
+func LogWarnings(warnings []error) error {
+	for _, w := range warnings {
+		log.Println("EdgeDB warning:", w)
+	}
+
+	return nil
+}
+
+// WarningsAsErrors is a WarningHandler that returns the warnings as an error.
+func WarningsAsErrors(warnings []error) error {
+	return wrapAll(warnings...)
+}
+
+type warningJSON struct {
+	Code    uint32 `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+// decodeAnnotations reads the annotations of a message returning the
+// warnings that they contain.
+func decodeAnnotations(r *buff.Reader) ([]error, error) {
+	var warnings []error
+
+	n := int(r.PopUint16())
+	for i := 0; i < n; i++ {
+		name := r.PopString()
+		value := r.PopBytes()
+
+		if name != "warnings" {
+			continue
+		}
+
+		var decoded []warningJSON
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return nil, &binaryProtocolError{err: fmt.Errorf(
+				"invalid warnings annotation: %w", err)}
+		}
+
+		for _, w := range decoded {
+			msg := w.Message
+			if w.Hint != "" {
+				msg = fmt.Sprintf("%v\nHint: %v", msg, w.Hint)
+			}
+
+			warnings = append(warnings, errorFromCode(w.Code, msg))
+		}
+	}
+
+	return warnings, nil
+}
+
+// handleWarnings calls the query's warning handler with the warnings that the
+// server reported for the query if the query succeeded.
+func (q *query) handleWarnings(err error) error {
+	if err != nil ||
+		len(q.descIDs.warnings) == 0 ||
+		q.warningHandler == nil {
+		return err
+	}
+
+	return q.warningHandler(q.descIDs.warnings)
+}
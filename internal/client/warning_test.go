@@ -0,0 +1,65 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWarningAnnotations(t *testing.T) {
+	w := buff.NewWriter(nil)
+	w.BeginMessage(uint8(CommandDataDescription))
+	w.PushUint16(2) // annotation count
+	w.PushString("other")
+	w.PushString("ignored")
+	w.PushString("warnings")
+	w.PushString(`[{"code": 67108864, "message": "deprecated", "hint": "x"}]`)
+	w.EndMessage()
+
+	r := buff.SimpleReader(w.Unwrap()[5:])
+	warnings, err := decodeAnnotations(r)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.EqualError(t, warnings[0],
+		"edgedb.QueryError: deprecated\nHint: x")
+
+	var edbErr Error
+	require.True(t, errors.As(warnings[0], &edbErr))
+	assert.True(t, edbErr.Category(QueryError))
+}
+
+func TestHandleWarnings(t *testing.T) {
+	warning := &queryError{msg: "deprecated"}
+	q := query{descIDs: idPair{warnings: []error{warning}}}
+
+	assert.NoError(t, q.handleWarnings(nil), "nil handler ignores warnings")
+
+	q.warningHandler = LogWarnings
+	assert.NoError(t, q.handleWarnings(nil))
+
+	q.warningHandler = WarningsAsErrors
+	assert.Equal(t, warning, q.handleWarnings(nil))
+
+	failed := errors.New("query failed")
+	assert.Equal(t, failed, q.handleWarnings(failed),
+		"warnings are not handled for failed queries")
+}
@@ -21,7 +21,10 @@ package edgedb
 
 import (
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"unsafe"
 
 	"github.com/certifi/gocertifi"
 	"golang.org/x/sys/windows"
@@ -46,3 +49,54 @@ func configDirOSSpecific() (string, error) {
 func device(dir string) (int, error) {
 	return 0, nil
 }
+
+const credTypeGeneric = 1
+
+var (
+	advapi32     = windows.NewLazySystemDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func keyringLookup(service, account string) (string, bool, error) {
+	target, err := windows.UTF16PtrFromString(service + ":" + account)
+	if err != nil {
+		return "", false, err
+	}
+
+	var cred *credential
+	r, _, err := procCredRead.Call(
+		uintptr(unsafe.Pointer(target)),
+		credTypeGeneric,
+		0,
+		uintptr(unsafe.Pointer(&cred)),
+	)
+	if r == 0 {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf(
+			"credential manager lookup failed: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred))) // nolint:errcheck
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), true, nil
+}
@@ -109,7 +109,7 @@ func (c *argsEncoder) Encode(
 	var err error
 	for i, field := range c.fields {
 		w.PushUint32(0) // reserved
-		err = field.encoder.Encode(w, in[i], path.AddIndex(i), field.required)
+		err = field.encode(w, in[i], path.AddIndex(i), field.required)
 		if err != nil {
 			return err
 		}
@@ -143,6 +143,10 @@ func (c *kwargsEncoder) Encode(
 		)
 	}
 
+	if s, ok := args[0].(StructArgs); ok {
+		return c.encodeStruct(w, s)
+	}
+
 	in, ok := args[0].(map[string]interface{})
 	if !ok {
 		return fmt.Errorf(
@@ -157,7 +161,7 @@ func (c *kwargsEncoder) Encode(
 	var err error
 	for _, field := range c.fields {
 		w.PushUint32(0) // reserved
-		err = field.encoder.Encode(
+		err = field.encode(
 			w,
 			in[field.name],
 			path.AddField(field.name),
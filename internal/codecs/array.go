@@ -112,6 +112,7 @@ func buildArrayDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Slice {
 		return nil, fmt.Errorf(
@@ -119,31 +120,47 @@ func buildArrayDecoder(
 		)
 	}
 
-	child, err := BuildDecoder(desc.Fields[0].Desc, typ.Elem(), path)
+	elm := desc.Fields[0].Desc
+	child, err := buildElementDecoder(
+		typ.Elem(),
+		elm.Type == descriptor.Object,
+		func(typ reflect.Type) (Decoder, error) {
+			return BuildDecoder(elm, typ, path, opts)
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &arrayDecoder{desc.ID, child, typ, calcStep(typ.Elem())}, nil
+	return &arrayDecoder{desc.ID, child, typ, calcStep(typ.Elem()), path}, nil
 }
 
 func buildArrayDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Slice {
 		return nil, fmt.Errorf(
-			"expected %v to be a Slice, got %v", path, typ.Kind(),
+			"expected %v to be a Slice, got %v%v",
+			path, typ.Kind(), serverTypeV2(desc),
 		)
 	}
 
-	child, err := BuildDecoderV2(&desc.Fields[0].Desc, typ.Elem(), path)
+	elm := &desc.Fields[0].Desc
+	child, err := buildElementDecoder(
+		typ.Elem(),
+		elm.Type == descriptor.Object,
+		func(typ reflect.Type) (Decoder, error) {
+			return BuildDecoderV2(elm, typ, path, opts)
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &arrayDecoder{desc.ID, child, typ, calcStep(typ.Elem())}, nil
+	return &arrayDecoder{desc.ID, child, typ, calcStep(typ.Elem()), path}, nil
 }
 
 type arrayDecoder struct {
@@ -153,6 +170,9 @@ type arrayDecoder struct {
 
 	// step is the element width in bytes for a go array of type `Array.typ`.
 	step int
+
+	// path is used in errors about the elements.
+	path Path
 }
 
 func (c *arrayDecoder) DescriptorID() types.UUID { return c.id }
@@ -181,12 +201,14 @@ func (c *arrayDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 			continue
 		}
 
-		err := c.child.Decode(
-			r.PopSlice(elmLen),
-			pAdd(slice.Data, uintptr(i*c.step)),
-		)
+		elm := r.PopSlice(elmLen)
+		if err := CheckDataLength(c.child, elm, c.path); err != nil {
+			return WrapIndex(err, i)
+		}
+
+		err := c.child.Decode(elm, pAdd(slice.Data, uintptr(i*c.step)))
 		if err != nil {
-			return err
+			return WrapIndex(err, i)
 		}
 	}
 	return nil
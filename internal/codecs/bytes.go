@@ -18,6 +18,7 @@ package codecs
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"unsafe"
 
@@ -67,14 +68,16 @@ func (c *BytesCodec) Encode(
 ) error {
 	switch in := val.(type) {
 	case []byte:
-		return c.encodeData(w, in)
+		return c.encodeData(w, in, path)
 	case types.OptionalBytes:
 		data, ok := in.Get()
 		return encodeOptional(w, !ok, required,
-			func() error { return c.encodeData(w, data) },
+			func() error { return c.encodeData(w, data, path) },
 			func() error {
 				return missingValueError("edgedb.OptionalBytes", path)
 			})
+	case types.BytesReader:
+		return c.encodeReader(w, in, path)
 	case optionalBytesMarshaler:
 		return encodeOptional(w, in.Missing(), required,
 			func() error { return c.encodeMarshaler(w, in, path) },
@@ -82,27 +85,53 @@ func (c *BytesCodec) Encode(
 	case marshal.BytesMarshaler:
 		return c.encodeMarshaler(w, in, path)
 	default:
-		return fmt.Errorf("expected %v to be []byte, edgedb.OptionalBytes or "+
-			"BytesMarshaler got %T", path, val)
+		return fmt.Errorf("expected %v to be []byte, edgedb.OptionalBytes, "+
+			"edgedb.BytesReader or BytesMarshaler got %T", path, val)
 	}
 }
 
-func (c *BytesCodec) encodeData(w *buff.Writer, data []byte) error {
+func (c *BytesCodec) encodeData(
+	w *buff.Writer,
+	data []byte,
+	path Path,
+) error {
+	if err := checkEncodedLength(path, len(data)); err != nil {
+		return err
+	}
+
 	w.PushUint32(uint32(len(data)))
 	w.PushBytes(data)
 	return nil
 }
 
+func (c *BytesCodec) encodeReader(
+	w *buff.Writer,
+	in types.BytesReader,
+	path Path,
+) error {
+	if in.Reader() == nil {
+		return fmt.Errorf("%v is an edgedb.BytesReader without a reader", path)
+	}
+
+	if in.Size() < 0 || int64(in.Size()) > math.MaxUint32 {
+		return fmt.Errorf("invalid size for %v: %v bytes", path, in.Size())
+	}
+
+	w.PushUint32(uint32(in.Size()))
+	w.PushReader(in.Reader(), in.Size())
+	return nil
+}
+
 func (c *BytesCodec) encodeMarshaler(
 	w *buff.Writer,
 	val marshal.BytesMarshaler,
-	_ Path,
+	path Path,
 ) error {
 	data, err := val.MarshalEdgeDBBytes()
 	if err != nil {
 		return err
 	}
-	return c.encodeData(w, data)
+	return c.encodeData(w, data, path)
 }
 
 type optionalBytesLayout struct {
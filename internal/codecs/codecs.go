@@ -58,6 +58,7 @@ type OptionalDecoder interface {
 // used by objects, named tuples and tuples.
 type DecoderField struct {
 	name    string
+	path    Path
 	offset  uintptr
 	decoder Decoder
 }
@@ -94,9 +95,6 @@ func BuildEncoder(
 		}
 		return buildTupleEncoder(desc, version)
 	case descriptor.NamedTuple:
-		if version.GTE(internal.ProtocolVersion{Major: 0, Minor: 12}) {
-			return nil, errors.New("tuples can not be encoded")
-		}
 		return buildNamedTupleEncoder(desc, version)
 	case descriptor.Array:
 		return buildArrayEncoder(desc, version)
@@ -128,7 +126,7 @@ func BuildEncoderV2(
 	case descriptor.Tuple:
 		return nil, errors.New("tuples can not be encoded")
 	case descriptor.NamedTuple:
-		return nil, errors.New("tuples can not be encoded")
+		return buildNamedTupleEncoderV2(desc, version)
 	case descriptor.Array:
 		return buildArrayEncoderV2(desc, version)
 	case descriptor.Range:
@@ -169,11 +167,11 @@ func BuildScalarEncoder(desc descriptor.Descriptor) (Encoder, error) {
 	}
 
 	if desc.ID == DecimalID {
-		return &decimalEncoder{}, nil
+		return &DecimalCodec{}, nil
 	}
 
 	if desc.Type == descriptor.Enum {
-		return &StrCodec{desc.ID}, nil
+		return &enumEncoder{StrCodec{desc.ID}}, nil
 	}
 
 	switch desc.ID {
@@ -193,10 +191,6 @@ func BuildScalarEncoder(desc descriptor.Descriptor) (Encoder, error) {
 		return &Float32Codec{}, nil
 	case Float64ID:
 		return &Float64Codec{}, nil
-	case DecimalID:
-		return nil, errors.New("decimal codec not implemented. " +
-			"Consider implementing your own edgedb.DecimalMarshaler " +
-			"and edgedb.DecimalUnmarshaler.")
 	case BoolID:
 		return &BoolCodec{}, nil
 	case DateTimeID:
@@ -231,12 +225,20 @@ func BuildScalarEncoderV2(desc *descriptor.V2) (Encoder, error) {
 		desc = GetScalarDescriptorV2(desc)
 	}
 
+	if isGeometry(desc) {
+		return &geometryEncoder{BytesCodec{desc.ID}}, nil
+	}
+
+	if isVector(desc) {
+		return buildVectorEncoder(desc), nil
+	}
+
 	if desc.ID == DecimalID {
-		return &decimalEncoder{}, nil
+		return &DecimalCodec{}, nil
 	}
 
 	if desc.Type == descriptor.Enum {
-		return &StrCodec{desc.ID}, nil
+		return &enumEncoder{StrCodec{desc.ID}}, nil
 	}
 
 	switch desc.ID {
@@ -256,10 +258,6 @@ func BuildScalarEncoderV2(desc *descriptor.V2) (Encoder, error) {
 		return &Float32Codec{}, nil
 	case Float64ID:
 		return &Float64Codec{}, nil
-	case DecimalID:
-		return nil, errors.New("decimal codec not implemented. " +
-			"Consider implementing your own edgedb.DecimalMarshaler " +
-			"and edgedb.DecimalUnmarshaler.")
 	case BoolID:
 		return &BoolCodec{}, nil
 	case DateTimeID:
@@ -293,24 +291,29 @@ func BuildDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if desc.ID == descriptor.IDZero {
 		return noOpDecoder{}, nil
 	}
 
+	if decoder, ok, err := buildDynamicDecoder(desc, typ, path, opts); ok {
+		return decoder, err
+	}
+
 	switch desc.Type {
 	case descriptor.Set:
-		return buildSetDecoder(desc, typ, path)
+		return buildSetDecoder(desc, typ, path, opts)
 	case descriptor.Object:
-		return buildObjectDecoder(desc, typ, path)
+		return buildObjectDecoder(desc, typ, path, opts)
 	case descriptor.BaseScalar, descriptor.Enum, descriptor.Scalar:
 		return buildScalarDecoder(desc, typ, path)
 	case descriptor.Tuple:
-		return buildTupleDecoder(desc, typ, path)
+		return buildTupleDecoder(desc, typ, path, opts)
 	case descriptor.NamedTuple:
-		return buildNamedTupleDecoder(desc, typ, path)
+		return buildNamedTupleDecoder(desc, typ, path, opts)
 	case descriptor.Array:
-		return buildArrayDecoder(desc, typ, path)
+		return buildArrayDecoder(desc, typ, path, opts)
 	case descriptor.Range:
 		return buildRangeDecoder(desc, typ, path)
 	default:
@@ -325,24 +328,33 @@ func BuildDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if desc.ID == descriptor.IDZero {
 		return noOpDecoder{}, nil
 	}
 
+	if decoder, ok, err := buildDynamicDecoderV2(desc, typ, path, opts); ok {
+		return decoder, err
+	}
+
+	if desc.Type == descriptor.Object && typ.Kind() == reflect.Interface {
+		return buildPolymorphicDecoderV2(desc, typ, path, opts)
+	}
+
 	switch desc.Type {
 	case descriptor.Set:
-		return buildSetDecoderV2(desc, typ, path)
+		return buildSetDecoderV2(desc, typ, path, opts)
 	case descriptor.Object:
-		return buildObjectDecoderV2(desc, typ, path)
+		return buildObjectDecoderV2(desc, typ, path, opts)
 	case descriptor.BaseScalar, descriptor.Enum, descriptor.Scalar:
 		return buildScalarDecoderV2(desc, typ, path)
 	case descriptor.Tuple:
-		return buildTupleDecoderV2(desc, typ, path)
+		return buildTupleDecoderV2(desc, typ, path, opts)
 	case descriptor.NamedTuple:
-		return buildNamedTupleDecoderV2(desc, typ, path)
+		return buildNamedTupleDecoderV2(desc, typ, path, opts)
 	case descriptor.Array:
-		return buildArrayDecoderV2(desc, typ, path)
+		return buildArrayDecoderV2(desc, typ, path, opts)
 	case descriptor.Range:
 		return buildRangeDecoderV2(desc, typ, path)
 	default:
@@ -361,6 +373,11 @@ func buildScalarDecoder(
 		desc = GetScalarDescriptor(desc)
 	}
 
+	if desc.Type == descriptor.Enum {
+		return buildEnumDecoder(
+			desc.ID, desc.EnumMembers, typ, path, "")
+	}
+
 	decoder, ok, err := buildUnmarshaler(desc, typ)
 	if err != nil {
 		return decoder, err
@@ -371,18 +388,6 @@ func buildScalarDecoder(
 
 	var expectedType string
 
-	if desc.Type == descriptor.Enum {
-		switch typ {
-		case strType:
-			return &StrCodec{desc.ID}, nil
-		case optionalStrType:
-			return &optionalStrDecoder{StrID}, nil
-		default:
-			expectedType = "string or edgedb.OptionalStr"
-			goto TypeMissmatch
-		}
-	}
-
 	switch desc.ID {
 	case UUIDID:
 		switch typ {
@@ -457,9 +462,7 @@ func buildScalarDecoder(
 			expectedType = "float64 or edgedb.OptionalFloat64"
 		}
 	case DecimalID:
-		return nil, errors.New("decimal codec not implemented. " +
-			"Consider implementing your own edgedb.DecimalMarshaler " +
-			"and edgedb.DecimalUnmarshaler.")
+		return buildDecimalDecoder(typ, path)
 	case BoolID:
 		switch typ {
 		case boolType:
@@ -577,7 +580,6 @@ func buildScalarDecoder(
 		return nil, fmt.Errorf("unknown scalar type id %v %v", desc.ID, s)
 	}
 
-TypeMissmatch:
 	return nil, fmt.Errorf(
 		"expected %v to be %v got %v", path, expectedType, typ,
 	)
@@ -588,10 +590,25 @@ func buildScalarDecoderV2(
 	typ reflect.Type,
 	path Path,
 ) (Decoder, error) {
+	// Custom scalars are named in errors instead of their base type.
+	serverType := serverTypeV2(desc)
 	if desc.Type == descriptor.Scalar {
 		desc = GetScalarDescriptorV2(desc)
 	}
 
+	if isGeometry(desc) {
+		return buildGeometryDecoder(desc, typ, path)
+	}
+
+	if isVector(desc) {
+		return buildVectorDecoder(desc, typ, path)
+	}
+
+	if desc.Type == descriptor.Enum {
+		return buildEnumDecoder(
+			desc.ID, desc.EnumMembers, typ, path, serverType)
+	}
+
 	decoder, ok, err := buildUnmarshalerV2(desc, typ)
 	if err != nil {
 		return decoder, err
@@ -602,18 +619,6 @@ func buildScalarDecoderV2(
 
 	var expectedType string
 
-	if desc.Type == descriptor.Enum {
-		switch typ {
-		case strType:
-			return &StrCodec{desc.ID}, nil
-		case optionalStrType:
-			return &optionalStrDecoder{StrID}, nil
-		default:
-			expectedType = "string or edgedb.OptionalStr"
-			goto TypeMissmatch
-		}
-	}
-
 	switch desc.ID {
 	case UUIDID:
 		switch typ {
@@ -688,9 +693,7 @@ func buildScalarDecoderV2(
 			expectedType = "float64 or edgedb.OptionalFloat64"
 		}
 	case DecimalID:
-		return nil, errors.New("decimal codec not implemented. " +
-			"Consider implementing your own edgedb.DecimalMarshaler " +
-			"and edgedb.DecimalUnmarshaler.")
+		return buildDecimalDecoder(typ, path)
 	case BoolID:
 		switch typ {
 		case boolType:
@@ -808,9 +811,8 @@ func buildScalarDecoderV2(
 		return nil, fmt.Errorf("unknown scalar type id %v %v", desc.ID, s)
 	}
 
-TypeMissmatch:
 	return nil, fmt.Errorf(
-		"expected %v to be %v got %v", path, expectedType, typ,
+		"expected %v to be %v got %v%v", path, expectedType, typ, serverType,
 	)
 }
 
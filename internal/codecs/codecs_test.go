@@ -17,13 +17,796 @@
 package codecs
 
 import (
+	"bytes"
 	"reflect"
+	"strings"
 	"testing"
+	"unsafe"
 
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCalcStep(t *testing.T) {
 	step := calcStep(reflect.TypeOf(int64(0)))
 	assert.Equal(t, step, 8)
 }
+
+func TestDecodeObjectSkipsMissingImplicitFields(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "id",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: UUIDID},
+				Required: true,
+				Implicit: true,
+			},
+			{
+				Name:     "name",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+				Required: true,
+			},
+		},
+	}
+
+	var result struct {
+		Name string `edgedb:"name"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 2, // element count
+		// id
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 16, // data length
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", result.Name)
+
+	desc.Fields[0].Implicit = false
+	_, err = BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{},
+	)
+	assert.EqualError(t, err,
+		`expected out to have a field named "id"`)
+}
+
+func TestDecodeFreeShape(t *testing.T) {
+	str := descriptor.V2{Type: descriptor.Scalar, ID: StrID, Name: "std::str"}
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "total",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   Int64ID,
+					Name: "std::int64",
+				},
+				Required:    true,
+				Cardinality: 0x41,
+			},
+			{
+				Name:        "name",
+				Desc:        str,
+				Cardinality: 0x6f,
+			},
+			{
+				Name: "tags",
+				Desc: descriptor.V2{
+					Type:   descriptor.Set,
+					ID:     types.UUID{2},
+					Fields: []*descriptor.FieldV2{{Desc: str}},
+				},
+				Cardinality: 0x6d,
+			},
+		},
+	}
+
+	var result struct {
+		Total int64             `edgedb:"total"`
+		Name  types.OptionalStr `edgedb:"name"`
+		Tags  []string          `edgedb:"tags"`
+	}
+
+	_, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	var wrong struct {
+		Name string `edgedb:"name"`
+		Tags string `edgedb:"tags"`
+	}
+
+	_, err = BuildDecoderV2(
+		&desc, reflect.TypeOf(wrong), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "3 fields of out do not match "+
+		"the query result: "+
+		`expected out to have a field named "total" `+
+		"(required std::int64); "+
+		"expected string at out.name to be edgedb.OptionalStr "+
+		"because the field is not required; "+
+		"expected out.tags to be a Slice got string")
+}
+
+func TestDecodeNamedTupleMismatches(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.NamedTuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "score",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   types.UUID{2},
+					Name: "default::Score",
+					Ancestors: []*descriptor.FieldV2{{
+						Desc: descriptor.V2{
+							Type: descriptor.Scalar,
+							ID:   Int64ID,
+							Name: "std::int64",
+						},
+					}},
+				},
+			},
+			{
+				Name: "name",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   StrID,
+					Name: "std::str",
+				},
+			},
+		},
+	}
+
+	var wrong struct {
+		Score string `edgedb:"score"`
+	}
+
+	_, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(wrong), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "2 fields of out do not match "+
+		"the query result: "+
+		"expected out.score to be int64 or edgedb.OptionalInt64 "+
+		"got string (server type default::Score); "+
+		`expected out to have a field named "name" (server type std::str)`)
+}
+
+func TestDecodeObjectMismatchesV1(t *testing.T) {
+	desc := descriptor.Descriptor{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.Field{
+			{
+				Name: "score",
+				Desc: descriptor.Descriptor{
+					Type: descriptor.BaseScalar,
+					ID:   Int64ID,
+				},
+				Required: true,
+			},
+			{
+				Name: "name",
+				Desc: descriptor.Descriptor{
+					Type: descriptor.BaseScalar,
+					ID:   StrID,
+				},
+				Required: true,
+			},
+		},
+	}
+
+	var wrong struct {
+		Score string `edgedb:"score"`
+	}
+
+	_, err := BuildDecoder(
+		desc, reflect.TypeOf(wrong), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "2 fields of out do not match "+
+		"the query result: "+
+		"expected out.score to be int64 or edgedb.OptionalInt64 "+
+		"got string; "+
+		`expected out to have a field named "name"`)
+}
+
+func TestDecodeObjectWithStructTag(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "name",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+				Required: true,
+			},
+		},
+	}
+
+	var result struct {
+		Nick string `edgedb:"name"`
+		Name string `db:"name"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc,
+		reflect.TypeOf(result),
+		Path("out"),
+		DecoderOptions{StructTag: "db"},
+	)
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", result.Name)
+	assert.Equal(t, "", result.Nick)
+}
+
+func TestDecodeObjectMissingPolicy(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "name",
+				Desc: descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+			},
+		},
+	}
+	missing := []byte{
+		0, 0, 0, 1, // element count
+		// name
+		0, 0, 0, 0, // reserved
+		255, 255, 255, 255, // missing
+	}
+	present := []byte{
+		0, 0, 0, 1, // element count
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+	}
+
+	type Zero struct {
+		Name string `edgedb:"name"`
+	}
+	type Pointer struct {
+		Name *string `edgedb:"name"`
+	}
+
+	_, err := BuildDecoderV2(&desc,
+		reflect.TypeOf(Zero{}), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "expected string at out.name to be "+
+		"edgedb.OptionalStr because the field is not required")
+
+	_, err = BuildDecoderV2(&desc, reflect.TypeOf(Zero{}), Path("out"),
+		DecoderOptions{Missing: MissingPointerNil})
+	assert.EqualError(t, err, "expected string at out.name to be "+
+		"edgedb.OptionalStr because the field is not required")
+
+	decoder, err := BuildDecoderV2(&desc, reflect.TypeOf(Zero{}),
+		Path("out"), DecoderOptions{Missing: MissingZero})
+	require.NoError(t, err)
+
+	zero := Zero{Name: "alice"}
+	err = decoder.Decode(buff.SimpleReader(missing), unsafe.Pointer(&zero))
+	require.NoError(t, err)
+	assert.Equal(t, Zero{}, zero)
+
+	decoder, err = BuildDecoderV2(&desc, reflect.TypeOf(Pointer{}),
+		Path("out"), DecoderOptions{Missing: MissingPointerNil})
+	require.NoError(t, err)
+
+	var pointer Pointer
+	err = decoder.Decode(buff.SimpleReader(present), unsafe.Pointer(&pointer))
+	require.NoError(t, err)
+	require.NotNil(t, pointer.Name)
+	assert.Equal(t, "bob", *pointer.Name)
+
+	err = decoder.Decode(buff.SimpleReader(missing), unsafe.Pointer(&pointer))
+	require.NoError(t, err)
+	assert.Nil(t, pointer.Name)
+}
+
+func TestDecodeLinkIntoPointer(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "friend",
+				Desc: descriptor.V2{
+					Type: descriptor.Object,
+					ID:   types.UUID{2},
+					Fields: []*descriptor.FieldV2{
+						{
+							Name: "name",
+							Desc: descriptor.V2{
+								Type: descriptor.Scalar,
+								ID:   StrID,
+							},
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	type Friend struct {
+		Name string `edgedb:"name"`
+	}
+	var result struct {
+		Friend *Friend `edgedb:"friend"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// friend
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 15, // data length
+		0, 0, 0, 1, // element count
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, &Friend{Name: "bob"}, result.Friend)
+
+	data = []byte{
+		0, 0, 0, 1, // element count
+		// friend
+		0, 0, 0, 0, // reserved
+		255, 255, 255, 255, // missing
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Nil(t, result.Friend)
+}
+
+func TestDecodeLinkSetIntoPointers(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "friends",
+				Desc: descriptor.V2{
+					Type: descriptor.Set,
+					ID:   types.UUID{2},
+					Fields: []*descriptor.FieldV2{{
+						Desc: descriptor.V2{
+							Type: descriptor.Object,
+							ID:   types.UUID{3},
+							Fields: []*descriptor.FieldV2{{
+								Name: "name",
+								Desc: descriptor.V2{
+									Type: descriptor.Scalar,
+									ID:   StrID,
+								},
+								Required: true,
+							}},
+						},
+					}},
+				},
+				Required: true,
+			},
+		},
+	}
+
+	type Friend struct {
+		Name string `edgedb:"name"`
+	}
+	var result struct {
+		Friends []*Friend `edgedb:"friends"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// friends
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 58, // data length
+		0, 0, 0, 1, // number of dimensions
+		0, 0, 0, 0, 0, 0, 0, 0, // reserved
+		0, 0, 0, 2, // dimension length
+		0, 0, 0, 1, // lower bound
+		// friends[0]
+		0, 0, 0, 15, // data length
+		0, 0, 0, 1, // element count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+		// friends[1]
+		0, 0, 0, 15, // data length
+		0, 0, 0, 1, // element count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		106, 111, 101,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, []*Friend{{Name: "bob"}, {Name: "joe"}}, result.Friends)
+}
+
+func TestEncodeBytesReader(t *testing.T) {
+	codec := &BytesCodec{}
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0xa)
+	in := types.NewBytesReader(strings.NewReader("hello world"), 5)
+	require.NoError(t, codec.Encode(w, in, Path("args"), true))
+	w.EndMessage()
+
+	var out bytes.Buffer
+	_, err := w.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xa, 0, 0, 0, 13,
+		0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o',
+	}, out.Bytes())
+
+	err = codec.Encode(w, types.BytesReader{}, Path("args"), true)
+	assert.EqualError(t, err,
+		"args is an edgedb.BytesReader without a reader")
+}
+
+func TestEncodeJSONValue(t *testing.T) {
+	codec := &JSONCodec{}
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	for _, in := range []interface{}{
+		map[string]string{"name": "bob"},
+		user{Name: "bob"},
+		&user{Name: "bob"},
+	} {
+		w := buff.NewWriter(nil)
+		w.BeginMessage(0xa)
+		require.NoError(t, codec.Encode(w, in, Path("args"), true))
+		w.EndMessage()
+
+		var out bytes.Buffer
+		_, err := w.WriteTo(&out)
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{
+			0xa, 0, 0, 0, 23,
+			0, 0, 0, 15, // data length
+			1, // json format
+		}, `{"name":"bob"}`...), out.Bytes())
+	}
+
+	w := buff.NewWriter(nil)
+	var missing map[string]string
+	err := codec.Encode(w, missing, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode map[string]string at args "+
+		"because its value is missing")
+
+	err = codec.Encode(w, map[string]interface{}{"c": make(chan int)},
+		Path("args"), true)
+	assert.EqualError(t, err,
+		"cannot encode args: json: unsupported type: chan int")
+
+	err = codec.Encode(w, 1, Path("args"), true)
+	assert.EqualError(t, err, "expected args to be []byte, "+
+		"edgedb.OptionalBytes, JSONMarshaler, a map or a struct got int")
+}
+
+type panickyStr struct{}
+
+func (panickyStr) MarshalEdgeDBStr() ([]byte, error) { panic("boom") }
+
+func (*panickyStr) UnmarshalEdgeDBStr(data []byte) error { panic("boom") }
+
+func TestDecodePanicIsReturned(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "name",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+				Required: true,
+			},
+		},
+	}
+
+	var result struct {
+		Name panickyStr `edgedb:"name"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc,
+		reflect.TypeOf(result),
+		Path("out"),
+		DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	assert.EqualError(t, err,
+		"decoding result.name: panic while decoding: boom")
+}
+
+func TestEncodePanicIsReturned(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "0",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+				Required: true,
+			},
+		},
+	}
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0xa)
+	err = encoder.Encode(w, []interface{}{panickyStr{}}, Path("args"), true)
+	assert.EqualError(t, err, "panic while encoding args[0]: boom")
+}
+
+func TestDecodeWrongDataLength(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "count",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: Int32ID},
+				Required: true,
+			},
+		},
+	}
+
+	var result struct {
+		Count int32 `edgedb:"count"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc,
+		reflect.TypeOf(result),
+		Path("out"),
+		DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		0, 0, 7,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	assert.EqualError(t, err, "decoding result.count: "+
+		"wrong number of bytes for std::int32 at out.count: "+
+		"expected 4, got 3")
+}
+
+func TestDecodeErrorLocation(t *testing.T) {
+	count := descriptor.V2{Type: descriptor.Scalar, ID: Int32ID}
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name: "posts",
+				Desc: descriptor.V2{
+					Type: descriptor.Set,
+					ID:   types.UUID{2},
+					Fields: []*descriptor.FieldV2{{
+						Desc: descriptor.V2{
+							Type: descriptor.Object,
+							ID:   types.UUID{3},
+							Fields: []*descriptor.FieldV2{{
+								Name:     "count",
+								Desc:     count,
+								Required: true,
+							}},
+						},
+					}},
+				},
+				Required: true,
+			},
+		},
+	}
+
+	var result struct {
+		Posts []struct {
+			Count int32 `edgedb:"count"`
+		} `edgedb:"posts"`
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc,
+		reflect.TypeOf(result),
+		Path("out"),
+		DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// posts
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 59, // data length
+		0, 0, 0, 1, // number of dimensions
+		0, 0, 0, 0, 0, 0, 0, 0, // reserved
+		0, 0, 0, 2, // dimension length
+		0, 0, 0, 1, // lower bound
+		// posts[0]
+		0, 0, 0, 16, // data length
+		0, 0, 0, 1, // element count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 4, // data length
+		0, 0, 0, 7,
+		// posts[1]
+		0, 0, 0, 15, // data length
+		0, 0, 0, 1, // element count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		0, 0, 7,
+	}
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	assert.EqualError(t, err, "decoding result.posts[1].count: "+
+		"wrong number of bytes for std::int32 "+
+		"at out.posts.count: expected 4, got 3")
+
+	var decodeErr *DecodeError
+	require.ErrorAs(t, WrapIndex(err, 10532), &decodeErr)
+	assert.Equal(t, "[10532].posts[1].count", decodeErr.Location())
+}
+
+func TestEncodeDataLengthLimit(t *testing.T) {
+	defer func(n int) { maxDataLength = n }(maxDataLength)
+	maxDataLength = 4
+
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{
+				Name:     "0",
+				Desc:     descriptor.V2{Type: descriptor.Scalar, ID: StrID},
+				Required: true,
+			},
+		},
+	}
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0xa)
+	err = encoder.Encode(w, []interface{}{"abcd"}, Path("args"), true)
+	require.NoError(t, err)
+
+	err = encoder.Encode(w, []interface{}{"abcde"}, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode args[0]: its 5 bytes exceed "+
+		"the protocol limit of 4 bytes")
+}
+
+func TestEncodeStructArgs(t *testing.T) {
+	scalar := func(id types.UUID) descriptor.V2 {
+		return descriptor.V2{Type: descriptor.Scalar, ID: id}
+	}
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "name", Desc: scalar(StrID), Required: true},
+			{Name: "count", Desc: scalar(Int64ID)},
+			{Name: "score", Desc: scalar(Float64ID), Required: true},
+		},
+	}
+	version := internal.ProtocolVersion{Major: 2}
+	encoder, err := BuildEncoderV2(&desc, version)
+	require.NoError(t, err)
+
+	type args struct {
+		Name  string
+		Count types.OptionalInt64
+		Score float64
+	}
+	typ := reflect.TypeOf(args{})
+	fields := make([]ArgField, typ.NumField())
+	for i := range fields {
+		field := typ.Field(i)
+		fields[i] = ArgField{
+			Name:   strings.ToLower(field.Name),
+			Offset: field.Offset,
+			Type:   field.Type,
+		}
+	}
+
+	binding := NewArgBinding(fields)
+	require.NoError(t, binding.Bind(encoder))
+
+	val := args{Name: "n", Score: 1.5}
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w,
+		[]interface{}{StructArgs{Binding: binding, Ptr: unsafe.Pointer(&val)}},
+		Path("args"), true)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	expected := buff.NewWriter(nil)
+	expected.BeginMessage(0)
+	err = encoder.Encode(expected, []interface{}{map[string]interface{}{
+		"name":  "n",
+		"count": types.OptionalInt64{},
+		"score": 1.5,
+	}}, Path("args"), true)
+	require.NoError(t, err)
+	expected.EndMessage()
+	assert.Equal(t, expected.Unwrap(), w.Unwrap())
+
+	err = NewArgBinding(fields[:2]).Bind(encoder)
+	assert.EqualError(t, err, "no struct field for argument args.score")
+
+	positional := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{2},
+		Fields: []*descriptor.FieldV2{
+			{Name: "0", Desc: scalar(StrID), Required: true},
+		},
+	}
+	encoder, err = BuildEncoderV2(&positional, version)
+	require.NoError(t, err)
+	err = binding.Bind(encoder)
+	assert.EqualError(t, err,
+		"struct arguments can not be bound to positional arguments")
+}
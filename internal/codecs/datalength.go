@@ -0,0 +1,80 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// maxDataLength is the largest encoded value
+// that fits in a protocol element's int32 data length.
+var maxDataLength = math.MaxInt32
+
+type fixedLengthScalar struct {
+	name   string
+	length int
+}
+
+// fixedLengthScalars are the scalar types that are always encoded
+// with the same number of bytes keyed by their descriptor ID.
+var fixedLengthScalars = map[types.UUID]fixedLengthScalar{
+	UUIDID:             {"std::uuid", 16},
+	Int16ID:            {"std::int16", 2},
+	Int32ID:            {"std::int32", 4},
+	Int64ID:            {"std::int64", 8},
+	Float32ID:          {"std::float32", 4},
+	Float64ID:          {"std::float64", 8},
+	BoolID:             {"std::bool", 1},
+	DateTimeID:         {"std::datetime", 8},
+	LocalDTID:          {"cal::local_datetime", 8},
+	LocalDateID:        {"cal::local_date", 4},
+	LocalTimeID:        {"cal::local_time", 8},
+	DurationID:         {"std::duration", 16},
+	RelativeDurationID: {"cal::relative_duration", 16},
+	DateDurationID:     {"cal::date_duration", 16},
+	MemoryID:           {"cfg::memory", 8},
+}
+
+// CheckDataLength returns an error if decoder decodes a fixed length scalar
+// and r does not hold exactly the number of bytes that scalar is encoded
+// with. Without this check a wrong data length either fails with an
+// unhelpful panic or is silently ignored.
+func CheckDataLength(decoder Decoder, r *buff.Reader, path Path) error {
+	scalar, ok := fixedLengthScalars[decoder.DescriptorID()]
+	if !ok || len(r.Buf) == scalar.length {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"wrong number of bytes for %v at %v: expected %v, got %v",
+		scalar.name, path, scalar.length, len(r.Buf))
+}
+
+// checkEncodedLength returns an error if n bytes are too many
+// to be encoded as the value at path.
+func checkEncodedLength(path Path, n int) error {
+	if n <= maxDataLength {
+		return nil
+	}
+
+	return fmt.Errorf("cannot encode %v: its %v bytes exceed "+
+		"the protocol limit of %v bytes", path, n, maxDataLength)
+}
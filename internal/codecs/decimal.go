@@ -0,0 +1,356 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/marshal"
+)
+
+var (
+	textMarshalerType   = getType((*encoding.TextMarshaler)(nil))
+	textUnmarshalerType = getType((*encoding.TextUnmarshaler)(nil))
+)
+
+const (
+	decimalPositive = 0x0000
+	decimalNegative = 0x4000
+	decimalNaN      = 0xc000
+)
+
+func buildDecimalDecoder(typ reflect.Type, path Path) (Decoder, error) {
+	if typ == strType {
+		return &decimalStrDecoder{}, nil
+	}
+
+	ptr := reflect.PtrTo(typ)
+	if !ptr.Implements(textUnmarshalerType) {
+		return nil, fmt.Errorf("expected %v to be string, DecimalUnmarshaler "+
+			"or encoding.TextUnmarshaler got %v", path, typ)
+	}
+
+	decoder := DecimalCodec{typ}
+	if ptr.Implements(optionalUnmarshalerType) {
+		return &optionalDecimalDecoder{decoder}, nil
+	}
+
+	return &decoder, nil
+}
+
+// DecimalCodec encodes/decodes std::decimal values. The driver has no
+// decimal type of its own, instead values are exchanged with types that
+// implement DecimalMarshaler and DecimalUnmarshaler or
+// encoding.TextMarshaler and encoding.TextUnmarshaler. This allows decimal
+// libraries like github.com/shopspring/decimal to be used directly.
+type DecimalCodec struct {
+	typ reflect.Type
+}
+
+// DescriptorID returns the codecs descriptor id.
+func (c *DecimalCodec) DescriptorID() types.UUID { return DecimalID }
+
+// Decode decodes a decimal into an encoding.TextUnmarshaler.
+func (c *DecimalCodec) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) (err error) {
+	defer recoverUnmarshaler(&err)
+	text, err := decodeDecimalText(r)
+	if err != nil {
+		return err
+	}
+
+	val := reflect.NewAt(c.typ, out).Interface()
+	return val.(encoding.TextUnmarshaler).UnmarshalText(text)
+}
+
+// decimalStrDecoder decodes a decimal into its text form. It is the natural
+// type of decimals decoded into interface{}.
+type decimalStrDecoder struct{}
+
+func (c *decimalStrDecoder) DescriptorID() types.UUID { return DecimalID }
+
+func (c *decimalStrDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	text, err := decodeDecimalText(r)
+	if err != nil {
+		return err
+	}
+
+	*(*string)(out) = string(text)
+	return nil
+}
+
+// decodeDecimalText converts the decimal wire format into its text form.
+// https://www.edgedb.com/docs/internals/protocol/dataformats#std-decimal
+func decodeDecimalText(r *buff.Reader) ([]byte, error) {
+	n := int(r.PopUint16())
+	weight := int(int16(r.PopUint16()))
+	sign := r.PopUint16()
+	scale := int(r.PopUint16())
+
+	digits := make([]uint16, n)
+	for i := range digits {
+		digits[i] = r.PopUint16()
+	}
+
+	switch sign {
+	case decimalPositive, decimalNegative:
+	case decimalNaN:
+		return nil, errors.New("decimal NaN is not supported")
+	default:
+		return nil, fmt.Errorf("unexpected decimal sign 0x%x", sign)
+	}
+
+	digit := func(i int) uint64 {
+		if i < 0 || i >= n {
+			return 0
+		}
+		return uint64(digits[i])
+	}
+
+	text := make([]byte, 0, 4*(n+2)+scale)
+	if sign == decimalNegative {
+		text = append(text, '-')
+	}
+
+	if weight < 0 {
+		text = append(text, '0')
+	} else {
+		text = strconv.AppendUint(text, digit(0), 10)
+		for i := 1; i <= weight; i++ {
+			text = appendDecimalDigit(text, digit(i))
+		}
+	}
+
+	if scale > 0 {
+		text = append(text, '.')
+		end := len(text) + scale
+		for i := weight + 1; len(text) < end; i++ {
+			text = appendDecimalDigit(text, digit(i))
+		}
+		text = text[:end]
+	}
+
+	return text, nil
+}
+
+// appendDecimalDigit appends a base 10000 digit padded to 4 places.
+func appendDecimalDigit(text []byte, digit uint64) []byte {
+	for pad := uint64(1000); pad > 1 && digit < pad; pad /= 10 {
+		text = append(text, '0')
+	}
+	return strconv.AppendUint(text, digit, 10)
+}
+
+type optionalDecimalMarshaler interface {
+	marshal.DecimalMarshaler
+	marshal.OptionalMarshaler
+}
+
+type optionalTextMarshaler interface {
+	encoding.TextMarshaler
+	marshal.OptionalMarshaler
+}
+
+// Encode encodes a DecimalMarshaler or encoding.TextMarshaler.
+func (c *DecimalCodec) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	switch in := val.(type) {
+	case optionalDecimalMarshaler:
+		return encodeOptional(w, in.Missing(), required,
+			func() error { return c.encodeMarshaler(w, in, path) },
+			func() error { return missingValueError(in, path) })
+	case marshal.DecimalMarshaler:
+		return c.encodeMarshaler(w, in, path)
+	case optionalTextMarshaler:
+		return encodeOptional(w, in.Missing(), required,
+			func() error { return c.encodeText(w, in, path) },
+			func() error { return missingValueError(in, path) })
+	case encoding.TextMarshaler:
+		return c.encodeText(w, in, path)
+	default:
+		return fmt.Errorf("expected %v to be DecimalMarshaler "+
+			"or encoding.TextMarshaler got %T", path, val)
+	}
+}
+
+func (c *DecimalCodec) encodeMarshaler(
+	w *buff.Writer,
+	val marshal.DecimalMarshaler,
+	path Path,
+) error {
+	data, err := val.MarshalEdgeDBDecimal()
+	if err != nil {
+		return err
+	}
+	if len(data) < 8 {
+		return wrongNumberOfBytesError(val, path, "at least 8", len(data))
+	}
+	w.BeginBytes()
+	w.PushBytes(data)
+	w.EndBytes()
+	return nil
+}
+
+func (c *DecimalCodec) encodeText(
+	w *buff.Writer,
+	val encoding.TextMarshaler,
+	path Path,
+) error {
+	text, err := val.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeDecimalText(string(text))
+	if err != nil {
+		return fmt.Errorf("cannot encode %T at %v: %w", val, path, err)
+	}
+
+	w.BeginBytes()
+	w.PushBytes(data)
+	w.EndBytes()
+	return nil
+}
+
+// encodeDecimalText converts a decimal string like -12.50 or 1.25e-3
+// into the decimal wire format.
+func encodeDecimalText(text string) ([]byte, error) {
+	invalid := fmt.Errorf("invalid decimal %q", text)
+	s := text
+
+	var sign uint16 = decimalPositive
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = decimalNegative
+		}
+		s = s[1:]
+	}
+
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return nil, invalid
+		}
+		s = s[:i]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+
+	if whole == "" && frac == "" {
+		return nil, invalid
+	}
+
+	digits := whole + frac
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, invalid
+		}
+	}
+
+	// point is the position of the decimal point in digits.
+	point := len(whole) + exp
+	scale := len(frac) - exp
+	if scale < 0 {
+		scale = 0
+	}
+
+	trimmed := strings.TrimLeft(digits, "0")
+	point -= len(digits) - len(trimmed)
+	digits = strings.TrimRight(trimmed, "0")
+
+	if digits == "" {
+		return decimalWireFormat(nil, 0, decimalPositive, scale), nil
+	}
+
+	// Align the decimal point to a base 10000 digit boundary.
+	pad := ((4-point%4)%4 + 4) % 4
+	digits = strings.Repeat("0", pad) + digits
+	point += pad
+	digits += strings.Repeat("0", (4-len(digits)%4)%4)
+
+	weight := point/4 - 1
+	if weight > 0x7fff || weight < -0x8000 || scale > 0xffff ||
+		len(digits)/4 > 0xffff {
+		return nil, fmt.Errorf("decimal %q is out of range", text)
+	}
+
+	groups := make([]uint16, len(digits)/4)
+	for i := range groups {
+		n, _ := strconv.ParseUint(digits[4*i:4*i+4], 10, 16)
+		groups[i] = uint16(n)
+	}
+
+	return decimalWireFormat(groups, weight, sign, scale), nil
+}
+
+func decimalWireFormat(
+	digits []uint16,
+	weight int,
+	sign uint16,
+	scale int,
+) []byte {
+	data := make([]byte, 8+2*len(digits))
+	binary.BigEndian.PutUint16(data[0:], uint16(len(digits)))
+	binary.BigEndian.PutUint16(data[2:], uint16(int16(weight)))
+	binary.BigEndian.PutUint16(data[4:], sign)
+	binary.BigEndian.PutUint16(data[6:], uint16(scale))
+	for i, digit := range digits {
+		binary.BigEndian.PutUint16(data[8+2*i:], digit)
+	}
+	return data
+}
+
+type optionalDecimalDecoder struct {
+	DecimalCodec
+}
+
+func (c *optionalDecimalDecoder) DecodeMissing(out unsafe.Pointer) {
+	val := reflect.NewAt(c.typ, out)
+	method := val.MethodByName("SetMissing")
+	method.Call([]reflect.Value{trueValue})
+}
+
+func (c *optionalDecimalDecoder) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) error {
+	if err := setPresent(c.typ, out); err != nil {
+		return err
+	}
+
+	return c.DecimalCodec.Decode(r, out)
+}
@@ -0,0 +1,195 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textDecimal stands in for decimal libraries like shopspring/decimal
+// that implement encoding.TextMarshaler and encoding.TextUnmarshaler.
+type textDecimal struct {
+	text string
+}
+
+func (d textDecimal) MarshalText() ([]byte, error) {
+	return []byte(d.text), nil
+}
+
+func (d *textDecimal) UnmarshalText(text []byte) error {
+	d.text = string(text)
+	return nil
+}
+
+type optionalTextDecimal struct {
+	textDecimal
+	missing bool
+}
+
+func (d optionalTextDecimal) Missing() bool { return d.missing }
+
+func (d *optionalTextDecimal) SetMissing(missing bool) {
+	d.missing = missing
+}
+
+func TestDecimalText(t *testing.T) {
+	samples := []struct {
+		text    string
+		decoded string
+		data    []byte
+	}{
+		{"0", "0", []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{"0.00", "0.00", []byte{0, 0, 0, 0, 0, 0, 0, 2}},
+		{"1", "1", []byte{0, 1, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{"-1", "-1", []byte{0, 1, 0, 0, 0x40, 0, 0, 0, 0, 1}},
+		{"+12345", "12345", []byte{
+			0, 2, 0, 1, 0, 0, 0, 0,
+			0, 1, // 1
+			0x09, 0x29, // 2345
+		}},
+		{"10000", "10000", []byte{0, 1, 0, 1, 0, 0, 0, 0, 0, 1}},
+		{"-12.50", "-12.50", []byte{
+			0, 2, 0, 0, 0x40, 0, 0, 2,
+			0, 12, // 12
+			0x13, 0x88, // 5000
+		}},
+		{"0.0001", "0.0001", []byte{0, 1, 0xff, 0xff, 0, 0, 0, 4, 0, 1}},
+		{"0.00001", "0.00001", []byte{
+			0, 1, 0xff, 0xfe, 0, 0, 0, 5,
+			0x03, 0xe8, // 1000
+		}},
+		{".5", "0.5", []byte{
+			0, 1, 0xff, 0xff, 0, 0, 0, 1,
+			0x13, 0x88, // 5000
+		}},
+		{"1.25e-3", "0.00125", []byte{
+			0, 2, 0xff, 0xff, 0, 0, 0, 5,
+			0, 12, // 12
+			0x13, 0x88, // 5000
+		}},
+		{"1.5E+5", "150000", []byte{0, 1, 0, 1, 0, 0, 0, 0, 0, 15}},
+	}
+
+	for _, s := range samples {
+		t.Run(s.text, func(t *testing.T) {
+			data, err := encodeDecimalText(s.text)
+			require.NoError(t, err)
+			assert.Equal(t, s.data, data)
+
+			text, err := decodeDecimalText(buff.SimpleReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, s.decoded, string(text))
+		})
+	}
+
+	for _, text := range []string{"", "-", ".", "1.2.3", "1e", "NaN", "1_0"} {
+		_, err := encodeDecimalText(text)
+		assert.EqualError(t, err, fmt.Sprintf("invalid decimal %q", text))
+	}
+
+	_, err := decodeDecimalText(
+		buff.SimpleReader([]byte{0, 0, 0, 0, 0xc0, 0, 0, 0}))
+	assert.EqualError(t, err, "decimal NaN is not supported")
+}
+
+func TestDecimalCodec(t *testing.T) {
+	desc := descriptor.V2{Type: descriptor.Scalar, ID: DecimalID}
+	data := []byte{
+		0, 2, 0, 0, 0x40, 0, 0, 2,
+		0, 12, // 12
+		0x13, 0x88, // 5000
+	}
+
+	decoder, err := BuildDecoderV2(&desc,
+		reflect.TypeOf(textDecimal{}), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	var result textDecimal
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, "-12.50", result.text)
+
+	decoder, err = BuildDecoderV2(&desc,
+		reflect.TypeOf(optionalTextDecimal{}), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	var optional optionalTextDecimal
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&optional))
+	assert.True(t, optional.missing)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&optional))
+	require.NoError(t, err)
+	assert.Equal(t, optionalTextDecimal{textDecimal{"-12.50"}, false},
+		optional)
+
+	_, err = BuildDecoderV2(&desc,
+		reflect.TypeOf(float64(0)), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "expected out to be string, "+
+		"DecimalUnmarshaler or encoding.TextUnmarshaler got float64")
+
+	decoder, err = BuildDecoderV2(&desc,
+		reflect.TypeOf(""), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	var text string
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&text))
+	require.NoError(t, err)
+	assert.Equal(t, "-12.50", text)
+
+	decoder, err = BuildDecoderV2(&desc,
+		reflect.TypeOf((*interface{})(nil)).Elem(), Path("out"),
+		DecoderOptions{})
+	require.NoError(t, err)
+
+	var any interface{}
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&any))
+	require.NoError(t, err)
+	assert.Equal(t, "-12.50", any)
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, textDecimal{"-12.50"}, Path("args"), true)
+	require.NoError(t, err)
+	err = encoder.Encode(w,
+		optionalTextDecimal{missing: true}, Path("args"), false)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	assert.Equal(t, append(
+		append([]byte{0, 0, 0, 12}, data...),
+		255, 255, 255, 255, // missing decimal
+	), w.Unwrap()[5:])
+
+	err = encoder.Encode(w, textDecimal{"twelve"}, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode codecs.textDecimal at args: "+
+		`invalid decimal "twelve"`)
+
+	err = encoder.Encode(w, 12.5, Path("args"), true)
+	assert.EqualError(t, err, "expected args to be DecimalMarshaler "+
+		"or encoding.TextMarshaler got float64")
+}
@@ -0,0 +1,430 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// typeNameKey is the name of the implicit field that holds an object's type
+// name if the query was compiled with implicit type names. Dynamically
+// decoded objects store their type name under it.
+const typeNameKey = "__tname__"
+
+var (
+	anyType      = reflect.TypeOf((*interface{})(nil)).Elem()
+	anyMapType   = reflect.TypeOf(map[string]interface{}(nil))
+	anySliceType = reflect.TypeOf([]interface{}(nil))
+
+	// naturalTypes are the types that scalars are decoded into
+	// when the out type is interface{}.
+	naturalTypes = map[types.UUID]reflect.Type{
+		UUIDID:             uuidType,
+		StrID:              strType,
+		BytesID:            bytesType,
+		Int16ID:            int16Type,
+		Int32ID:            int32Type,
+		Int64ID:            int64Type,
+		Float32ID:          float32Type,
+		Float64ID:          float64Type,
+		BoolID:             boolType,
+		DateTimeID:         dateTimeType,
+		LocalDTID:          localDateTimeType,
+		LocalDateID:        localDateType,
+		LocalTimeID:        localTimeType,
+		DurationID:         durationType,
+		JSONID:             anyType,
+		BigIntID:           bigIntType,
+		DecimalID:          strType,
+		RelativeDurationID: relativeDurationType,
+		DateDurationID:     dateDurationType,
+		MemoryID:           memoryType,
+	}
+
+	// naturalRangeTypes are the types that ranges are decoded into
+	// when the out type is interface{}.
+	naturalRangeTypes = map[types.UUID]reflect.Type{
+		Int32ID:     rangeInt32Type,
+		Int64ID:     rangeInt64Type,
+		Float32ID:   rangeFloat32Type,
+		Float64ID:   rangeFloat64Type,
+		DateTimeID:  rangeDateTimeType,
+		LocalDTID:   rangeLocalDateTimeType,
+		LocalDateID: rangeLocalDateType,
+	}
+)
+
+// buildDynamicDecoder builds decoders for the dynamic out types interface{},
+// map[string]interface{} and []interface{}. Named map types with the same
+// underlying type are decoded like map[string]interface{}. Objects and
+// named tuples are decoded into maps, sets, arrays and tuples into slices
+// and scalars into their natural go type. ok is false if typ is not a
+// dynamic type for desc.
+func buildDynamicDecoder(
+	desc descriptor.Descriptor,
+	typ reflect.Type,
+	path Path,
+	opts DecoderOptions,
+) (Decoder, bool, error) {
+	switch {
+	case typ == anyType:
+		natural, err := naturalType(desc, path)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if natural == anyType {
+			// json is already decoded into interface{} values
+			return nil, false, nil
+		}
+
+		child, err := BuildDecoder(desc, natural, path, opts)
+		if err != nil {
+			return nil, true, err
+		}
+
+		return &anyDecoder{natural, child}, true, nil
+	case isAnyMap(typ):
+		if desc.Type != descriptor.Object &&
+			desc.Type != descriptor.NamedTuple {
+			return nil, false, nil
+		}
+
+		fields := make([]*dynamicField, len(desc.Fields))
+		for i, field := range desc.Fields {
+			child, err := BuildDecoder(
+				field.Desc, anyType, path.AddField(field.Name), opts)
+			if err != nil {
+				return nil, true, err
+			}
+
+			fields[i] = &dynamicField{field.Name, field.Implicit, child}
+		}
+
+		return &mapDecoder{id: desc.ID, fields: fields}, true, nil
+	case typ == anySliceType:
+		if desc.Type != descriptor.Tuple {
+			return nil, false, nil
+		}
+
+		fields := make([]*dynamicField, len(desc.Fields))
+		for i, field := range desc.Fields {
+			child, err := BuildDecoder(
+				field.Desc, anyType, path.AddIndex(i), opts)
+			if err != nil {
+				return nil, true, err
+			}
+
+			fields[i] = &dynamicField{field.Name, false, child}
+		}
+
+		return &sliceTupleDecoder{desc.ID, fields}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// buildDynamicDecoderV2 builds decoders for the dynamic out types
+// interface{}, map[string]interface{} and []interface{}. Named map types
+// with the same underlying type are decoded like map[string]interface{}.
+// Objects and named tuples are decoded into maps, sets, arrays and tuples
+// into slices and scalars into their natural go type. ok is false if typ is
+// not a dynamic type for desc.
+func buildDynamicDecoderV2(
+	desc *descriptor.V2,
+	typ reflect.Type,
+	path Path,
+	opts DecoderOptions,
+) (Decoder, bool, error) {
+	named := desc.Type == descriptor.NamedTuple ||
+		desc.Type == descriptor.Tuple &&
+			len(desc.Fields) > 0 && desc.Fields[0].Name != "0"
+
+	switch {
+	case typ == anyType:
+		natural, err := naturalTypeV2(desc, named, path)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if natural == anyType {
+			// json is already decoded into interface{} values
+			return nil, false, nil
+		}
+
+		child, err := BuildDecoderV2(desc, natural, path, opts)
+		if err != nil {
+			return nil, true, err
+		}
+
+		return &anyDecoder{natural, child}, true, nil
+	case isAnyMap(typ):
+		if desc.Type != descriptor.Object && !named {
+			return nil, false, nil
+		}
+
+		fields := make([]*dynamicField, len(desc.Fields))
+		for i, field := range desc.Fields {
+			child, err := BuildDecoderV2(
+				&field.Desc, anyType, path.AddField(field.Name), opts)
+			if err != nil {
+				return nil, true, err
+			}
+
+			fields[i] = &dynamicField{field.Name, field.Implicit, child}
+		}
+
+		decoder := mapDecoder{id: desc.ID, fields: fields}
+		if desc.Type == descriptor.Object {
+			decoder.typeName = desc.Name
+		}
+
+		return &decoder, true, nil
+	case typ == anySliceType:
+		if desc.Type != descriptor.Tuple || named {
+			return nil, false, nil
+		}
+
+		fields := make([]*dynamicField, len(desc.Fields))
+		for i, field := range desc.Fields {
+			child, err := BuildDecoderV2(
+				&field.Desc, anyType, path.AddIndex(i), opts)
+			if err != nil {
+				return nil, true, err
+			}
+
+			fields[i] = &dynamicField{field.Name, false, child}
+		}
+
+		return &sliceTupleDecoder{desc.ID, fields}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// isAnyMap returns true if typ is map[string]interface{} or a named type
+// like `type Row map[string]interface{}`.
+func isAnyMap(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Map &&
+		typ.Key() == strType &&
+		typ.Elem() == anyType
+}
+
+func naturalType(desc descriptor.Descriptor, path Path) (reflect.Type, error) {
+	switch desc.Type {
+	case descriptor.Object, descriptor.NamedTuple:
+		return anyMapType, nil
+	case descriptor.Set, descriptor.Array, descriptor.Tuple:
+		return anySliceType, nil
+	case descriptor.Enum:
+		return strType, nil
+	case descriptor.Range:
+		elm := GetScalarDescriptor(desc.Fields[0].Desc)
+		if typ, ok := naturalRangeTypes[elm.ID]; ok {
+			return typ, nil
+		}
+	case descriptor.BaseScalar, descriptor.Scalar:
+		if typ, ok := naturalTypes[GetScalarDescriptor(desc).ID]; ok {
+			return typ, nil
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"%v can not be decoded into interface{}: unsupported type %v",
+		path, desc.ID)
+}
+
+func naturalTypeV2(
+	desc *descriptor.V2,
+	named bool,
+	path Path,
+) (reflect.Type, error) {
+	switch desc.Type {
+	case descriptor.Object:
+		return anyMapType, nil
+	case descriptor.NamedTuple, descriptor.Tuple:
+		if named {
+			return anyMapType, nil
+		}
+		return anySliceType, nil
+	case descriptor.Set, descriptor.Array:
+		return anySliceType, nil
+	case descriptor.Enum:
+		return strType, nil
+	case descriptor.Range:
+		elm := GetScalarDescriptorV2(&desc.Fields[0].Desc)
+		if typ, ok := naturalRangeTypes[elm.ID]; ok {
+			return typ, nil
+		}
+	case descriptor.BaseScalar, descriptor.Scalar:
+		base := GetScalarDescriptorV2(desc)
+		if isGeometry(base) {
+			return bytesType, nil
+		}
+
+		if isVector(base) {
+			return naturalVectorType(base), nil
+		}
+
+		if typ, ok := naturalTypes[base.ID]; ok {
+			return typ, nil
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"%v can not be decoded into interface{}: unsupported type %v %q",
+		path, desc.ID, desc.Name)
+}
+
+// anyDecoder decodes a value into its natural go type
+// and stores it in an interface{}.
+type anyDecoder struct {
+	typ     reflect.Type
+	decoder Decoder
+}
+
+func (c *anyDecoder) DescriptorID() types.UUID {
+	return c.decoder.DescriptorID()
+}
+
+func (c *anyDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	val := reflect.New(c.typ)
+	err := c.decoder.Decode(r, unsafe.Pointer(val.Pointer()))
+	if err != nil {
+		return err
+	}
+
+	*(*interface{})(out) = val.Elem().Interface()
+	return nil
+}
+
+func (c *anyDecoder) DecodeMissing(out unsafe.Pointer) {
+	*(*interface{})(out) = nil
+}
+
+type dynamicField struct {
+	name     string
+	implicit bool
+	decoder  Decoder
+}
+
+// mapDecoder decodes objects and named tuples
+// into map[string]interface{} values.
+// Implicit object fields are not included in the map.
+type mapDecoder struct {
+	id       types.UUID
+	typeName string
+	fields   []*dynamicField
+}
+
+func (c *mapDecoder) DescriptorID() types.UUID { return c.id }
+
+func (c *mapDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	elmCount := int(int32(r.PopUint32()))
+	if elmCount != len(c.fields) {
+		return fmt.Errorf(
+			"wrong number of elements: expected %v, got %v",
+			len(c.fields), elmCount)
+	}
+
+	result := make(map[string]interface{}, len(c.fields)+1)
+	if c.typeName != "" {
+		result[typeNameKey] = c.typeName
+	}
+
+	for _, field := range c.fields {
+		r.Discard(4) // reserved
+
+		elmLen := r.PopUint32()
+		if elmLen == 0xffffffff {
+			// element length -1 means missing field
+			if !field.implicit {
+				result[field.name] = nil
+			}
+			continue
+		}
+
+		data := r.PopSlice(elmLen)
+		if field.implicit {
+			continue
+		}
+
+		var val interface{}
+		err := field.decoder.Decode(data, unsafe.Pointer(&val))
+		if err != nil {
+			return wrapField(err, field.name)
+		}
+
+		result[field.name] = val
+	}
+
+	*(*map[string]interface{})(out) = result
+	return nil
+}
+
+func (c *mapDecoder) DecodeMissing(out unsafe.Pointer) {
+	*(*map[string]interface{})(out) = nil
+}
+
+// sliceTupleDecoder decodes tuples into []interface{} values.
+type sliceTupleDecoder struct {
+	id     types.UUID
+	fields []*dynamicField
+}
+
+func (c *sliceTupleDecoder) DescriptorID() types.UUID { return c.id }
+
+func (c *sliceTupleDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	elmCount := int(int32(r.PopUint32()))
+	if elmCount != len(c.fields) {
+		return fmt.Errorf(
+			"wrong number of elements, expected %v got %v",
+			len(c.fields), elmCount)
+	}
+
+	result := make([]interface{}, len(c.fields))
+	for i, field := range c.fields {
+		r.Discard(4) // reserved
+
+		elmLen := r.PopUint32()
+		if elmLen == 0xffffffff {
+			continue
+		}
+
+		err := field.decoder.Decode(
+			r.PopSlice(elmLen),
+			unsafe.Pointer(&result[i]),
+		)
+		if err != nil {
+			return wrapField(err, strconv.Itoa(i))
+		}
+	}
+
+	*(*[]interface{})(out) = result
+	return nil
+}
+
+func (c *sliceTupleDecoder) DecodeMissing(out unsafe.Pointer) {
+	*(*[]interface{})(out) = nil
+}
@@ -0,0 +1,209 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeObjectIntoMap(t *testing.T) {
+	str := descriptor.V2{Type: descriptor.Scalar, ID: StrID, Name: "std::str"}
+	i64 := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   Int64ID,
+		Name: "std::int64",
+	}
+	uuid := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   UUIDID,
+		Name: "std::uuid",
+	}
+	tuple := descriptor.V2{
+		Type: descriptor.Tuple,
+		ID:   types.UUID{2},
+		Fields: []*descriptor.FieldV2{
+			{Name: "0", Desc: i64},
+			{Name: "1", Desc: str},
+		},
+	}
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Name: "default::User",
+		Fields: []*descriptor.FieldV2{
+			{Name: "id", Desc: uuid, Required: true, Implicit: true},
+			{Name: "name", Desc: str, Required: true},
+			{Name: "nick", Desc: str},
+			{Name: "pair", Desc: tuple, Required: true},
+		},
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, anyMapType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	data := []byte{
+		0, 0, 0, 4, // element count
+		// id
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 16, // data length
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		// name
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 3, // data length
+		98, 111, 98,
+		// nick
+		0, 0, 0, 0, // reserved
+		255, 255, 255, 255, // missing
+		// pair
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 29, // data length
+		0, 0, 0, 2, // element count
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 8, // data length
+		0, 0, 0, 0, 0, 0, 0, 7,
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 1, // data length
+		120,
+	}
+
+	var result map[string]interface{}
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"__tname__": "default::User",
+		"name":      "bob",
+		"nick":      nil,
+		"pair":      []interface{}{int64(7), "x"},
+	}, result)
+}
+
+func TestDecodeNamedTupleIntoMap(t *testing.T) {
+	data := []byte{
+		0, 0, 0, 2, // element count
+		// a
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 8, // data length
+		0, 0, 0, 0, 0, 0, 0, 7,
+		// b
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 1, // data length
+		120,
+	}
+	expected := map[string]interface{}{"a": int64(7), "b": "x"}
+
+	desc := descriptor.Descriptor{
+		Type: descriptor.NamedTuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.Field{
+			{Name: "a", Desc: descriptor.Descriptor{
+				Type: descriptor.BaseScalar,
+				ID:   Int64ID,
+			}},
+			{Name: "b", Desc: descriptor.Descriptor{
+				Type: descriptor.BaseScalar,
+				ID:   StrID,
+			}},
+		},
+	}
+
+	decoder, err := BuildDecoder(
+		desc, anyMapType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// Protocol version 2.0 describes named tuples
+	// as tuples with named elements.
+	descV2 := descriptor.V2{
+		Type: descriptor.Tuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "a", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   Int64ID,
+				Name: "std::int64",
+			}},
+			{Name: "b", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   StrID,
+				Name: "std::str",
+			}},
+		},
+	}
+
+	decoder, err = BuildDecoderV2(
+		&descV2, anyMapType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	result = nil
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&result))
+	assert.Nil(t, result)
+}
+
+func TestDecodeNamedTupleIntoNamedMap(t *testing.T) {
+	type row map[string]interface{}
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		// a
+		0, 0, 0, 0, // reserved
+		0, 0, 0, 8, // data length
+		0, 0, 0, 0, 0, 0, 0, 7,
+	}
+
+	desc := descriptor.V2{
+		Type: descriptor.NamedTuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "a", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   Int64ID,
+				Name: "std::int64",
+			}},
+		},
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(row{}), Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result row
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, row{"a": int64(7)}, result)
+}
@@ -0,0 +1,173 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/sebastiean/edgedb-go/internal/marshal"
+)
+
+var (
+	enumUnmarshalerType = getType((*marshal.EnumUnmarshaler)(nil))
+	strUnmarshalerType  = getType((*marshal.StrUnmarshaler)(nil))
+)
+
+// buildEnumDecoder builds a decoder for an enum. Enums can be decoded into
+// string, edgedb.OptionalStr, user defined string types like
+// `type Color string` and types implementing EnumUnmarshaler or
+// StrUnmarshaler. Values are checked against the enum's members.
+func buildEnumDecoder(
+	id types.UUID,
+	members []string,
+	typ reflect.Type,
+	path Path,
+	serverType string,
+) (Decoder, error) {
+	var decoder Decoder
+	ptr := reflect.PtrTo(typ)
+
+	switch {
+	case ptr.Implements(enumUnmarshalerType):
+		decoder = buildMethodDecoder(id, typ, "UnmarshalEdgeDBEnum")
+	case ptr.Implements(strUnmarshalerType):
+		decoder = buildMethodDecoder(id, typ, "UnmarshalEdgeDBStr")
+	case typ == optionalStrType:
+		decoder = &optionalStrDecoder{StrID}
+	case typ.Kind() == reflect.String:
+		// Named string types share string's memory layout.
+		decoder = &StrCodec{id}
+	default:
+		return nil, fmt.Errorf("expected %v to be string, "+
+			"edgedb.OptionalStr or EnumUnmarshaler got %v%v",
+			path, typ, serverType)
+	}
+
+	if len(members) == 0 {
+		return decoder, nil
+	}
+
+	enum := enumDecoder{decoder, members, path}
+	if optional, ok := decoder.(OptionalDecoder); ok {
+		return &optionalEnumDecoder{enum, optional}, nil
+	}
+
+	return &enum, nil
+}
+
+func buildMethodDecoder(
+	id types.UUID,
+	typ reflect.Type,
+	methodName string,
+) Decoder {
+	decoder := unmarshalerDecoder{id, typ, methodName}
+	if reflect.PtrTo(typ).Implements(optionalUnmarshalerType) {
+		return &optionalUnmarshalerDecoder{decoder}
+	}
+
+	return &decoder
+}
+
+// enumDecoder rejects values that are not members of the enum
+// before decoding them.
+type enumDecoder struct {
+	Decoder
+	members []string
+	path    Path
+}
+
+func (c *enumDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	if !c.isMember(r.Buf) {
+		return fmt.Errorf("decoding %v: %q is not one of %v",
+			c.path, r.Buf, strings.Join(c.members, ", "))
+	}
+
+	return c.Decoder.Decode(r, out)
+}
+
+func (c *enumDecoder) isMember(data []byte) bool {
+	for _, member := range c.members {
+		if member == string(data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type optionalEnumDecoder struct {
+	enumDecoder
+	optional OptionalDecoder
+}
+
+func (c *optionalEnumDecoder) DecodeMissing(out unsafe.Pointer) {
+	c.optional.DecodeMissing(out)
+}
+
+type optionalEnumMarshaler interface {
+	marshal.EnumMarshaler
+	marshal.OptionalMarshaler
+}
+
+// enumEncoder encodes enum arguments. In addition to the types accepted by
+// StrCodec it accepts EnumMarshaler and user defined string types.
+type enumEncoder struct {
+	StrCodec
+}
+
+func (c *enumEncoder) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	switch in := val.(type) {
+	case optionalEnumMarshaler:
+		return encodeOptional(w, in.Missing(), required,
+			func() error { return c.encodeEnumMarshaler(w, in, path) },
+			func() error { return missingValueError(in, path) })
+	case marshal.EnumMarshaler:
+		return c.encodeEnumMarshaler(w, in, path)
+	case string, types.OptionalStr, marshal.StrMarshaler:
+		return c.StrCodec.Encode(w, val, path, required)
+	}
+
+	if in := reflect.ValueOf(val); in.Kind() == reflect.String {
+		return c.encodeData(w, in.String(), path)
+	}
+
+	return fmt.Errorf("expected %v to be string, edgedb.OptionalStr, "+
+		"EnumMarshaler or StrMarshaler got %T", path, val)
+}
+
+func (c *enumEncoder) encodeEnumMarshaler(
+	w *buff.Writer,
+	val marshal.EnumMarshaler,
+	path Path,
+) error {
+	data, err := val.MarshalEdgeDBEnum()
+	if err != nil {
+		return err
+	}
+
+	return c.encodeData(w, string(data), path)
+}
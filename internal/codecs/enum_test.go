@@ -0,0 +1,155 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type color string
+
+type enumColor struct {
+	name string
+}
+
+func (c enumColor) MarshalEdgeDBEnum() ([]byte, error) {
+	return []byte(c.name), nil
+}
+
+func (c *enumColor) UnmarshalEdgeDBEnum(data []byte) error {
+	c.name = string(data)
+	return nil
+}
+
+type optionalEnumColor struct {
+	enumColor
+	missing bool
+}
+
+func (c optionalEnumColor) Missing() bool { return c.missing }
+
+func (c *optionalEnumColor) SetMissing(missing bool) { c.missing = missing }
+
+func TestEnumDecoder(t *testing.T) {
+	desc := descriptor.V2{
+		Type:        descriptor.Enum,
+		ID:          types.UUID{1},
+		Name:        "default::Color",
+		EnumMembers: []string{"Red", "Green", "Blue"},
+	}
+
+	build := func(typ reflect.Type) Decoder {
+		decoder, err := BuildDecoderV2(
+			&desc, typ, Path("out"), DecoderOptions{})
+		require.NoError(t, err)
+		return decoder
+	}
+
+	var str string
+	decoder := build(reflect.TypeOf(str))
+	err := decoder.Decode(
+		buff.SimpleReader([]byte("Red")), unsafe.Pointer(&str))
+	require.NoError(t, err)
+	assert.Equal(t, "Red", str)
+
+	var named color
+	decoder = build(reflect.TypeOf(named))
+	err = decoder.Decode(
+		buff.SimpleReader([]byte("Green")), unsafe.Pointer(&named))
+	require.NoError(t, err)
+	assert.Equal(t, color("Green"), named)
+
+	err = decoder.Decode(
+		buff.SimpleReader([]byte("Purple")), unsafe.Pointer(&named))
+	assert.EqualError(t, err,
+		`decoding out: "Purple" is not one of Red, Green, Blue`)
+
+	var custom enumColor
+	decoder = build(reflect.TypeOf(custom))
+	err = decoder.Decode(
+		buff.SimpleReader([]byte("Blue")), unsafe.Pointer(&custom))
+	require.NoError(t, err)
+	assert.Equal(t, "Blue", custom.name)
+
+	var optional optionalEnumColor
+	decoder = build(reflect.TypeOf(optional))
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&optional))
+	assert.True(t, optional.missing)
+	err = decoder.Decode(
+		buff.SimpleReader([]byte("Red")), unsafe.Pointer(&optional))
+	require.NoError(t, err)
+	assert.Equal(t, optionalEnumColor{enumColor{"Red"}, false}, optional)
+
+	var optionalStr types.OptionalStr
+	decoder = build(reflect.TypeOf(optionalStr))
+	err = decoder.Decode(
+		buff.SimpleReader([]byte("Red")), unsafe.Pointer(&optionalStr))
+	require.NoError(t, err)
+	assert.Equal(t, types.NewOptionalStr("Red"), optionalStr)
+
+	_, err = BuildDecoderV2(
+		&desc, reflect.TypeOf(0), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "expected out to be string, "+
+		"edgedb.OptionalStr or EnumUnmarshaler got int "+
+		"(server type default::Color)")
+}
+
+func TestEnumEncoder(t *testing.T) {
+	desc := descriptor.V2{
+		Type:        descriptor.Enum,
+		ID:          types.UUID{1},
+		Name:        "default::Color",
+		EnumMembers: []string{"Red", "Green", "Blue"},
+	}
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	require.NoError(t, encoder.Encode(w, "Red", Path("args"), true))
+	require.NoError(t, encoder.Encode(w, color("Red"), Path("args"), true))
+	require.NoError(t,
+		encoder.Encode(w, enumColor{"Red"}, Path("args"), true))
+	require.NoError(t, encoder.Encode(w,
+		optionalEnumColor{missing: true}, Path("args"), false))
+	w.EndMessage()
+
+	red := []byte{0, 0, 0, 3, 'R', 'e', 'd'}
+	expected := append(append(append(red, red...), red...),
+		255, 255, 255, 255, // missing enum
+	)
+	assert.Equal(t, expected, w.Unwrap()[5:])
+
+	err = encoder.Encode(w,
+		optionalEnumColor{missing: true}, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode codecs.optionalEnumColor "+
+		"at args because its value is missing")
+
+	err = encoder.Encode(w, 1, Path("args"), true)
+	assert.EqualError(t, err, "expected args to be string, "+
+		"edgedb.OptionalStr, EnumMarshaler or StrMarshaler got int")
+}
@@ -0,0 +1,132 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+var (
+	scannerType = getType((*sql.Scanner)(nil))
+
+	// geometryTypeNames are the postgis types
+	// that are sent over the wire as EWKB.
+	geometryTypeNames = map[string]bool{
+		"ext::postgis::geometry":  true,
+		"ext::postgis::geography": true,
+	}
+)
+
+func isGeometry(desc *descriptor.V2) bool {
+	return geometryTypeNames[desc.Name]
+}
+
+// buildGeometryDecoder builds a decoder for postgis geometry values. Values
+// can be decoded into []byte, OptionalBytes or any type implementing
+// sql.Scanner. Scanners receive the value's EWKB encoding as a []byte which
+// is what geometry libraries like github.com/paulmach/orb and
+// github.com/twpayne/go-geom expect when scanning postgis columns.
+func buildGeometryDecoder(
+	desc *descriptor.V2,
+	typ reflect.Type,
+	path Path,
+) (Decoder, error) {
+	switch {
+	case typ == bytesType:
+		return &BytesCodec{desc.ID}, nil
+	case typ == optionalBytesType:
+		return &optionalBytesDecoder{desc.ID}, nil
+	case reflect.PtrTo(typ).Implements(scannerType):
+		return &scannerDecoder{desc.ID, typ}, nil
+	default:
+		return nil, fmt.Errorf(
+			"expected %v to be []byte, edgedb.OptionalBytes "+
+				"or to implement sql.Scanner got %v%v",
+			path, typ, serverTypeV2(desc))
+	}
+}
+
+type scannerDecoder struct {
+	id  types.UUID
+	typ reflect.Type
+}
+
+func (c *scannerDecoder) DescriptorID() types.UUID { return c.id }
+
+func (c *scannerDecoder) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) (err error) {
+	defer recoverUnmarshaler(&err)
+	data := make([]byte, len(r.Buf))
+	copy(data, r.Buf)
+	r.Discard(len(r.Buf))
+
+	scanner := reflect.NewAt(c.typ, out).Interface().(sql.Scanner)
+	return scanner.Scan(data)
+}
+
+func (c *scannerDecoder) DecodeMissing(out unsafe.Pointer) {
+	// Scanners represent missing values as nil like sql NULL.
+	// DecodeMissing can not fail so an error from Scan is ignored.
+	scanner := reflect.NewAt(c.typ, out).Interface().(sql.Scanner)
+	_ = scanner.Scan(nil)
+}
+
+// geometryEncoder encodes postgis geometry values. In addition to the values
+// BytesCodec accepts, it accepts driver.Valuer values that return the
+// geometry's EWKB encoding as a []byte or nil for a missing value.
+type geometryEncoder struct {
+	BytesCodec
+}
+
+func (c *geometryEncoder) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	in, ok := val.(driver.Valuer)
+	if !ok {
+		return c.BytesCodec.Encode(w, val, path, required)
+	}
+
+	v, err := in.Value()
+	if err != nil {
+		return err
+	}
+
+	switch data := v.(type) {
+	case nil:
+		return encodeOptional(w, true, required, nil,
+			func() error { return missingValueError(val, path) })
+	case []byte:
+		return c.encodeData(w, data, path)
+	default:
+		return fmt.Errorf(
+			"expected %v.Value() at %v to return []byte got %T",
+			reflect.TypeOf(val), path, v)
+	}
+}
@@ -0,0 +1,91 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wkbGeometry struct {
+	data  []byte
+	valid bool
+}
+
+func (g *wkbGeometry) Scan(src interface{}) error {
+	g.data, g.valid = src.([]byte)
+	return nil
+}
+
+func (g wkbGeometry) Value() (driver.Value, error) {
+	if !g.valid {
+		return nil, nil
+	}
+
+	return g.data, nil
+}
+
+func TestGeometryScanner(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   types.UUID{1},
+		Name: "ext::postgis::geometry",
+	}
+	data := []byte{1, 1, 0, 0, 0}
+
+	var result wkbGeometry
+	decoder, err := BuildDecoderV2(
+		&desc, reflect.TypeOf(result), Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, wkbGeometry{data, true}, result)
+
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&result))
+	assert.Equal(t, wkbGeometry{}, result)
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, wkbGeometry{data, true}, Path("args"), true)
+	require.NoError(t, err)
+	err = encoder.Encode(w, wkbGeometry{}, Path("args"), false)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	assert.Equal(t, []byte{
+		0, 0, 0, 5, 1, 1, 0, 0, 0, // encoded geometry
+		255, 255, 255, 255, // missing geometry
+	}, w.Unwrap()[5:])
+
+	err = encoder.Encode(w, wkbGeometry{}, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode codecs.wkbGeometry at args "+
+		"because its value is missing")
+}
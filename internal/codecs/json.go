@@ -43,8 +43,7 @@ func (c *JSONCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
 	}
 
 	if c.typ != bytesType {
-		ptr := reflect.NewAt(c.typ, out).Interface()
-		return json.Unmarshal(r.Buf, ptr)
+		return unmarshalJSON(r.Buf, reflect.NewAt(c.typ, out).Interface())
 	}
 
 	n := len(r.Buf)
@@ -74,11 +73,11 @@ func (c *JSONCodec) Encode(
 ) error {
 	switch in := val.(type) {
 	case []byte:
-		return c.encodeData(w, in)
+		return c.encodeData(w, in, path)
 	case types.OptionalBytes:
 		data, ok := in.Get()
 		return encodeOptional(w, !ok, required,
-			func() error { return c.encodeData(w, data) },
+			func() error { return c.encodeData(w, data, path) },
 			func() error {
 				return missingValueError("edgedb.OptionalBytes", path)
 			})
@@ -89,12 +88,63 @@ func (c *JSONCodec) Encode(
 	case marshal.JSONMarshaler:
 		return c.encodeMarshaler(w, in, path)
 	default:
-		return fmt.Errorf("expected %v to be []byte, edgedb.OptionalBytes or "+
-			"JSONMarshaler got %T", path, val)
+		if isJSONValue(val) {
+			return c.encodeValue(w, val, path, required)
+		}
+
+		return fmt.Errorf("expected %v to be []byte, edgedb.OptionalBytes, "+
+			"JSONMarshaler, a map or a struct got %T", path, val)
+	}
+}
+
+// isJSONValue returns true if val is a map, a struct or a pointer to a struct
+// that is encoded with encoding/json.
+func isJSONValue(val interface{}) bool {
+	typ := reflect.TypeOf(val)
+	if typ == nil {
+		return false
 	}
+
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return typ.Kind() == reflect.Map || typ.Kind() == reflect.Struct
 }
 
-func (c *JSONCodec) encodeData(w *buff.Writer, data []byte) error {
+// encodeValue encodes val using encoding/json.
+// A nil map or pointer is a missing value.
+func (c *JSONCodec) encodeValue(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	in := reflect.ValueOf(val)
+	missing := (in.Kind() == reflect.Map || in.Kind() == reflect.Ptr) &&
+		in.IsNil()
+
+	return encodeOptional(w, missing, required,
+		func() error {
+			data, err := json.Marshal(val)
+			if err != nil {
+				return fmt.Errorf("cannot encode %v: %w", path, err)
+			}
+
+			return c.encodeData(w, data, path)
+		},
+		func() error { return missingValueError(val, path) })
+}
+
+func (c *JSONCodec) encodeData(
+	w *buff.Writer,
+	data []byte,
+	path Path,
+) error {
+	if err := checkEncodedLength(path, 1+len(data)); err != nil {
+		return err
+	}
+
 	// data length
 	w.PushUint32(uint32(1 + len(data)))
 
@@ -109,12 +159,15 @@ func (c *JSONCodec) encodeData(w *buff.Writer, data []byte) error {
 func (c *JSONCodec) encodeMarshaler(
 	w *buff.Writer,
 	val marshal.JSONMarshaler,
-	_ Path,
+	path Path,
 ) error {
 	data, err := val.MarshalEdgeDBJSON()
 	if err != nil {
 		return err
 	}
+	if err := checkEncodedLength(path, len(data)); err != nil {
+		return err
+	}
 	w.PushUint32(uint32(len(data)))
 	w.PushBytes(data)
 	return nil
@@ -122,6 +175,13 @@ func (c *JSONCodec) encodeMarshaler(
 
 type baseJSONDecoder struct{}
 
+// unmarshalJSON unmarshals data into ptr. A panic in a user implemented
+// json.Unmarshaler is returned as an error.
+func unmarshalJSON(data []byte, ptr interface{}) (err error) {
+	defer recoverUnmarshaler(&err)
+	return json.Unmarshal(data, ptr)
+}
+
 func popJSONFormat(r *buff.Reader) error {
 	format := r.PopUint8()
 	if format != 1 {
@@ -148,7 +208,7 @@ func (c *optionalNilableJSONDecoder) Decode(
 	}
 
 	ptr := reflect.NewAt(c.typ, out).Interface()
-	return json.Unmarshal(r.Buf, ptr)
+	return unmarshalJSON(r.Buf, ptr)
 }
 
 func (c *optionalNilableJSONDecoder) DecodeMissing(out unsafe.Pointer) {
@@ -171,9 +231,11 @@ func (c *optionalUnmarshalerJSONDecoder) Decode(
 		return e
 	}
 
-	ptr := reflect.NewAt(c.typ, out).Interface()
-	ptr.(marshal.OptionalUnmarshaler).SetMissing(false)
-	return json.Unmarshal(r.Buf, ptr)
+	if err := setPresent(c.typ, out); err != nil {
+		return err
+	}
+
+	return unmarshalJSON(r.Buf, reflect.NewAt(c.typ, out).Interface())
 }
 
 func (c *optionalUnmarshalerJSONDecoder) DecodeMissing(out unsafe.Pointer) {
@@ -195,7 +257,7 @@ func (c *optionalScalarUnmarshalerJSONDecoder) Decode(
 	}
 
 	ptr := reflect.NewAt(c.typ, out).Interface()
-	return json.Unmarshal(r.Buf, ptr)
+	return unmarshalJSON(r.Buf, ptr)
 }
 
 func (c *optionalScalarUnmarshalerJSONDecoder) DecodeMissing(
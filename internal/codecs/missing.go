@@ -0,0 +1,62 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// pointerDecoder decodes values into a newly allocated typ
+// and stores a pointer to it. Missing values are decoded as nil.
+type pointerDecoder struct {
+	typ     reflect.Type
+	decoder Decoder
+}
+
+func (c *pointerDecoder) DescriptorID() types.UUID {
+	return c.decoder.DescriptorID()
+}
+
+func (c *pointerDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	val := reflect.New(c.typ)
+	err := c.decoder.Decode(r, unsafe.Pointer(val.Pointer()))
+	if err != nil {
+		return err
+	}
+
+	*(*unsafe.Pointer)(out) = unsafe.Pointer(val.Pointer())
+	return nil
+}
+
+func (c *pointerDecoder) DecodeMissing(out unsafe.Pointer) {
+	*(*unsafe.Pointer)(out) = nil
+}
+
+// zeroMissingDecoder decodes missing values as the zero value of typ.
+type zeroMissingDecoder struct {
+	Decoder
+	typ reflect.Type
+}
+
+func (c *zeroMissingDecoder) DecodeMissing(out unsafe.Pointer) {
+	val := reflect.NewAt(c.typ, out).Elem()
+	val.Set(reflect.Zero(c.typ))
+}
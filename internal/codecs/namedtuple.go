@@ -49,6 +49,29 @@ func buildNamedTupleEncoder(
 	return &namedTupleEncoder{desc.ID, fields}, nil
 }
 
+func buildNamedTupleEncoderV2(
+	desc *descriptor.V2,
+	version internal.ProtocolVersion,
+) (Encoder, error) {
+	fields := make([]*EncoderField, len(desc.Fields))
+
+	for i, field := range desc.Fields {
+		encoder, err := BuildEncoderV2(&field.Desc, version)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[i] = &EncoderField{
+			name:    field.Name,
+			encoder: encoder,
+		}
+	}
+
+	return &namedTupleEncoder{desc.ID, fields}, nil
+}
+
+// namedTupleEncoder encodes maps with string keys as named tuples. Each
+// element is encoded from the map value with the element's name as key.
 type namedTupleEncoder struct {
 	id     types.UUID
 	fields []*EncoderField
@@ -60,37 +83,50 @@ func (c *namedTupleEncoder) Encode(
 	w *buff.Writer,
 	val interface{},
 	path Path,
-	_ bool,
+	required bool,
 ) error {
-	args, ok := val.([]interface{})
-	if !ok {
-		return fmt.Errorf("expected %v to be []interface{} got %T", path, val)
-	}
-
-	elmCount := len(c.fields)
+	if args, ok := val.([]interface{}); ok {
+		// Before protocol 0.12 named query arguments are a named tuple.
+		if len(args) != 1 {
+			return fmt.Errorf(
+				"wrong number of arguments, expected 1 got: %v", len(args),
+			)
+		}
 
-	w.BeginBytes()
-	w.PushUint32(uint32(elmCount))
+		val = args[0]
+		required = true
+	}
 
-	if len(args) != 1 {
+	in := reflect.ValueOf(val)
+	if in.Kind() != reflect.Map || in.Type().Key().Kind() != reflect.String {
 		return fmt.Errorf(
-			"wrong number of arguments, expected 1 got: %v", len(args),
+			"expected %v to be a map with string keys got %T", path, val,
 		)
 	}
 
-	in, ok := args[0].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf(
-			"expected %v to be map[string]interface{} got %T", path, args[0],
-		)
+	if in.IsNil() {
+		return encodeOptional(w, true, required, nil,
+			func() error { return missingValueError(val, path) })
 	}
 
+	elmCount := len(c.fields)
+
+	w.BeginBytes()
+	w.PushUint32(uint32(elmCount))
+
+	keyType := in.Type().Key()
 	var err error
 	for _, field := range c.fields {
+		var elm interface{}
+		key := reflect.ValueOf(field.name).Convert(keyType)
+		if v := in.MapIndex(key); v.IsValid() {
+			elm = v.Interface()
+		}
+
 		w.PushUint32(0) // reserved
-		err = field.encoder.Encode(
+		err = field.encode(
 			w,
-			in[field.name],
+			elm,
 			path.AddField(field.name),
 			true,
 		)
@@ -108,6 +144,7 @@ func buildNamedTupleDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
@@ -117,26 +154,33 @@ func buildNamedTupleDecoder(
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
 		if !ok {
 			return nil, fmt.Errorf(
 				"%v struct is missing field %q", typ, field.Name,
 			)
 		}
 
+		fieldPath := path.AddField(field.Name)
 		child, err := BuildDecoder(
 			field.Desc,
 			sf.Type,
-			path.AddField(field.Name),
+			fieldPath,
+			opts,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
+		child = tupleElementDecoder(child, sf.Type, opts)
+
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
@@ -155,6 +199,7 @@ func buildNamedTupleDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
@@ -164,31 +209,46 @@ func buildNamedTupleDecoderV2(
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	var errs []error
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
 		if !ok {
-			return nil, fmt.Errorf(
-				"%v struct is missing field %q", typ, field.Name,
-			)
+			errs = append(errs, fmt.Errorf(
+				"expected %v to have a field named %q%v",
+				path, field.Name, serverTypeV2(&field.Desc),
+			))
+			continue
 		}
 
+		fieldPath := path.AddField(field.Name)
 		child, err := BuildDecoderV2(
 			&field.Desc,
 			sf.Type,
-			path.AddField(field.Name),
+			fieldPath,
+			opts,
 		)
 
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 
+		child = tupleElementDecoder(child, sf.Type, opts)
+
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
 	}
 
+	if err := joinFieldErrors(path, errs); err != nil {
+		return nil, err
+	}
+
 	decoder := namedTupleDecoder{desc.ID, fields}
 
 	if reflect.PtrTo(typ).Implements(optionalUnmarshalerType) {
@@ -218,13 +278,14 @@ func (c *namedTupleDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 
 		elmLen := r.PopUint32()
 		if elmLen == 0xffffffff {
+			// element length -1 means missing element
+			if err := field.decodeMissingElement(out); err != nil {
+				return err
+			}
 			continue
 		}
 
-		err := field.decoder.Decode(
-			r.PopSlice(elmLen),
-			pAdd(out, field.offset),
-		)
+		err := field.decode(r.PopSlice(elmLen), out)
 		if err != nil {
 			return err
 		}
@@ -247,8 +308,9 @@ func (c *optionalNamedTupleDecoder) Decode(
 	r *buff.Reader,
 	out unsafe.Pointer,
 ) error {
-	val := reflect.NewAt(c.typ, out)
-	method := val.MethodByName("SetMissing")
-	method.Call([]reflect.Value{falseValue})
+	if err := setPresent(c.typ, out); err != nil {
+		return err
+	}
+
 	return c.namedTupleDecoder.Decode(r, out)
 }
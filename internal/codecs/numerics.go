@@ -211,49 +211,3 @@ func (c *optionalBigIntDecoder) DecodeMissing(out unsafe.Pointer) {
 }
 
 func (c *optionalBigIntDecoder) DecodePresent(_ unsafe.Pointer) {}
-
-type decimalEncoder struct{}
-
-func (c *decimalEncoder) DescriptorID() types.UUID { return DecimalID }
-
-type optionalDecimalMarshaler interface {
-	marshal.DecimalMarshaler
-	marshal.OptionalMarshaler
-}
-
-func (c *decimalEncoder) Encode(
-	w *buff.Writer,
-	val interface{},
-	path Path,
-	required bool,
-) error {
-	switch in := val.(type) {
-	case optionalDecimalMarshaler:
-		return encodeOptional(w, in.Missing(), required,
-			func() error { return c.encodeMarshaler(w, in, path) },
-			func() error { return missingValueError(in, path) })
-	case marshal.DecimalMarshaler:
-		return c.encodeMarshaler(w, in, path)
-	default:
-		return fmt.Errorf("expected %v to be DecimalMarshaler got %T",
-			path, val)
-	}
-}
-
-func (c *decimalEncoder) encodeMarshaler(
-	w *buff.Writer,
-	val marshal.DecimalMarshaler,
-	path Path,
-) error {
-	data, err := val.MarshalEdgeDBDecimal()
-	if err != nil {
-		return err
-	}
-	if len(data) < 8 {
-		return wrongNumberOfBytesError(val, path, "at least 8", len(data))
-	}
-	w.BeginBytes()
-	w.PushBytes(data)
-	w.EndBytes()
-	return nil
-}
@@ -19,6 +19,7 @@ package codecs
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"unsafe"
 
 	"github.com/sebastiean/edgedb-go/internal/buff"
@@ -54,6 +55,7 @@ func buildObjectDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
@@ -63,42 +65,52 @@ func buildObjectDecoder(
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	// All mismatched fields are reported together, see buildShapeDecoderV2.
+	var errs []error
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
-		if !ok {
-			return nil, fmt.Errorf(
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
+		if !ok && field.Implicit {
+			// Implicit fields like id are only decoded
+			// if the out type has a field for them.
+			fields[i] = &DecoderField{name: field.Name}
+			continue
+		} else if !ok {
+			errs = append(errs, fmt.Errorf(
 				"expected %v to have a field named %q", path, field.Name,
-			)
+			))
+			continue
 		}
 
-		child, err := BuildDecoder(
-			field.Desc,
+		fieldPath := path.AddField(field.Name)
+		child, err := buildObjectField(
 			sf.Type,
-			path.AddField(field.Name),
+			field.Required,
+			field.Desc.Type == descriptor.Object,
+			fieldPath,
+			opts,
+			func(t reflect.Type) (Decoder, error) {
+				return BuildDecoder(field.Desc, t, fieldPath, opts)
+			},
 		)
 		if err != nil {
-			return nil, err
-		}
-
-		if !field.Required {
-			if _, isOptional := child.(OptionalDecoder); !isOptional {
-				typeName, ok := optionalTypeNameLookup[reflect.TypeOf(child)]
-				if !ok {
-					typeName = "OptionalUnmarshaler interface"
-				}
-				return nil, fmt.Errorf("expected %v at %v.%v to be %v "+
-					"because the field is not required",
-					sf.Type, path, field.Name, typeName)
-			}
+			errs = append(errs, err)
+			continue
 		}
 
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
 	}
 
+	if err := joinFieldErrors(path, errs); err != nil {
+		return nil, err
+	}
+
 	decoder := objectDecoder{desc.ID, fields}
 
 	if reflect.PtrTo(typ).Implements(optionalUnmarshalerType) {
@@ -112,51 +124,78 @@ func buildObjectDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
+) (Decoder, error) {
+	return buildShapeDecoderV2(desc, typ, path, opts, false)
+}
+
+// buildShapeDecoderV2 builds an object decoder. If skipMissing is true
+// shape fields that typ does not have are skipped instead of being
+// reported.
+func buildShapeDecoderV2(
+	desc *descriptor.V2,
+	typ reflect.Type,
+	path Path,
+	opts DecoderOptions,
+	skipMissing bool,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
-			"expected %v to be a Struct got %v", path, typ.Kind(),
+			"expected %v to be a Struct got %v%v",
+			path, typ.Kind(), serverTypeV2(desc),
 		)
 	}
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	// All mismatched fields are reported together so that out types for
+	// free shapes and computed fields can be fixed in one go.
+	var errs []error
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
-		if !ok {
-			return nil, fmt.Errorf(
-				"expected %v to have a field named %q", path, field.Name,
-			)
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
+		if !ok && (field.Implicit || skipMissing) {
+			// Implicit fields like id are only decoded
+			// if the out type has a field for them.
+			fields[i] = &DecoderField{name: field.Name}
+			continue
+		} else if !ok {
+			errs = append(errs, fmt.Errorf(
+				"expected %v to have a field named %q%v",
+				path, field.Name, describeFieldV2(field),
+			))
+			continue
 		}
 
-		child, err := BuildDecoderV2(
-			&field.Desc,
+		fieldPath := path.AddField(field.Name)
+		child, err := buildObjectField(
 			sf.Type,
-			path.AddField(field.Name),
+			field.Required,
+			field.Desc.Type == descriptor.Object,
+			fieldPath,
+			opts,
+			func(t reflect.Type) (Decoder, error) {
+				return BuildDecoderV2(&field.Desc, t, fieldPath, opts)
+			},
 		)
 		if err != nil {
-			return nil, err
-		}
-
-		if !field.Required {
-			if _, isOptional := child.(OptionalDecoder); !isOptional {
-				typeName, ok := optionalTypeNameLookup[reflect.TypeOf(child)]
-				if !ok {
-					typeName = "OptionalUnmarshaler interface"
-				}
-				return nil, fmt.Errorf("expected %v at %v.%v to be %v "+
-					"because the field is not required",
-					sf.Type, path, field.Name, typeName)
-			}
+			errs = append(errs, err)
+			continue
 		}
 
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
 	}
 
+	if err := joinFieldErrors(path, errs); err != nil {
+		return nil, err
+	}
+
 	decoder := objectDecoder{desc.ID, fields}
 
 	if reflect.PtrTo(typ).Implements(optionalUnmarshalerType) {
@@ -166,6 +205,144 @@ func buildObjectDecoderV2(
 	return &decoder, nil
 }
 
+// fieldErrors is returned when more than one field
+// of an out struct does not match the query result.
+type fieldErrors struct {
+	path Path
+	errs []error
+}
+
+// joinFieldErrors returns nil if errs is empty, the only error if there is
+// one and a fieldErrors otherwise.
+func joinFieldErrors(path Path, errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return &fieldErrors{path, errs}
+	}
+}
+
+func (e *fieldErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%v fields of %v do not match the query result: %v",
+		len(e.errs), e.path, strings.Join(msgs, "; "))
+}
+
+// describeFieldV2 describes the type and cardinality of an object field,
+// e.g. " (optional std::str)". It is empty if the cardinality is unknown.
+func describeFieldV2(field *descriptor.FieldV2) string {
+	var card string
+	switch field.Cardinality {
+	case 0x6f:
+		card = "optional"
+	case 0x41:
+		card = "required"
+	case 0x6d:
+		card = "multi"
+	case 0x4d:
+		card = "required multi"
+	default:
+		return ""
+	}
+
+	desc := &field.Desc
+	if desc.Type == descriptor.Set {
+		desc = &desc.Fields[0].Desc
+	}
+
+	return fmt.Sprintf(" (%v %v)", card, typeNameV2(desc))
+}
+
+// typeNameV2 returns the name of desc's type, e.g. std::str or
+// default::User, or its kind if the type is anonymous, e.g. tuple.
+func typeNameV2(desc *descriptor.V2) string {
+	if desc.Name != "" {
+		return desc.Name
+	}
+
+	return strings.ToLower(desc.Type.String())
+}
+
+// serverTypeV2 names desc's type in type mismatch errors,
+// e.g. " (server type std::str)". It is empty for anonymous types.
+func serverTypeV2(desc *descriptor.V2) string {
+	if desc.Name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" (server type %v)", desc.Name)
+}
+
+// buildObjectField builds the decoder for an object field
+// of type typ using build. Links can be decoded into pointers to structs
+// which are set to nil when the link is missing.
+func buildObjectField(
+	typ reflect.Type,
+	required bool,
+	link bool,
+	path Path,
+	opts DecoderOptions,
+	build func(reflect.Type) (Decoder, error),
+) (Decoder, error) {
+	isPtr := typ.Kind() == reflect.Ptr
+	if isPtr && link {
+		child, err := build(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &pointerDecoder{typ.Elem(), child}, nil
+	}
+
+	if isPtr && !required && opts.Missing == MissingPointerNil {
+		// Types like *big.Int that can not be decoded into their element
+		// type are decoded into the pointer type below.
+		child, err := build(typ.Elem())
+		if err == nil {
+			return &pointerDecoder{typ.Elem(), child}, nil
+		}
+	}
+
+	child, err := build(typ)
+	if err != nil || required {
+		return child, err
+	}
+
+	if _, isOptional := child.(OptionalDecoder); isOptional {
+		return child, nil
+	}
+
+	if opts.Missing == MissingZero ||
+		opts.Missing == MissingPointerNil && isPtr {
+		return &zeroMissingDecoder{child, typ}, nil
+	}
+
+	return nil, fmt.Errorf("expected %v at %v to be %v "+
+		"because the field is not required",
+		typ, path, optionalTypeName(child))
+}
+
+// optionalTypeName returns the name of the optional type
+// that can be used instead of the type that decoder decodes.
+func optionalTypeName(decoder Decoder) string {
+	if enum, ok := decoder.(*enumDecoder); ok {
+		decoder = enum.Decoder
+	}
+
+	if name, ok := optionalTypeNameLookup[reflect.TypeOf(decoder)]; ok {
+		return name
+	}
+
+	return "OptionalUnmarshaler interface"
+}
+
 type objectDecoder struct {
 	id     types.UUID
 	fields []*DecoderField
@@ -184,17 +361,24 @@ func (c *objectDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 	for _, field := range c.fields {
 		r.Discard(4) // reserved
 
-		p := pAdd(out, field.offset)
 		elmLen := r.PopUint32()
+		if field.decoder == nil {
+			// skipped implicit field
+			if elmLen != 0xffffffff {
+				r.Discard(int(elmLen))
+			}
+			continue
+		}
+
 		if elmLen == 0xffffffff {
 			// element length -1 means missing field
 			// https://www.edgedb.com/docs/internals/protocol/dataformats
-			field.decoder.(OptionalDecoder).DecodeMissing(p)
-		} else {
-			err := field.decoder.Decode(r.PopSlice(elmLen), p)
-			if err != nil {
-				return err
-			}
+			field.decodeMissing(out)
+			continue
+		}
+
+		if err := field.decode(r.PopSlice(elmLen), out); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -215,8 +399,9 @@ func (c *optionalObjectDecoder) Decode(
 	r *buff.Reader,
 	out unsafe.Pointer,
 ) error {
-	val := reflect.NewAt(c.typ, out)
-	method := val.MethodByName("SetMissing")
-	method.Call([]reflect.Value{falseValue})
+	if err := setPresent(c.typ, out); err != nil {
+		return err
+	}
+
 	return c.objectDecoder.Decode(r, out)
 }
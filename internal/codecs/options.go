@@ -0,0 +1,75 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"reflect"
+
+	"github.com/sebastiean/edgedb-go/internal/introspect"
+)
+
+// defaultStructTag is the struct tag key used when
+// DecoderOptions.StructTag is empty.
+const defaultStructTag = "edgedb"
+
+// MissingPolicy determines how missing values are decoded into struct fields
+// that are not optional types.
+type MissingPolicy int
+
+const (
+	// MissingError rejects struct fields that can not represent a missing
+	// value.
+	MissingError MissingPolicy = iota
+
+	// MissingZero sets struct fields to their zero value
+	// when the value is missing.
+	MissingZero
+
+	// MissingPointerNil allows pointer struct fields which are set to nil
+	// when the value is missing. Other struct fields are rejected.
+	MissingPointerNil
+)
+
+// DecoderOptions change how query results are decoded into go values.
+// The zero value decodes using the default behavior.
+type DecoderOptions struct {
+	// StructTag is the struct tag key used to match struct fields to shape
+	// fields. If StructTag is empty "edgedb" is used.
+	StructTag string
+
+	// FieldMatch determines how shape fields are matched to struct fields
+	// that are not tagged with StructTag.
+	FieldMatch introspect.FieldMatch
+
+	// Missing determines how missing values are decoded into
+	// struct fields that are not optional types.
+	Missing MissingPolicy
+
+	// ObjectTypes maps object type names to the struct types, or pointers
+	// to struct types, that objects are decoded into when the out type is
+	// an interface that they implement.
+	ObjectTypes map[string]reflect.Type
+}
+
+// Tag returns the struct tag key used to match struct fields.
+func (o DecoderOptions) Tag() string {
+	if o.StructTag == "" {
+		return defaultStructTag
+	}
+
+	return o.StructTag
+}
@@ -0,0 +1,110 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+)
+
+// panicError is returned instead of panicking when encoding or decoding a
+// value panics, usually in a user implemented marshaler or unmarshaler.
+type panicError struct {
+	op   string
+	path Path
+	val  interface{}
+}
+
+func (e *panicError) Error() string {
+	if e.path == "" {
+		return fmt.Sprintf("panic while %v: %v", e.op, e.val)
+	}
+
+	return fmt.Sprintf("panic while %v %v: %v", e.op, e.path, e.val)
+}
+
+func (e *panicError) Unwrap() error {
+	err, _ := e.val.(error)
+	return err
+}
+
+// PanicError returns the error for a panic with the value val while
+// encoding or decoding the value at path. op is "encoding" or "decoding".
+func PanicError(op string, path Path, val interface{}) error {
+	return &panicError{op: op, path: path, val: val}
+}
+
+// recoverPanic sets *err to an error identifying path if the calling
+// function panics. It must be deferred.
+func recoverPanic(err *error, op string, path Path) {
+	if r := recover(); r != nil {
+		*err = PanicError(op, path, r)
+	}
+}
+
+// recoverUnmarshaler sets *err to an error if a user implemented unmarshaler
+// called by the calling function panics. It must be deferred. Decoders that
+// don't call user code don't recover, a panic in them is recovered where
+// the result element is decoded.
+func recoverUnmarshaler(err *error) {
+	if r := recover(); r != nil {
+		*err = PanicError("decoding", "", r)
+	}
+}
+
+// setPresent calls SetMissing(false) on the OptionalUnmarshaler of type typ
+// at out. A panic in SetMissing is returned as an error.
+func setPresent(typ reflect.Type, out unsafe.Pointer) (err error) {
+	defer recoverUnmarshaler(&err)
+	val := reflect.NewAt(typ, out)
+	method := val.MethodByName("SetMissing")
+	method.Call([]reflect.Value{falseValue})
+	return nil
+}
+
+// encode encodes the field's value. Panics are returned as errors.
+func (f *EncoderField) encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) (err error) {
+	defer recoverPanic(&err, "encoding", path)
+	return f.encoder.Encode(w, val, path, required)
+}
+
+// decode decodes the field into out.
+func (f *DecoderField) decode(r *buff.Reader, out unsafe.Pointer) error {
+	err := CheckDataLength(f.decoder, r, f.path)
+	if err == nil {
+		err = f.decoder.Decode(r, pAdd(out, f.offset))
+	}
+
+	if err != nil {
+		return wrapField(err, f.name)
+	}
+
+	return nil
+}
+
+// decodeMissing decodes a missing field into out.
+func (f *DecoderField) decodeMissing(out unsafe.Pointer) {
+	f.decoder.(OptionalDecoder).DecodeMissing(pAdd(out, f.offset))
+}
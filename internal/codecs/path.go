@@ -16,7 +16,11 @@
 
 package codecs
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Path is used in error messages
 // to show what field in a nested data structure caused the error.
@@ -31,3 +35,54 @@ func (p Path) AddField(name string) Path {
 func (p Path) AddIndex(index int) Path {
 	return Path(fmt.Sprintf("%v[%v]", p, index))
 }
+
+// DecodeError is returned when a value in a query result can not be
+// decoded. It records where the value is in the result, for example
+// [10532].tags[3].name is the name of the fourth tag of the 10,533rd row.
+type DecodeError struct {
+	// segments are the location's field names and indices,
+	// innermost first.
+	segments []string
+	err      error
+}
+
+// Location returns the location of the value that could not be decoded.
+func (e *DecodeError) Location() string {
+	return strings.TrimPrefix(e.location(), ".")
+}
+
+func (e *DecodeError) location() string {
+	var b strings.Builder
+	for i := len(e.segments) - 1; i >= 0; i-- {
+		b.WriteString(e.segments[i])
+	}
+
+	return b.String()
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding result%v: %v", e.location(), e.err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.err }
+
+// WrapIndex adds the index of the element that err was returned for
+// to err's location.
+func WrapIndex(err error, index int) error {
+	return wrapLocation(err, "["+strconv.Itoa(index)+"]")
+}
+
+// wrapField adds the name of the field that err was returned for
+// to err's location.
+func wrapField(err error, name string) error {
+	return wrapLocation(err, "."+name)
+}
+
+func wrapLocation(err error, segment string) error {
+	if e, ok := err.(*DecodeError); ok {
+		e.segments = append(e.segments, segment)
+		return e
+	}
+
+	return &DecodeError{segments: []string{segment}, err: err}
+}
@@ -0,0 +1,177 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// buildPolymorphicDecoderV2 builds a decoder for objects decoded into the
+// interface type typ. Each object is decoded into the type registered in
+// DecoderOptions.ObjectTypes for the object's type name.
+func buildPolymorphicDecoderV2(
+	desc *descriptor.V2,
+	typ reflect.Type,
+	path Path,
+	opts DecoderOptions,
+) (Decoder, error) {
+	decoders := make(map[string]*concreteDecoder)
+	for name, t := range opts.ObjectTypes {
+		if !t.Implements(typ) {
+			continue
+		}
+
+		structType := t
+		if t.Kind() == reflect.Ptr {
+			structType = t.Elem()
+		}
+
+		decoder, err := buildShapeDecoderV2(
+			desc, structType, path, opts, true)
+		if err != nil {
+			return nil, err
+		}
+
+		decoders[name] = &concreteDecoder{
+			typ:     structType,
+			ptr:     t.Kind() == reflect.Ptr,
+			decoder: decoder,
+		}
+	}
+
+	if len(decoders) == 0 {
+		return nil, fmt.Errorf(
+			"expected %v to be a Struct got %v, "+
+				"no registered object type implements %v",
+			path, typ.Kind(), typ)
+	}
+
+	nameIndex := -1
+	for i, field := range desc.Fields {
+		if field.Name == typeNameKey {
+			nameIndex = i
+			break
+		}
+	}
+
+	if nameIndex < 0 {
+		return nil, fmt.Errorf("decoding %v into the interface %v "+
+			"requires implicit type names, "+
+			"see QueryOptions.WithImplicitTypeNames", path, typ)
+	}
+
+	return &polymorphicDecoder{
+		id:        desc.ID,
+		typ:       typ,
+		path:      path,
+		nameIndex: nameIndex,
+		decoders:  decoders,
+	}, nil
+}
+
+// concreteDecoder decodes objects into a registered struct type.
+type concreteDecoder struct {
+	typ     reflect.Type
+	ptr     bool
+	decoder Decoder
+}
+
+type polymorphicDecoder struct {
+	id        types.UUID
+	typ       reflect.Type
+	path      Path
+	nameIndex int
+	decoders  map[string]*concreteDecoder
+}
+
+func (c *polymorphicDecoder) DescriptorID() types.UUID { return c.id }
+
+func (c *polymorphicDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	name, err := c.typeName(r.Buf)
+	if err != nil {
+		return err
+	}
+
+	concrete, ok := c.decoders[name]
+	if !ok {
+		return fmt.Errorf("no object type implementing %v "+
+			"is registered for %v at %v", c.typ, name, c.path)
+	}
+
+	val := reflect.New(concrete.typ)
+	err = concrete.decoder.Decode(r, unsafe.Pointer(val.Pointer()))
+	if err != nil {
+		return err
+	}
+
+	if !concrete.ptr {
+		val = val.Elem()
+	}
+
+	reflect.NewAt(c.typ, out).Elem().Set(val)
+	return nil
+}
+
+func (c *polymorphicDecoder) DecodeMissing(out unsafe.Pointer) {
+	val := reflect.NewAt(c.typ, out).Elem()
+	val.Set(reflect.Zero(c.typ))
+}
+
+// typeName reads the value of the implicit type name field
+// from the encoded object data without consuming it.
+func (c *polymorphicDecoder) typeName(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", c.invalidData()
+	}
+	data = data[4:] // element count
+
+	for i := 0; ; i++ {
+		if len(data) < 8 {
+			return "", c.invalidData()
+		}
+
+		n := binary.BigEndian.Uint32(data[4:8]) // after reserved
+		data = data[8:]
+		if n == 0xffffffff {
+			if i == c.nameIndex {
+				return "", fmt.Errorf(
+					"missing %v at %v", typeNameKey, c.path)
+			}
+			continue
+		}
+
+		if int(n) > len(data) {
+			return "", c.invalidData()
+		}
+
+		if i == c.nameIndex {
+			return string(data[:n]), nil
+		}
+		data = data[n:]
+	}
+}
+
+func (c *polymorphicDecoder) invalidData() error {
+	return fmt.Errorf("invalid object data at %v", c.path)
+}
@@ -0,0 +1,136 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type content interface{ contentTitle() string }
+
+type movie struct {
+	Title    string            `edgedb:"title"`
+	Director types.OptionalStr `edgedb:"director"`
+}
+
+func (m movie) contentTitle() string { return m.Title }
+
+type show struct {
+	Title   string              `edgedb:"title"`
+	Seasons types.OptionalInt64 `edgedb:"seasons"`
+}
+
+func (s *show) contentTitle() string { return s.Title }
+
+// encodeObject encodes object fields with reserved and length prefixes.
+// nil fields are missing.
+func encodeObject(fields ...[]byte) []byte {
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	w.PushUint32(uint32(len(fields)))
+	for _, field := range fields {
+		w.PushUint32(0) // reserved
+		if field == nil {
+			w.PushUint32(0xffffffff)
+			continue
+		}
+		w.PushUint32(uint32(len(field)))
+		w.PushBytes(field)
+	}
+	w.EndMessage()
+	return w.Unwrap()[5:]
+}
+
+func TestDecodePolymorphic(t *testing.T) {
+	str := descriptor.V2{Type: descriptor.Scalar, ID: StrID, Name: "std::str"}
+	desc := descriptor.V2{
+		Type: descriptor.Object,
+		ID:   types.UUID{1},
+		Name: "default::Content",
+		Fields: []*descriptor.FieldV2{
+			{Name: "__tname__", Desc: str, Required: true, Implicit: true},
+			{Name: "title", Desc: str, Required: true},
+			{Name: "director", Desc: str},
+			{
+				Name: "seasons",
+				Desc: descriptor.V2{
+					Type: descriptor.Scalar,
+					ID:   Int64ID,
+					Name: "std::int64",
+				},
+			},
+		},
+	}
+
+	opts := DecoderOptions{ObjectTypes: map[string]reflect.Type{
+		"default::Movie": reflect.TypeOf(movie{}),
+		"default::Show":  reflect.TypeOf(&show{}),
+	}}
+
+	var result []content
+	typ := reflect.TypeOf(result).Elem()
+	decoder, err := BuildDecoderV2(&desc, typ, Path("out"), opts)
+	require.NoError(t, err)
+
+	data := encodeObject(
+		[]byte("default::Show"),
+		[]byte("Dark"),
+		nil,
+		[]byte{0, 0, 0, 0, 0, 0, 0, 3},
+	)
+	var out content
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&out))
+	require.NoError(t, err)
+	seasons := types.OptionalInt64{}
+	seasons.Set(3)
+	assert.Equal(t, &show{Title: "Dark", Seasons: seasons}, out)
+
+	data = encodeObject(
+		[]byte("default::Movie"),
+		[]byte("Alien"),
+		[]byte("Ridley Scott"),
+		nil,
+	)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&out))
+	require.NoError(t, err)
+	director := types.OptionalStr{}
+	director.Set("Ridley Scott")
+	assert.Equal(t, movie{Title: "Alien", Director: director}, out)
+
+	data = encodeObject([]byte("default::Book"), []byte("Dune"), nil, nil)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&out))
+	assert.EqualError(t, err, "no object type implementing "+
+		"codecs.content is registered for default::Book at out")
+
+	_, err = BuildDecoderV2(&desc, typ, Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "expected out to be a Struct got interface, "+
+		"no registered object type implements codecs.content")
+
+	desc.Fields = desc.Fields[1:]
+	_, err = BuildDecoderV2(&desc, typ, Path("out"), opts)
+	assert.EqualError(t, err, "decoding out into the interface "+
+		"codecs.content requires implicit type names, "+
+		"see QueryOptions.WithImplicitTypeNames")
+}
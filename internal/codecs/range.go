@@ -85,8 +85,8 @@ func buildRangeDecoderV2(
 	}
 
 	return nil, fmt.Errorf(
-		"expected %v to be an edgedb.Range type got %v",
-		path, typ)
+		"expected %v to be an edgedb.Range type got %v%v",
+		path, typ, serverTypeV2(desc))
 }
 
 func buildRequiredRangeDecoder(
@@ -297,7 +297,7 @@ func (c *rangeCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
 		} else {
 			err := c.child.Decode(r.PopSlice(subLen), p)
 			if err != nil {
-				return err
+				return wrapField(err, "lower")
 			}
 			// todo check that the  popped slice was consumed
 		}
@@ -312,7 +312,7 @@ func (c *rangeCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
 			subBuf := r.PopSlice(subLen)
 			err := c.child.Decode(subBuf, p)
 			if err != nil {
-				return err
+				return wrapField(err, "upper")
 			}
 			// todo check that the  popped slice was consumed
 		}
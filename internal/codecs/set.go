@@ -30,6 +30,7 @@ func buildSetDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Slice {
 		return nil, fmt.Errorf(
@@ -37,18 +38,26 @@ func buildSetDecoder(
 		)
 	}
 
-	child, err := BuildDecoder(desc.Fields[0].Desc, typ.Elem(), path)
+	elm := desc.Fields[0].Desc
+	child, err := buildElementDecoder(
+		typ.Elem(),
+		elm.Type == descriptor.Object,
+		func(typ reflect.Type) (Decoder, error) {
+			return BuildDecoder(elm, typ, path, opts)
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &setDecoder{desc.ID, child, typ, calcStep(typ.Elem())}, nil
+	return &setDecoder{desc.ID, child, typ, calcStep(typ.Elem()), path}, nil
 }
 
 func buildSetDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Slice {
 		return nil, fmt.Errorf(
@@ -56,12 +65,39 @@ func buildSetDecoderV2(
 		)
 	}
 
-	child, err := BuildDecoderV2(&desc.Fields[0].Desc, typ.Elem(), path)
+	elm := &desc.Fields[0].Desc
+	child, err := buildElementDecoder(
+		typ.Elem(),
+		elm.Type == descriptor.Object,
+		func(typ reflect.Type) (Decoder, error) {
+			return BuildDecoderV2(elm, typ, path, opts)
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &setDecoder{desc.ID, child, typ, calcStep(typ.Elem())}, nil
+	return &setDecoder{desc.ID, child, typ, calcStep(typ.Elem()), path}, nil
+}
+
+// buildElementDecoder builds the decoder for the elements of a set or array
+// using build. Object elements can be decoded into pointers to structs
+// so that large nested objects are not copied, for example []*User.
+func buildElementDecoder(
+	typ reflect.Type,
+	object bool,
+	build func(reflect.Type) (Decoder, error),
+) (Decoder, error) {
+	if object && typ.Kind() == reflect.Ptr {
+		child, err := build(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &pointerDecoder{typ.Elem(), child}, nil
+	}
+
+	return build(typ)
 }
 
 type setDecoder struct {
@@ -71,6 +107,9 @@ type setDecoder struct {
 
 	// step is the element width in bytes for a go array of type `Array.typ`.
 	step int
+
+	// path is used in errors about the elements.
+	path Path
 }
 
 func (c *setDecoder) DescriptorID() types.UUID { return c.id }
@@ -119,7 +158,11 @@ func (c *setDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 	slice := (*sliceHeader)(out)
 	setSliceLen(slice, c.typ, n)
 
-	_, isSetOfArrays := c.child.(*arrayDecoder)
+	child := c.child
+	if dynamic, ok := child.(*anyDecoder); ok {
+		child = dynamic.decoder
+	}
+	_, isSetOfArrays := child.(*arrayDecoder)
 
 	for i := 0; i < n; i++ {
 		if isSetOfArrays {
@@ -127,12 +170,14 @@ func (c *setDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 		}
 
 		elmLen := r.PopUint32()
-		err := c.child.Decode(
-			r.PopSlice(elmLen),
-			pAdd(slice.Data, uintptr(i*c.step)),
-		)
+		elm := r.PopSlice(elmLen)
+		if err := CheckDataLength(c.child, elm, c.path); err != nil {
+			return WrapIndex(err, i)
+		}
+
+		err := c.child.Decode(elm, pAdd(slice.Data, uintptr(i*c.step)))
 		if err != nil {
-			return err
+			return WrapIndex(err, i)
 		}
 	}
 	return nil
@@ -58,11 +58,11 @@ func (c *StrCodec) Encode(
 ) error {
 	switch in := val.(type) {
 	case string:
-		return c.encodeData(w, in)
+		return c.encodeData(w, in, path)
 	case types.OptionalStr:
 		str, ok := in.Get()
 		return encodeOptional(w, !ok, required,
-			func() error { return c.encodeData(w, str) },
+			func() error { return c.encodeData(w, str, path) },
 			func() error {
 				return missingValueError("edgedb.OptionalStr", path)
 			})
@@ -78,7 +78,15 @@ func (c *StrCodec) Encode(
 	}
 }
 
-func (c *StrCodec) encodeData(w *buff.Writer, data string) error {
+func (c *StrCodec) encodeData(
+	w *buff.Writer,
+	data string,
+	path Path,
+) error {
+	if err := checkEncodedLength(path, len(data)); err != nil {
+		return err
+	}
+
 	w.PushString(data)
 	return nil
 }
@@ -86,12 +94,15 @@ func (c *StrCodec) encodeData(w *buff.Writer, data string) error {
 func (c *StrCodec) encodeMarshaler(
 	w *buff.Writer,
 	val marshal.StrMarshaler,
-	_ Path,
+	path Path,
 ) error {
 	data, err := val.MarshalEdgeDBStr()
 	if err != nil {
 		return err
 	}
+	if err := checkEncodedLength(path, len(data)); err != nil {
+		return err
+	}
 	w.PushUint32(uint32(len(data)))
 	w.PushBytes(data)
 	return nil
@@ -0,0 +1,219 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+// ArgField is a struct field that a named argument is read from.
+type ArgField struct {
+	Name   string
+	Offset uintptr
+	Type   reflect.Type
+}
+
+// StructArgs are named arguments that are read from the fields of the struct
+// at Ptr. They are passed to an arguments encoder as the only argument and
+// are encoded without boxing the field values, see ArgBinding.
+type StructArgs struct {
+	Binding *ArgBinding
+	Ptr     unsafe.Pointer
+}
+
+// ArgBinding binds the fields of a struct type to the encoders of a query's
+// named arguments. The field encoders are built once for each arguments
+// descriptor. An ArgBinding is safe for concurrent use.
+type ArgBinding struct {
+	fields map[string]ArgField
+
+	// bound holds the *boundArgs for the last arguments descriptor.
+	bound atomic.Value
+}
+
+// boundArgs are the field encoders for an arguments descriptor
+// in the order of the descriptor's fields.
+type boundArgs struct {
+	id       types.UUID
+	encoders []fieldEncoder
+}
+
+// fieldEncoder encodes the struct field at p.
+type fieldEncoder func(w *buff.Writer, p unsafe.Pointer) error
+
+// NewArgBinding returns a binding for fields.
+func NewArgBinding(fields []ArgField) *ArgBinding {
+	b := &ArgBinding{fields: make(map[string]ArgField, len(fields))}
+	for _, field := range fields {
+		b.fields[field.Name] = field
+	}
+
+	return b
+}
+
+// Bind builds the field encoders for enc, which must be the encoder of a
+// query's arguments. It is an error if the query does not take named
+// arguments.
+func (b *ArgBinding) Bind(enc Encoder) error {
+	switch c := enc.(type) {
+	case noOpEncoder:
+		return nil
+	case *kwargsEncoder:
+		_, err := b.encoders(c)
+		return err
+	default:
+		return errors.New(
+			"struct arguments can not be bound to positional arguments")
+	}
+}
+
+// encoders returns the field encoders for c.
+func (b *ArgBinding) encoders(c *kwargsEncoder) ([]fieldEncoder, error) {
+	if bound, ok := b.bound.Load().(*boundArgs); ok && bound.id == c.id {
+		return bound.encoders, nil
+	}
+
+	bound := &boundArgs{
+		id:       c.id,
+		encoders: make([]fieldEncoder, len(c.fields)),
+	}
+
+	path := Path("args")
+	for i, f := range c.fields {
+		field, ok := b.fields[f.name]
+		if !ok {
+			return nil, fmt.Errorf(
+				"no struct field for argument %v", path.AddField(f.name))
+		}
+
+		bound.encoders[i] = buildFieldEncoder(
+			f, field.Type, field.Offset, path.AddField(f.name))
+	}
+
+	b.bound.Store(bound)
+	return bound.encoders, nil
+}
+
+func (c *kwargsEncoder) encodeStruct(
+	w *buff.Writer,
+	args StructArgs,
+) error {
+	encoders, err := args.Binding.encoders(c)
+	if err != nil {
+		return err
+	}
+
+	w.BeginBytes()
+	w.PushUint32(uint32(len(encoders)))
+	for _, encode := range encoders {
+		w.PushUint32(0) // reserved
+		if err := encode(w, args.Ptr); err != nil {
+			return err
+		}
+	}
+
+	w.EndBytes()
+	return nil
+}
+
+// buildFieldEncoder returns an encoder for the field of type typ at offset.
+// Common scalar types are encoded without reflection.
+func buildFieldEncoder(
+	f *EncoderField,
+	typ reflect.Type,
+	offset uintptr,
+	path Path,
+) fieldEncoder {
+	switch enc := f.encoder.(type) {
+	case *StrCodec:
+		switch typ {
+		case strType:
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*string)(pAdd(p, offset)), path)
+			}
+		case optionalStrType:
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				str, ok := (*types.OptionalStr)(pAdd(p, offset)).Get()
+				return encodeOptional(w, !ok, f.required,
+					func() error { return enc.encodeData(w, str, path) },
+					func() error {
+						return missingValueError("edgedb.OptionalStr", path)
+					})
+			}
+		}
+	case *BytesCodec:
+		if typ == bytesType {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*[]byte)(pAdd(p, offset)), path)
+			}
+		}
+	case *UUIDCodec:
+		if typ == uuidType {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*types.UUID)(pAdd(p, offset)))
+			}
+		}
+	case *BoolCodec:
+		if typ == boolType {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*bool)(pAdd(p, offset)))
+			}
+		}
+	case *Int16Codec:
+		if typ == int16Type {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*int16)(pAdd(p, offset)))
+			}
+		}
+	case *Int32Codec:
+		if typ == int32Type {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*int32)(pAdd(p, offset)))
+			}
+		}
+	case *Int64Codec:
+		if typ == int64Type {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*int64)(pAdd(p, offset)))
+			}
+		}
+	case *Float32Codec:
+		if typ == float32Type {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*float32)(pAdd(p, offset)))
+			}
+		}
+	case *Float64Codec:
+		if typ == float64Type {
+			return func(w *buff.Writer, p unsafe.Pointer) error {
+				return enc.encodeData(w, *(*float64)(pAdd(p, offset)))
+			}
+		}
+	}
+
+	return func(w *buff.Writer, p unsafe.Pointer) error {
+		val := reflect.NewAt(typ, pAdd(p, offset)).Elem().Interface()
+		return f.encode(w, val, path, f.required)
+	}
+}
@@ -80,7 +80,7 @@ func (c *tupleEncoder) Encode(
 	var err error
 	for i, field := range c.fields {
 		w.PushUint32(0) // reserved
-		err = field.encoder.Encode(w, in[i], path.AddIndex(i), true)
+		err = field.encode(w, in[i], path.AddIndex(i), true)
 		if err != nil {
 			return err
 		}
@@ -94,6 +94,7 @@ func buildTupleDecoder(
 	desc descriptor.Descriptor,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
@@ -103,8 +104,10 @@ func buildTupleDecoder(
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
 		if !ok {
 			return nil, fmt.Errorf(
 				"expected %v to have a field with the tag `edgedb:\"%v\"`",
@@ -112,18 +115,23 @@ func buildTupleDecoder(
 			)
 		}
 
+		fieldPath := path.AddField(field.Name)
 		child, err := BuildDecoder(
 			field.Desc,
 			sf.Type,
-			path.AddField(field.Name),
+			fieldPath,
+			opts,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
+		child = tupleElementDecoder(child, sf.Type, opts)
+
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
@@ -142,6 +150,7 @@ func buildTupleDecoderV2(
 	desc *descriptor.V2,
 	typ reflect.Type,
 	path Path,
+	opts DecoderOptions,
 ) (Decoder, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf(
@@ -151,32 +160,46 @@ func buildTupleDecoderV2(
 
 	fields := make([]*DecoderField, len(desc.Fields))
 
+	var errs []error
+	tag := opts.Tag()
 	for i, field := range desc.Fields {
-		sf, ok := introspect.StructField(typ, field.Name)
+		sf, ok := introspect.TaggedStructField(
+			typ, field.Name, tag, opts.FieldMatch)
 		if !ok {
-			return nil, fmt.Errorf(
-				"expected %v to have a field with the tag `edgedb:\"%v\"`",
-				typ, field.Name,
-			)
+			errs = append(errs, fmt.Errorf(
+				"expected %v to have a field with the tag `edgedb:\"%v\"`%v",
+				typ, field.Name, serverTypeV2(&field.Desc),
+			))
+			continue
 		}
 
+		fieldPath := path.AddField(field.Name)
 		child, err := BuildDecoderV2(
 			&field.Desc,
 			sf.Type,
-			path.AddField(field.Name),
+			fieldPath,
+			opts,
 		)
 
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 
+		child = tupleElementDecoder(child, sf.Type, opts)
+
 		fields[i] = &DecoderField{
 			name:    field.Name,
+			path:    fieldPath,
 			offset:  sf.Offset,
 			decoder: child,
 		}
 	}
 
+	if err := joinFieldErrors(path, errs); err != nil {
+		return nil, err
+	}
+
 	decoder := tupleDecoder{desc.ID, fields}
 
 	if reflect.PtrTo(typ).Implements(optionalUnmarshalerType) {
@@ -206,13 +229,14 @@ func (c *tupleDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
 
 		elmLen := r.PopUint32()
 		if elmLen == 0xffffffff {
+			// element length -1 means missing element
+			if err := field.decodeMissingElement(out); err != nil {
+				return err
+			}
 			continue
 		}
 
-		err := field.decoder.Decode(
-			r.PopSlice(elmLen),
-			pAdd(out, field.offset),
-		)
+		err := field.decode(r.PopSlice(elmLen), out)
 		if err != nil {
 			return err
 		}
@@ -235,8 +259,44 @@ func (c *optionalTupleDecoder) Decode(
 	r *buff.Reader,
 	out unsafe.Pointer,
 ) error {
-	val := reflect.NewAt(c.typ, out)
-	method := val.MethodByName("SetMissing")
-	method.Call([]reflect.Value{falseValue})
+	if err := setPresent(c.typ, out); err != nil {
+		return err
+	}
+
 	return c.tupleDecoder.Decode(r, out)
 }
+
+// tupleElementDecoder applies opts.Missing to tuple elements
+// that are not decoded into optional types.
+func tupleElementDecoder(
+	child Decoder,
+	typ reflect.Type,
+	opts DecoderOptions,
+) Decoder {
+	if _, isOptional := child.(OptionalDecoder); isOptional {
+		return child
+	}
+
+	if opts.Missing == MissingZero ||
+		opts.Missing == MissingPointerNil && typ.Kind() == reflect.Ptr {
+		return &zeroMissingDecoder{child, typ}
+	}
+
+	return child
+}
+
+// decodeMissingElement decodes a missing tuple element into out,
+// for example the element in SELECT (opt := <OPTIONAL str>{}).
+// Tuple descriptors do not say which elements can be missing
+// so elements that are not optional types are rejected here
+// instead of when the decoder is built.
+func (f *DecoderField) decodeMissingElement(out unsafe.Pointer) error {
+	if _, isOptional := f.decoder.(OptionalDecoder); isOptional {
+		f.decodeMissing(out)
+		return nil
+	}
+
+	return wrapField(fmt.Errorf(
+		"the value is missing, expected %v to be %v",
+		f.path, optionalTypeName(f.decoder)), f.name)
+}
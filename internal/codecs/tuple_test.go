@@ -0,0 +1,140 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMissingTupleElement(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Tuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "opt", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   StrID,
+				Name: "std::str",
+			}},
+		},
+	}
+
+	data := []byte{
+		0, 0, 0, 1, // element count
+		0, 0, 0, 0, // reserved
+		255, 255, 255, 255, // missing
+	}
+
+	var optional struct {
+		Opt types.OptionalStr `edgedb:"opt"`
+	}
+	optional.Opt.Set("stale")
+
+	decoder, err := BuildDecoderV2(&desc,
+		reflect.TypeOf(optional), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&optional))
+	require.NoError(t, err)
+	assert.Equal(t, types.OptionalStr{}, optional.Opt)
+
+	var required struct {
+		Opt string `edgedb:"opt"`
+	}
+	required.Opt = "stale"
+
+	decoder, err = BuildDecoderV2(&desc,
+		reflect.TypeOf(required), Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&required))
+	assert.EqualError(t, err, "decoding result.opt: the value is missing, "+
+		"expected out.opt to be edgedb.OptionalStr")
+
+	decoder, err = BuildDecoderV2(&desc, reflect.TypeOf(required),
+		Path("out"), DecoderOptions{Missing: MissingZero})
+	require.NoError(t, err)
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&required))
+	require.NoError(t, err)
+	assert.Equal(t, "", required.Opt)
+}
+
+func TestEncodeNamedTupleFromMap(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.NamedTuple,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "a", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   Int64ID,
+			}},
+			{Name: "b", Desc: descriptor.V2{
+				Type: descriptor.Scalar,
+				ID:   StrID,
+			}},
+		},
+	}
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	type key string
+	for _, in := range []interface{}{
+		map[string]interface{}{"a": int64(1), "b": "x"},
+		map[key]interface{}{"b": "x", "a": int64(1)},
+	} {
+		w := buff.NewWriter(nil)
+		w.BeginMessage(0xa)
+		require.NoError(t, encoder.Encode(w, in, Path("args"), true))
+		w.EndMessage()
+
+		var out bytes.Buffer
+		_, err = w.WriteTo(&out)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0xa, 0, 0, 0, 37,
+			0, 0, 0, 29, // data length
+			0, 0, 0, 2, // element count
+			// a
+			0, 0, 0, 0, // reserved
+			0, 0, 0, 8, // data length
+			0, 0, 0, 0, 0, 0, 0, 1,
+			// b
+			0, 0, 0, 0, // reserved
+			0, 0, 0, 1, // data length
+			'x',
+		}, out.Bytes())
+	}
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0xa)
+	err = encoder.Encode(w, map[string]int64{"a": 1}, Path("args"), true)
+	assert.EqualError(t, err, "expected args.b to be string, "+
+		"edgedb.OptionalStr or StrMarshaler got <nil>")
+
+	err = encoder.Encode(w, []int64{1, 2}, Path("args"), true)
+	assert.EqualError(t, err,
+		"expected args to be a map with string keys got []int64")
+}
@@ -125,8 +125,6 @@ func buildUnmarshaler(
 	switch desc.Type {
 	case descriptor.BaseScalar:
 		id = desc.ID
-	case descriptor.Enum:
-		id = StrID
 	default:
 		return nil, false, fmt.Errorf(
 			"unexpected descriptor type 0x%x", desc.Type)
@@ -159,8 +157,6 @@ func buildUnmarshalerV2(
 	switch desc.Type {
 	case descriptor.Scalar:
 		id = desc.ID
-	case descriptor.Enum:
-		id = StrID
 	default:
 		return nil, false, fmt.Errorf(
 			"unexpected descriptor type 0x%x", desc.Type)
@@ -193,13 +189,16 @@ type unmarshalerDecoder struct {
 
 func (c *unmarshalerDecoder) DescriptorID() types.UUID { return c.id }
 
-func (c *unmarshalerDecoder) Decode(r *buff.Reader, out unsafe.Pointer) error {
+func (c *unmarshalerDecoder) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) (err error) {
+	defer recoverUnmarshaler(&err)
 	val := reflect.NewAt(c.typ, out)
 	method := val.MethodByName(c.methodName)
 	result := method.Call([]reflect.Value{reflect.ValueOf(r.Buf)})
-	err := result[0].Interface()
-	if err != nil {
-		return err.(error)
+	if e := result[0].Interface(); e != nil {
+		return e.(error)
 	}
 	return nil
 }
@@ -218,8 +217,9 @@ func (c *optionalUnmarshalerDecoder) Decode(
 	r *buff.Reader,
 	out unsafe.Pointer,
 ) error {
-	val := reflect.NewAt(c.unmarshalerDecoder.typ, out)
-	method := val.MethodByName("SetMissing")
-	method.Call([]reflect.Value{falseValue})
+	if err := setPresent(c.unmarshalerDecoder.typ, out); err != nil {
+		return err
+	}
+
 	return c.unmarshalerDecoder.Decode(r, out)
 }
@@ -0,0 +1,570 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+)
+
+const (
+	vectorTypeName       = "ext::pgvector::vector"
+	halfVectorTypeName   = "ext::pgvector::halfvec"
+	sparseVectorTypeName = "ext::pgvector::sparsevec"
+
+	// maxVectorDimensions is the largest number of dimensions
+	// pgvector allows in vectors and half vectors.
+	maxVectorDimensions = 16_000
+
+	// maxSparseVectorDimensions and maxSparseVectorElements are the largest
+	// number of dimensions and non-zero elements pgvector allows
+	// in a sparse vector.
+	maxSparseVectorDimensions = 1_000_000_000
+	maxSparseVectorElements   = 16_000
+)
+
+var (
+	float32SliceType         = reflect.TypeOf([]float32{})
+	sparseVectorType         = reflect.TypeOf(types.SparseVector{})
+	optionalSparseVectorType = reflect.TypeOf(types.OptionalSparseVector{})
+	optionalVectorType       = reflect.TypeOf(types.OptionalVector{})
+)
+
+func isVector(desc *descriptor.V2) bool {
+	switch desc.Name {
+	case vectorTypeName, halfVectorTypeName, sparseVectorTypeName:
+		return true
+	default:
+		return false
+	}
+}
+
+func buildVectorEncoder(desc *descriptor.V2) Encoder {
+	switch desc.Name {
+	case halfVectorTypeName:
+		return &HalfVectorCodec{desc.ID}
+	case sparseVectorTypeName:
+		return &SparseVectorCodec{desc.ID}
+	default:
+		return &VectorCodec{desc.ID}
+	}
+}
+
+func buildVectorDecoder(
+	desc *descriptor.V2,
+	typ reflect.Type,
+	path Path,
+) (Decoder, error) {
+	if desc.Name == sparseVectorTypeName {
+		switch typ {
+		case sparseVectorType:
+			return &SparseVectorCodec{desc.ID}, nil
+		case optionalSparseVectorType:
+			return &optionalSparseVectorDecoder{desc.ID}, nil
+		default:
+			return nil, fmt.Errorf("expected %v to be edgedb.SparseVector "+
+				"or edgedb.OptionalSparseVector got %v%v",
+				path, typ, serverTypeV2(desc))
+		}
+	}
+
+	var decoder OptionalDecoder = &VectorCodec{desc.ID}
+	if desc.Name == halfVectorTypeName {
+		decoder = &HalfVectorCodec{desc.ID}
+	}
+
+	switch typ {
+	case float32SliceType:
+		return decoder, nil
+	case optionalVectorType:
+		return &optionalVectorDecoder{decoder}, nil
+	default:
+		return nil, fmt.Errorf(
+			"expected %v to be []float32 or edgedb.OptionalVector got %v%v",
+			path, typ, serverTypeV2(desc))
+	}
+}
+
+// naturalVectorType returns the type that vectors are decoded into
+// when the out type is interface{}.
+func naturalVectorType(desc *descriptor.V2) reflect.Type {
+	if desc.Name == sparseVectorTypeName {
+		return sparseVectorType
+	}
+
+	return float32SliceType
+}
+
+// VectorCodec encodes/decodes ext::pgvector::vector values as []float32.
+type VectorCodec struct {
+	ID types.UUID
+}
+
+// Type returns the type the codec encodes/decodes
+func (c *VectorCodec) Type() reflect.Type { return float32SliceType }
+
+// DescriptorID returns the codecs descriptor id.
+func (c *VectorCodec) DescriptorID() types.UUID { return c.ID }
+
+// Decode decodes a value
+func (c *VectorCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	if len(r.Buf) < 4 {
+		return fmt.Errorf(
+			"invalid vector data: expected at least 4 bytes got %v",
+			len(r.Buf))
+	}
+
+	n := int(r.PopUint16())
+	r.Discard(2) // unused
+
+	if len(r.Buf) != 4*n {
+		return fmt.Errorf(
+			"invalid vector data: %v dimensions require %v bytes got %v",
+			n, 4*n, len(r.Buf))
+	}
+
+	p := (*[]float32)(out)
+	if cap(*p) >= n {
+		*p = (*p)[:n]
+	} else {
+		*p = make([]float32, n)
+	}
+
+	for i := 0; i < n; i++ {
+		(*p)[i] = math.Float32frombits(r.PopUint32())
+	}
+
+	return nil
+}
+
+// DecodeMissing decodes a missing value as a nil slice.
+func (c *VectorCodec) DecodeMissing(out unsafe.Pointer) {
+	*(*[]float32)(out) = nil
+}
+
+// Encode encodes a value
+func (c *VectorCodec) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	switch in := val.(type) {
+	case []float32:
+		return encodeOptional(w, in == nil, required,
+			func() error { return c.encodeData(w, in, path) },
+			func() error { return missingValueError("[]float32", path) })
+	case types.OptionalVector:
+		data, ok := in.Get()
+		return encodeOptional(w, !ok, required,
+			func() error { return c.encodeData(w, data, path) },
+			func() error {
+				return missingValueError("edgedb.OptionalVector", path)
+			})
+	default:
+		return fmt.Errorf("expected %v to be []float32 "+
+			"or edgedb.OptionalVector got %T", path, val)
+	}
+}
+
+func (c *VectorCodec) encodeData(
+	w *buff.Writer,
+	data []float32,
+	path Path,
+) error {
+	if len(data) == 0 || len(data) > maxVectorDimensions {
+		return fmt.Errorf(
+			"expected %v to have between 1 and %v dimensions got %v",
+			path, maxVectorDimensions, len(data))
+	}
+
+	w.PushUint32(uint32(4 + 4*len(data)))
+	w.PushUint16(uint16(len(data)))
+	w.PushUint16(0) // unused
+	for _, v := range data {
+		w.PushUint32(math.Float32bits(v))
+	}
+
+	return nil
+}
+
+// HalfVectorCodec encodes/decodes ext::pgvector::halfvec values as
+// []float32. Elements are sent over the wire as IEEE 754 half precision
+// floats and are rounded to the nearest half precision value when encoded.
+type HalfVectorCodec struct {
+	ID types.UUID
+}
+
+// Type returns the type the codec encodes/decodes
+func (c *HalfVectorCodec) Type() reflect.Type { return float32SliceType }
+
+// DescriptorID returns the codecs descriptor id.
+func (c *HalfVectorCodec) DescriptorID() types.UUID { return c.ID }
+
+// Decode decodes a value
+func (c *HalfVectorCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	if len(r.Buf) < 4 {
+		return fmt.Errorf(
+			"invalid halfvec data: expected at least 4 bytes got %v",
+			len(r.Buf))
+	}
+
+	n := int(r.PopUint16())
+	r.Discard(2) // unused
+
+	if len(r.Buf) != 2*n {
+		return fmt.Errorf(
+			"invalid halfvec data: %v dimensions require %v bytes got %v",
+			n, 2*n, len(r.Buf))
+	}
+
+	p := (*[]float32)(out)
+	if cap(*p) >= n {
+		*p = (*p)[:n]
+	} else {
+		*p = make([]float32, n)
+	}
+
+	for i := 0; i < n; i++ {
+		(*p)[i] = float16ToFloat32(r.PopUint16())
+	}
+
+	return nil
+}
+
+// DecodeMissing decodes a missing value as a nil slice.
+func (c *HalfVectorCodec) DecodeMissing(out unsafe.Pointer) {
+	*(*[]float32)(out) = nil
+}
+
+// Encode encodes a value
+func (c *HalfVectorCodec) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	switch in := val.(type) {
+	case []float32:
+		return encodeOptional(w, in == nil, required,
+			func() error { return c.encodeData(w, in, path) },
+			func() error { return missingValueError("[]float32", path) })
+	case types.OptionalVector:
+		data, ok := in.Get()
+		return encodeOptional(w, !ok, required,
+			func() error { return c.encodeData(w, data, path) },
+			func() error {
+				return missingValueError("edgedb.OptionalVector", path)
+			})
+	default:
+		return fmt.Errorf("expected %v to be []float32 "+
+			"or edgedb.OptionalVector got %T", path, val)
+	}
+}
+
+func (c *HalfVectorCodec) encodeData(
+	w *buff.Writer,
+	data []float32,
+	path Path,
+) error {
+	if len(data) == 0 || len(data) > maxVectorDimensions {
+		return fmt.Errorf(
+			"expected %v to have between 1 and %v dimensions got %v",
+			path, maxVectorDimensions, len(data))
+	}
+
+	halves := make([]uint16, len(data))
+	for i, v := range data {
+		h := float32ToFloat16(v)
+		if h&0x7fff == 0x7c00 && !math.IsInf(float64(v), 0) {
+			return fmt.Errorf(
+				"%v at %v is out of range for halfvec", v, path.AddIndex(i))
+		}
+		halves[i] = h
+	}
+
+	w.PushUint32(uint32(4 + 2*len(data)))
+	w.PushUint16(uint16(len(data)))
+	w.PushUint16(0) // unused
+	for _, h := range halves {
+		w.PushUint16(h)
+	}
+
+	return nil
+}
+
+// float16ToFloat32 converts IEEE 754 half precision bits to a float32.
+// Every half precision value is exactly representable as a float32.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		// zero or subnormal: mant * 2^-24
+		f := float32(mant) / (1 << 24)
+		return math.Float32frombits(math.Float32bits(f) | sign)
+	case 0x1f:
+		// infinity or NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	default:
+		return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+	}
+}
+
+// float32ToFloat16 converts a float32 to IEEE 754 half precision bits
+// rounding to the nearest value, ties to even. Values too large for half
+// precision become infinity.
+func float32ToFloat16(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if exp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00 // NaN
+		}
+		return sign | 0x7c00 // infinity
+	}
+
+	e := exp - 127 + 15
+	switch {
+	case e >= 0x1f:
+		return sign | 0x7c00
+	case e <= 0:
+		if e < -10 {
+			// less than half of the smallest subnormal
+			return sign
+		}
+
+		// subnormal, rounding may carry into the smallest normal
+		return sign | roundShift(mant|0x800000, uint(14-e))
+	default:
+		// rounding may carry into the exponent
+		// which correctly overflows to infinity.
+		return sign | (uint16(e)<<10 + roundShift(mant, 13))
+	}
+}
+
+// roundShift returns m >> shift rounded to the nearest value, ties to even.
+func roundShift(m uint32, shift uint) uint16 {
+	h := m >> shift
+	rem := m & (1<<shift - 1)
+	half := uint32(1) << (shift - 1)
+	if rem > half || rem == half && h&1 == 1 {
+		h++
+	}
+
+	return uint16(h)
+}
+
+// SparseVectorCodec encodes/decodes ext::pgvector::sparsevec values.
+type SparseVectorCodec struct {
+	ID types.UUID
+}
+
+// Type returns the type the codec encodes/decodes
+func (c *SparseVectorCodec) Type() reflect.Type { return sparseVectorType }
+
+// DescriptorID returns the codecs descriptor id.
+func (c *SparseVectorCodec) DescriptorID() types.UUID { return c.ID }
+
+// Decode decodes a value
+func (c *SparseVectorCodec) Decode(r *buff.Reader, out unsafe.Pointer) error {
+	return decodeSparseVector(r, (*types.SparseVector)(out))
+}
+
+func decodeSparseVector(r *buff.Reader, out *types.SparseVector) error {
+	if len(r.Buf) < 12 {
+		return fmt.Errorf(
+			"invalid sparse vector data: expected at least 12 bytes got %v",
+			len(r.Buf))
+	}
+
+	out.Dim = r.PopUint32()
+	n := int(r.PopUint32())
+	r.Discard(4) // unused
+
+	if len(r.Buf) != 8*n {
+		return fmt.Errorf("invalid sparse vector data: "+
+			"%v elements require %v bytes got %v", n, 8*n, len(r.Buf))
+	}
+
+	if cap(out.Indices) >= n {
+		out.Indices = out.Indices[:n]
+	} else {
+		out.Indices = make([]uint32, n)
+	}
+
+	if cap(out.Values) >= n {
+		out.Values = out.Values[:n]
+	} else {
+		out.Values = make([]float32, n)
+	}
+
+	for i := 0; i < n; i++ {
+		out.Indices[i] = r.PopUint32()
+	}
+
+	for i := 0; i < n; i++ {
+		out.Values[i] = math.Float32frombits(r.PopUint32())
+	}
+
+	return nil
+}
+
+// Encode encodes a value
+func (c *SparseVectorCodec) Encode(
+	w *buff.Writer,
+	val interface{},
+	path Path,
+	required bool,
+) error {
+	switch in := val.(type) {
+	case types.SparseVector:
+		return c.encodeData(w, in, path)
+	case types.OptionalSparseVector:
+		data, ok := in.Get()
+		return encodeOptional(w, !ok, required,
+			func() error { return c.encodeData(w, data, path) },
+			func() error {
+				return missingValueError("edgedb.OptionalSparseVector", path)
+			})
+	default:
+		return fmt.Errorf("expected %v to be edgedb.SparseVector "+
+			"or edgedb.OptionalSparseVector got %T", path, val)
+	}
+}
+
+func (c *SparseVectorCodec) encodeData(
+	w *buff.Writer,
+	data types.SparseVector,
+	path Path,
+) error {
+	if data.Dim == 0 || data.Dim > maxSparseVectorDimensions {
+		return fmt.Errorf(
+			"expected %v to have between 1 and %v dimensions got %v",
+			path, maxSparseVectorDimensions, data.Dim)
+	}
+
+	n := len(data.Indices)
+	if n != len(data.Values) {
+		return fmt.Errorf(
+			"expected %v to have as many values as indices: "+
+				"got %v indices and %v values",
+			path, n, len(data.Values))
+	}
+
+	if n > maxSparseVectorElements {
+		return fmt.Errorf(
+			"expected %v to have at most %v non-zero elements got %v",
+			path, maxSparseVectorElements, n)
+	}
+
+	for i, idx := range data.Indices {
+		if idx >= data.Dim {
+			return fmt.Errorf(
+				"index %v at %v is out of bounds for %v dimensions",
+				idx, path, data.Dim)
+		}
+
+		if i > 0 && idx <= data.Indices[i-1] {
+			return fmt.Errorf(
+				"expected %v indices to be unique and in ascending order",
+				path)
+		}
+	}
+
+	w.PushUint32(uint32(12 + 8*n))
+	w.PushUint32(data.Dim)
+	w.PushUint32(uint32(n))
+	w.PushUint32(0) // unused
+	for _, idx := range data.Indices {
+		w.PushUint32(idx)
+	}
+	for _, v := range data.Values {
+		w.PushUint32(math.Float32bits(v))
+	}
+
+	return nil
+}
+
+type optionalSparseVector struct {
+	val types.SparseVector
+	set bool
+}
+
+type optionalSparseVectorDecoder struct {
+	id types.UUID
+}
+
+func (c *optionalSparseVectorDecoder) DescriptorID() types.UUID {
+	return c.id
+}
+
+func (c *optionalSparseVectorDecoder) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) error {
+	opvec := (*optionalSparseVector)(out)
+	opvec.set = true
+	return decodeSparseVector(r, &opvec.val)
+}
+
+func (c *optionalSparseVectorDecoder) DecodeMissing(out unsafe.Pointer) {
+	(*types.OptionalSparseVector)(out).Unset()
+}
+
+func (c *optionalSparseVectorDecoder) DecodePresent(_ unsafe.Pointer) {}
+
+type optionalVector struct {
+	val []float32
+	set bool
+}
+
+// optionalVectorDecoder decodes vectors and half vectors
+// into edgedb.OptionalVector values.
+type optionalVectorDecoder struct {
+	decoder OptionalDecoder
+}
+
+func (c *optionalVectorDecoder) DescriptorID() types.UUID {
+	return c.decoder.DescriptorID()
+}
+
+func (c *optionalVectorDecoder) Decode(
+	r *buff.Reader,
+	out unsafe.Pointer,
+) error {
+	opvec := (*optionalVector)(out)
+	opvec.set = true
+	return c.decoder.Decode(r, unsafe.Pointer(&opvec.val))
+}
+
+func (c *optionalVectorDecoder) DecodeMissing(out unsafe.Pointer) {
+	(*types.OptionalVector)(out).Unset()
+}
+
+func (c *optionalVectorDecoder) DecodePresent(_ unsafe.Pointer) {}
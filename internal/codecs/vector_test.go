@@ -0,0 +1,284 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codecs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/sebastiean/edgedb-go/internal"
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorFloat32Slice(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   types.UUID{1},
+		Name: "ext::pgvector::vector",
+	}
+	data := []byte{
+		0, 2, // dimensions
+		0, 0, // unused
+		0x3f, 0x80, 0, 0, // 1.0
+		0xc0, 0, 0, 0, // -2.0
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, float32SliceType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result []float32
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, -2}, result)
+
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&result))
+	assert.Nil(t, result)
+
+	err = decoder.Decode(
+		buff.SimpleReader(data[:8]), unsafe.Pointer(&result))
+	assert.EqualError(t, err,
+		"invalid vector data: 2 dimensions require 8 bytes got 4")
+
+	_, err = BuildDecoderV2(
+		&desc, reflect.TypeOf([]float64{}), Path("out"), DecoderOptions{})
+	assert.EqualError(t, err, "expected out to be []float32 "+
+		"or edgedb.OptionalVector got []float64 "+
+		"(server type ext::pgvector::vector)")
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, []float32{1, -2}, Path("args"), true)
+	require.NoError(t, err)
+	err = encoder.Encode(w, []float32(nil), Path("args"), false)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	assert.Equal(t, append(
+		[]byte{0, 0, 0, 12},                 // data length
+		append(data, 255, 255, 255, 255)..., // missing vector
+	), w.Unwrap()[5:])
+
+	err = encoder.Encode(w, []float32{}, Path("args"), true)
+	assert.EqualError(t, err,
+		"expected args to have between 1 and 16000 dimensions got 0")
+}
+
+func TestOptionalVector(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   types.UUID{1},
+		Name: "ext::pgvector::vector",
+	}
+	data := []byte{
+		0, 2, // dimensions
+		0, 0, // unused
+		0x3f, 0x80, 0, 0, // 1.0
+		0xc0, 0, 0, 0, // -2.0
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, optionalVectorType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result types.OptionalVector
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, types.NewOptionalVector([]float32{1, -2}), result)
+
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&result))
+	assert.Equal(t, types.OptionalVector{}, result)
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, types.NewOptionalVector([]float32{1, -2}),
+		Path("args"), true)
+	require.NoError(t, err)
+	err = encoder.Encode(w, types.OptionalVector{}, Path("args"), false)
+	require.NoError(t, err)
+	w.EndMessage()
+
+	assert.Equal(t, append(
+		[]byte{0, 0, 0, 12},                 // data length
+		append(data, 255, 255, 255, 255)..., // missing vector
+	), w.Unwrap()[5:])
+
+	err = encoder.Encode(w, types.OptionalVector{}, Path("args"), true)
+	assert.EqualError(t, err, "cannot encode edgedb.OptionalVector at args "+
+		"because its value is missing")
+
+	err = encoder.Encode(w, []float64{1}, Path("args"), true)
+	assert.EqualError(t, err, "expected args to be []float32 "+
+		"or edgedb.OptionalVector got []float64")
+}
+
+func TestSparseVector(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   types.UUID{1},
+		Name: "ext::pgvector::sparsevec",
+	}
+	data := []byte{
+		0, 0, 0, 5, // dimensions
+		0, 0, 0, 2, // non-zero elements
+		0, 0, 0, 0, // unused
+		0, 0, 0, 1, // index
+		0, 0, 0, 3, // index
+		0x3f, 0x80, 0, 0, // 1.0
+		0xc0, 0, 0, 0, // -2.0
+	}
+	expected := types.SparseVector{
+		Dim:     5,
+		Indices: []uint32{1, 3},
+		Values:  []float32{1, -2},
+	}
+
+	decoder, err := BuildDecoderV2(
+		&desc, sparseVectorType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result types.SparseVector
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	assert.Equal(t, []float32{0, 1, 0, -2, 0}, result.Dense())
+	assert.Equal(t, expected, types.NewSparseVector(result.Dense()))
+
+	decoder, err = BuildDecoderV2(
+		&desc, optionalSparseVectorType, Path("out"), DecoderOptions{})
+	require.NoError(t, err)
+
+	var optional types.OptionalSparseVector
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&optional))
+	require.NoError(t, err)
+	assert.Equal(t, types.NewOptionalSparseVector(expected), optional)
+
+	decoder.(OptionalDecoder).DecodeMissing(unsafe.Pointer(&optional))
+	assert.Equal(t, types.OptionalSparseVector{}, optional)
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, expected, Path("args"), true)
+	require.NoError(t, err)
+	w.EndMessage()
+	assert.Equal(t, append([]byte{0, 0, 0, 28}, data...), w.Unwrap()[5:])
+
+	err = encoder.Encode(w, types.SparseVector{
+		Dim:     5,
+		Indices: []uint32{3, 1},
+		Values:  []float32{1, 2},
+	}, Path("args"), true)
+	assert.EqualError(t, err,
+		"expected args indices to be unique and in ascending order")
+
+	err = encoder.Encode(w, types.SparseVector{
+		Dim:     2,
+		Indices: []uint32{2},
+		Values:  []float32{1},
+	}, Path("args"), true)
+	assert.EqualError(t, err,
+		"index 2 at args is out of bounds for 2 dimensions")
+}
+
+func TestHalfVector(t *testing.T) {
+	desc := descriptor.V2{
+		Type: descriptor.Scalar,
+		ID:   types.UUID{1},
+		Name: "ext::pgvector::halfvec",
+	}
+	data := []byte{
+		0, 3, // dimensions
+		0, 0, // unused
+		0x3c, 0x00, // 1.0
+		0xc0, 0x00, // -2.0
+		0x00, 0x01, // smallest subnormal
+	}
+	expected := []float32{1, -2, float32(math.Ldexp(1, -24))}
+
+	decoder, err := BuildDecoderV2(
+		&desc, float32SliceType, Path("out"), DecoderOptions{},
+	)
+	require.NoError(t, err)
+
+	var result []float32
+	err = decoder.Decode(buff.SimpleReader(data), unsafe.Pointer(&result))
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	encoder, err := BuildEncoderV2(&desc, internal.ProtocolVersion{Major: 2})
+	require.NoError(t, err)
+
+	w := buff.NewWriter(nil)
+	w.BeginMessage(0)
+	err = encoder.Encode(w, expected, Path("args"), true)
+	require.NoError(t, err)
+	w.EndMessage()
+	assert.Equal(t, append([]byte{0, 0, 0, 10}, data...), w.Unwrap()[5:])
+
+	err = encoder.Encode(w, []float32{1, 65520}, Path("args"), true)
+	assert.EqualError(t, err, "65520 at args[1] is out of range for halfvec")
+}
+
+func TestFloat16Conversion(t *testing.T) {
+	for h := 0; h <= 0xffff; h++ {
+		if h&0x7c00 == 0x7c00 && h&0x3ff != 0 {
+			continue // NaN
+		}
+
+		f := float16ToFloat32(uint16(h))
+		require.Equal(t, uint16(h), float32ToFloat16(f), "%#04x %v", h, f)
+	}
+
+	samples := []struct {
+		in       float64
+		expected uint16
+	}{
+		{65504, 0x7bff},
+		{65519, 0x7bff},
+		{65520, 0x7c00},
+		{1 + math.Ldexp(1, -11), 0x3c00},   // tie rounds to even
+		{1 + math.Ldexp(3, -11), 0x3c02},   // tie rounds to even
+		{1 + math.Ldexp(1.5, -11), 0x3c01}, // above tie rounds up
+		{math.Ldexp(1, -25), 0x0000},       // tie rounds to even
+		{math.Ldexp(1.5, -25), 0x0001},
+		{math.Ldexp(1, -26), 0x0000},
+		{-math.Ldexp(1023.5, -24), 0x8400}, // carries into normal
+		{math.Inf(-1), 0xfc00},
+	}
+
+	for _, s := range samples {
+		assert.Equal(t, s.expected, float32ToFloat16(float32(s.in)), s.in)
+	}
+}
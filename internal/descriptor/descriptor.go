@@ -26,6 +26,15 @@ import (
 	"github.com/sebastiean/edgedb-go/internal/edgedbtypes"
 )
 
+const (
+	// flagImplicit is the object shape element flag
+	// for fields that were not explicitly selected.
+	flagImplicit = 1 << 0
+
+	// cardinalityOne is the protocol's value for exactly one.
+	cardinalityOne = 0x41
+)
+
 // IDZero is descriptor ID 00000000-0000-0000-0000-000000000000
 // https://www.edgedb.com/docs/internals/protocol/typedesc#type-descriptors
 var IDZero = edgedbtypes.UUID{}
@@ -79,6 +88,9 @@ type Descriptor struct {
 	Type   Type
 	ID     edgedbtypes.UUID
 	Fields []*Field
+
+	// EnumMembers are the allowed values of an enum descriptor.
+	EnumMembers []string
 }
 
 // Field represents the child of a descriptor
@@ -86,6 +98,14 @@ type Field struct {
 	Name     string
 	Desc     Descriptor
 	Required bool
+
+	// Cardinality is the field's cardinality as sent by the server.
+	// It is only set for object fields.
+	Cardinality uint8
+
+	// Implicit is true for object fields that were not explicitly selected,
+	// e.g. id and __tid__.
+	Implicit bool
 }
 
 // Pop builds a descriptor tree from a describe statement type description.
@@ -108,25 +128,25 @@ func Pop(
 			fields := []*Field{{
 				Desc: descriptors[r.PopUint16()],
 			}}
-			desc = Descriptor{Set, id, fields}
+			desc = Descriptor{Set, id, fields, nil}
 		case Object, InputShape:
 			fields, err := objectFields(r, descriptors, version)
 			if err != nil {
 				return Descriptor{}, err
 			}
-			desc = Descriptor{typ, id, fields}
+			desc = Descriptor{typ, id, fields, nil}
 		case BaseScalar:
-			desc = Descriptor{BaseScalar, id, nil}
+			desc = Descriptor{BaseScalar, id, nil, nil}
 		case Scalar:
 			desc = Descriptor{Scalar, id, []*Field{{
 				Desc: descriptors[r.PopUint16()],
-			}}}
+			}}, nil}
 		case Tuple:
 			fields := tupleFields(r, descriptors)
-			desc = Descriptor{Tuple, id, fields}
+			desc = Descriptor{Tuple, id, fields, nil}
 		case NamedTuple:
 			fields := namedTupleFields(r, descriptors)
-			desc = Descriptor{typ, id, fields}
+			desc = Descriptor{typ, id, fields, nil}
 		case Array:
 			fields := []*Field{{
 				Desc: descriptors[r.PopUint16()],
@@ -135,14 +155,14 @@ func Pop(
 			if err != nil {
 				return Descriptor{}, err
 			}
-			desc = Descriptor{typ, id, fields}
+			desc = Descriptor{typ, id, fields, nil}
 		case Enum:
-			discardEnumMemberNames(r)
-			desc = Descriptor{typ, id, nil}
+			members := enumMemberNames(r)
+			desc = Descriptor{typ, id, nil, members}
 		case Range:
 			desc = Descriptor{typ, id, []*Field{{
 				Desc: descriptors[r.PopUint16()],
-			}}}
+			}}, nil}
 		default:
 			if 0x80 <= typ && typ <= 0xff {
 				// ignore unknown type annotations
@@ -169,10 +189,14 @@ func objectFields(
 	fields := make([]*Field, n)
 
 	for i := 0; i < n; i++ {
-		var required bool
+		var (
+			required bool
+			implicit bool
+			card     uint8
+		)
 		if version.GTE(internal.ProtocolVersion{Major: 0, Minor: 11}) {
-			r.Discard(4) // flags
-			card := r.PopUint8()
+			implicit = r.PopUint32()&flagImplicit != 0
+			card = r.PopUint8()
 			switch card {
 			case 0x6f, 0x6d:
 				required = false
@@ -182,18 +206,21 @@ func objectFields(
 				return nil, fmt.Errorf("unexpected cardinality: %v", card)
 			}
 		} else {
-			r.Discard(1) // flags
+			implicit = r.PopUint8()&flagImplicit != 0
 
 			// Preserve backward compatibility with old behavior. If the
 			// protocol version does not support the cardinality flag assume
 			// all fields are required.
 			required = true
+			card = cardinalityOne
 		}
 
 		fields[i] = &Field{
-			Name:     r.PopString(),
-			Desc:     descriptors[r.PopUint16()],
-			Required: required,
+			Name:        r.PopString(),
+			Desc:        descriptors[r.PopUint16()],
+			Required:    required,
+			Cardinality: card,
+			Implicit:    implicit,
 		}
 	}
 
@@ -239,9 +266,12 @@ func assertArrayDimensions(r *buff.Reader) error {
 	return nil
 }
 
-func discardEnumMemberNames(r *buff.Reader) {
+func enumMemberNames(r *buff.Reader) []string {
 	n := int(r.PopUint16())
+	members := make([]string, n)
 	for i := 0; i < n; i++ {
-		r.PopBytes() // enumeration member name
+		members[i] = r.PopString()
 	}
+
+	return members
 }
@@ -34,6 +34,9 @@ type V2 struct {
 	SchemaDefined bool
 	Ancestors     []*FieldV2
 	Fields        []*FieldV2
+
+	// EnumMembers are the allowed values of an enum descriptor.
+	EnumMembers []string
 }
 
 // FieldV2 represents the child of a descriptor
@@ -42,6 +45,14 @@ type FieldV2 struct {
 	Desc     V2
 	Required bool
 	Union    bool
+
+	// Cardinality is the field's cardinality as sent by the server.
+	// It is only set for object fields.
+	Cardinality uint8
+
+	// Implicit is true for object fields that were not explicitly selected,
+	// e.g. id and __tid__.
+	Implicit bool
 }
 
 // PopV2 builds a descriptor tree from a describe statement type description.
@@ -65,30 +76,36 @@ func PopV2(
 			fields := []*FieldV2{{
 				Desc: descriptorsV2[r.PopUint16()],
 			}}
-			desc = V2{Set, id, "", false, nil, fields}
+			desc = V2{Set, id, "", false, nil, fields, nil}
 		case Object:
-			r.PopUint8()  // schema_defined
-			r.PopUint16() // type
+			r.PopUint8() // ephemeral_free_shape
+
+			// Free shapes are not guaranteed to reference a schema type.
+			var name string
+			if i := int(r.PopUint16()); i < len(descriptorsV2) {
+				name = descriptorsV2[i].Name
+			}
+
 			fields, err := objectFields2pX(r, descriptorsV2, false)
 			if err != nil {
 				return V2{}, err
 			}
-			desc = V2{Object, id, "", true, nil, fields}
+			desc = V2{Object, id, name, true, nil, fields, nil}
 		case Scalar:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
 			ancestors := scalarFields2pX(r, descriptorsV2, false)
-			desc = V2{Scalar, id, name, true, ancestors, nil}
+			desc = V2{Scalar, id, name, true, ancestors, nil, nil}
 		case Tuple:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
 			ancestors, fields := tupleFields2pX(r, descriptorsV2)
-			desc = V2{Tuple, id, name, true, ancestors, fields}
+			desc = V2{Tuple, id, name, true, ancestors, fields, nil}
 		case NamedTuple:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
 			ancestors, fields := namedTupleFields2pX(r, descriptorsV2)
-			desc = V2{Tuple, id, name, true, ancestors, fields}
+			desc = V2{Tuple, id, name, true, ancestors, fields, nil}
 		case Array:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
@@ -100,19 +117,19 @@ func PopV2(
 			if err != nil {
 				return V2{}, err
 			}
-			desc = V2{Array, id, name, true, ancestors, fields}
+			desc = V2{Array, id, name, true, ancestors, fields, nil}
 		case Enum:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
 			ancestors := scalarFields2pX(r, descriptorsV2, false)
-			discardEnumMemberNames(r)
-			desc = V2{Enum, id, name, true, ancestors, nil}
+			members := enumMemberNames(r)
+			desc = V2{Enum, id, name, true, ancestors, nil, members}
 		case InputShape:
 			fields, err := objectFields2pX(r, descriptorsV2, true)
 			if err != nil {
 				return V2{}, err
 			}
-			desc = V2{InputShape, id, "", true, nil, fields}
+			desc = V2{InputShape, id, "", true, nil, fields, nil}
 		case Range:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
@@ -120,11 +137,11 @@ func PopV2(
 			fields := []*FieldV2{{
 				Desc: descriptorsV2[r.PopUint16()],
 			}}
-			desc = V2{Range, id, name, true, ancestors, fields}
+			desc = V2{Range, id, name, true, ancestors, fields, nil}
 		case ObjectShape:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
-			desc = V2{ObjectShape, id, name, true, nil, nil}
+			desc = V2{ObjectShape, id, name, true, nil, nil, nil}
 		case Compound:
 			name := r.PopString()
 			r.PopUint8() // schema_defined
@@ -139,7 +156,7 @@ func PopV2(
 				return V2{}, fmt.Errorf("unexpected operation type: %v", t)
 			}
 			fields := scalarFields2pX(r, descriptorsV2, unionOperation)
-			desc = V2{Compound, id, name, true, nil, fields}
+			desc = V2{Compound, id, name, true, nil, fields, nil}
 		default:
 			if 0x80 <= typ && typ <= 0xff {
 				// ignore unknown type annotations
@@ -229,7 +246,7 @@ func objectFields2pX(r *buff.Reader, descriptors []V2, input bool) (
 
 	for i := 0; i < n; i++ {
 		var required bool
-		r.Discard(4) // flags
+		implicit := r.PopUint32()&flagImplicit != 0
 		card := r.PopUint8()
 		switch card {
 		case 0x6f, 0x6d:
@@ -240,9 +257,11 @@ func objectFields2pX(r *buff.Reader, descriptors []V2, input bool) (
 			return nil, fmt.Errorf("unexpected cardinality: %v", card)
 		}
 		fields[i] = &FieldV2{
-			Name:     r.PopString(),
-			Desc:     descriptors[r.PopUint16()],
-			Required: required,
+			Name:        r.PopString(),
+			Desc:        descriptors[r.PopUint16()],
+			Required:    required,
+			Cardinality: card,
+			Implicit:    implicit,
 		}
 		if !input {
 			r.PopUint16() // source_type
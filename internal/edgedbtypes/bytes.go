@@ -16,7 +16,10 @@
 
 package edgedbtypes
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"io"
+)
 
 // NewOptionalBytes is a convenience function for creating an OptionalBytes
 // with its value set to v.
@@ -75,3 +78,24 @@ func (o *OptionalBytes) UnmarshalJSON(bytes []byte) error {
 
 	return nil
 }
+
+// NewBytesReader returns a BytesReader that sends size bytes read from r.
+func NewBytesReader(r io.Reader, size int) BytesReader {
+	return BytesReader{r: r, size: size}
+}
+
+// BytesReader is a std::bytes query argument that is read from an
+// io.Reader while the query is sent instead of being held in memory. The
+// reader must return at least size bytes, extra bytes are not read. A
+// BytesReader can only be sent once, the reader is consumed by the first
+// query that uses it.
+type BytesReader struct {
+	r    io.Reader
+	size int
+}
+
+// Reader returns the reader that the bytes are read from.
+func (b BytesReader) Reader() io.Reader { return b.r }
+
+// Size returns the number of bytes that are read.
+func (b BytesReader) Size() int { return b.size }
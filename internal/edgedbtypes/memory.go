@@ -19,6 +19,7 @@ package edgedbtypes
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -31,6 +32,25 @@ const (
 	kilobyte = 1_024
 )
 
+// Memory units. Memory values can be combined using go arithmetic, e.g.
+// 512 * edgedb.MiB or mem + 2*edgedb.GiB.
+const (
+	// KiB is one kibibyte.
+	KiB Memory = kilobyte
+
+	// MiB is one mebibyte.
+	MiB Memory = megabyte
+
+	// GiB is one gibibyte.
+	GiB Memory = gigabyte
+
+	// TiB is one tebibyte.
+	TiB Memory = terabyte
+
+	// PiB is one pebibyte.
+	PiB Memory = petabyte
+)
+
 // Memory represents memory in bytes.
 type Memory int64
 
@@ -53,6 +73,22 @@ func (m Memory) String() string {
 	}
 }
 
+// Bytes returns m as a number of bytes.
+func (m Memory) Bytes() int64 { return int64(m) }
+
+// Cmp compares m and other and returns -1 if m is less than other,
+// 0 if they are equal and +1 if m is greater than other.
+func (m Memory) Cmp(other Memory) int {
+	switch {
+	case m < other:
+		return -1
+	case m > other:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // MarshalText returns m marshaled as text.
 func (m Memory) MarshalText() ([]byte, error) {
 	return []byte(m.String()), nil
@@ -60,7 +96,18 @@ func (m Memory) MarshalText() ([]byte, error) {
 
 // UnmarshalText unmarshals bytes into *m.
 func (m *Memory) UnmarshalText(b []byte) error {
-	s := string(b)
+	val, err := ParseMemory(string(b))
+	if err != nil {
+		return err
+	}
+
+	*m = val
+	return nil
+}
+
+// ParseMemory parses a memory string like 512MiB. The units B, KiB, MiB,
+// GiB, TiB and PiB are supported.
+func ParseMemory(s string) (Memory, error) {
 	suffixLen := 3
 	var multiplier int64 = 1
 	switch {
@@ -77,16 +124,19 @@ func (m *Memory) UnmarshalText(b []byte) error {
 	case strings.HasSuffix(s, "B"):
 		suffixLen = 1
 	default:
-		return fmt.Errorf("malformed edgedb.Memory: %q", s)
+		return 0, fmt.Errorf("malformed edgedb.Memory: %q", s)
 	}
 
 	i, err := strconv.ParseInt(s[:len(s)-suffixLen], 10, 64)
 	if err != nil {
-		return fmt.Errorf("malformed edgedb.Memory: %w", err)
+		return 0, fmt.Errorf("malformed edgedb.Memory: %w", err)
 	}
 
-	*m = Memory(i * multiplier)
-	return nil
+	if i > math.MaxInt64/multiplier || i < math.MinInt64/multiplier {
+		return 0, fmt.Errorf("malformed edgedb.Memory: %q overflows", s)
+	}
+
+	return Memory(i * multiplier), nil
 }
 
 // NewOptionalMemory is a convenience function for creating an
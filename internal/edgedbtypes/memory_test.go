@@ -0,0 +1,77 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedbtypes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMemory(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected Memory
+	}{
+		{"0B", 0},
+		{"7B", 7},
+		{"512MiB", 512 * MiB},
+		{"3KiB", 3 * KiB},
+		{"2GiB", 2 * GiB},
+		{"1TiB", TiB},
+		{"5PiB", 5 * PiB},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			mem, err := ParseMemory(c.input)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, mem)
+			assert.Equal(t, c.input, mem.String())
+		})
+	}
+
+	for _, input := range []string{"", "MiB", "1.5GiB", "12", "99999PiB"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseMemory(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMemoryArithmetic(t *testing.T) {
+	mem := 512*MiB + 512*MiB
+	assert.Equal(t, GiB, mem)
+	assert.Equal(t, int64(1_073_741_824), mem.Bytes())
+
+	assert.Equal(t, -1, MiB.Cmp(GiB))
+	assert.Equal(t, 0, GiB.Cmp(mem))
+	assert.Equal(t, 1, GiB.Cmp(MiB))
+}
+
+func TestMemoryJSON(t *testing.T) {
+	bts, err := json.Marshal(struct{ Mem Memory }{3 * GiB})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Mem":"3GiB"}`, string(bts))
+
+	var result struct{ Mem Memory }
+	err = json.Unmarshal(bts, &result)
+	require.NoError(t, err)
+	assert.Equal(t, 3*GiB, result.Mem)
+}
@@ -17,25 +17,32 @@
 package edgedbtypes
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// ErrMalformedUUID is returned when parsing an invalid UUID.
+var ErrMalformedUUID = errors.New("malformed edgedb.UUID")
+
 // ParseUUID parses s into a UUID or returns an error.
+// If s is not a valid UUID the error is ErrMalformedUUID.
 func ParseUUID(s string) (UUID, error) {
 	s = strings.ReplaceAll(s, "-", "")
 	if len(s) != 32 {
-		return UUID{}, errMalformedUUID
+		return UUID{}, ErrMalformedUUID
 	}
 
 	var tmp UUID
 	for i := 0; i < 16; i++ {
 		val, err := strconv.ParseUint(s[:2], 16, 8)
 		if err != nil {
-			return UUID{}, errMalformedUUID
+			return UUID{}, ErrMalformedUUID
 		}
 
 		tmp[i] = uint8(val)
@@ -45,10 +52,69 @@ func ParseUUID(s string) (UUID, error) {
 	return tmp, nil
 }
 
+// UUIDFromBytes returns the UUID in b. b must be exactly 16 bytes long,
+// otherwise ErrMalformedUUID is returned.
+func UUIDFromBytes(b []byte) (UUID, error) {
+	var id UUID
+	if len(b) != len(id) {
+		return UUID{}, ErrMalformedUUID
+	}
+
+	copy(id[:], b)
+	return id, nil
+}
+
+// NewUUIDV4 returns a new random (version 4) UUID.
+func NewUUIDV4() (UUID, error) {
+	var id UUID
+	if _, err := rand.Read(id[:]); err != nil {
+		return UUID{}, err
+	}
+
+	id.setVersion(4)
+	return id, nil
+}
+
+// NewUUIDV7 returns a new time ordered (version 7) UUID.
+// Ids generated in different milliseconds sort in generation order.
+func NewUUIDV7() (UUID, error) {
+	var id UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		return UUID{}, err
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], ms[2:])
+
+	id.setVersion(7)
+	return id, nil
+}
+
 // UUID is a universally unique identifier
 // https://www.edgedb.com/docs/stdlib/uuid
+//
+// UUID has the same underlying type as [16]byte and
+// github.com/google/uuid.UUID so it can be converted to and from those
+// types with a conversion, e.g. uuid.UUID(id) or edgedb.UUID(googleID).
 type UUID [16]byte
 
+// setVersion sets the version and the RFC 4122 variant bits.
+func (id *UUID) setVersion(version byte) {
+	id[6] = id[6]&0x0f | version<<4
+	id[8] = id[8]&0x3f | 0x80
+}
+
+// Version returns the UUID's version number.
+func (id UUID) Version() int {
+	return int(id[6] >> 4)
+}
+
+// Bytes returns the UUID's 16 bytes.
+func (id UUID) Bytes() []byte {
+	return append([]byte(nil), id[:]...)
+}
+
 func (id UUID) String() string {
 	return fmt.Sprintf(
 		"%x-%x-%x-%x-%x",
@@ -65,8 +131,6 @@ func (id UUID) MarshalText() ([]byte, error) {
 	return []byte(id.String()), nil
 }
 
-var errMalformedUUID = errors.New("malformed edgedb.UUID")
-
 // UnmarshalText unmarshals the id from a string.
 func (id *UUID) UnmarshalText(b []byte) error {
 	tmp, err := ParseUUID(string(b))
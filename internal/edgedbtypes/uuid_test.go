@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -147,3 +148,47 @@ func TestUnmarshalOptionalUUID(t *testing.T) {
 		})
 	}
 }
+
+func TestUUIDParseError(t *testing.T) {
+	_, err := ParseUUID("not a uuid")
+	assert.ErrorIs(t, err, ErrMalformedUUID)
+
+	_, err = UUIDFromBytes([]byte{1, 2, 3})
+	assert.ErrorIs(t, err, ErrMalformedUUID)
+}
+
+func TestUUIDFromBytes(t *testing.T) {
+	bts := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	id, err := UUIDFromBytes(bts)
+	require.NoError(t, err)
+
+	assert.Equal(t, UUID(*(*[16]byte)(bts)), id)
+	assert.Equal(t, bts, id.Bytes())
+}
+
+func TestNewUUIDV4(t *testing.T) {
+	id, err := NewUUIDV4()
+	require.NoError(t, err)
+	assert.Equal(t, 4, id.Version())
+	assert.Equal(t, byte(0x80), id[8]&0xc0)
+
+	other, err := NewUUIDV4()
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other)
+}
+
+func TestNewUUIDV7(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id, err := NewUUIDV7()
+	require.NoError(t, err)
+	after := time.Now().UnixMilli()
+
+	assert.Equal(t, 7, id.Version())
+	assert.Equal(t, byte(0x80), id[8]&0xc0)
+
+	var ms [8]byte
+	copy(ms[2:], id[:6])
+	timestamp := int64(binary.BigEndian.Uint64(ms[:]))
+	assert.GreaterOrEqual(t, timestamp, before)
+	assert.LessOrEqual(t, timestamp, after)
+}
@@ -0,0 +1,167 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedbtypes
+
+import "encoding/json"
+
+// SparseVector is a pgvector ext::pgvector::sparsevec value. Only the
+// non-zero elements are stored. Indices are zero based and must be in
+// ascending order, Values[i] is the element at Indices[i].
+type SparseVector struct {
+	// Dim is the number of dimensions of the vector.
+	Dim uint32 `json:"dim"`
+
+	// Indices are the indices of the non-zero elements.
+	Indices []uint32 `json:"indices"`
+
+	// Values are the values of the non-zero elements.
+	Values []float32 `json:"values"`
+}
+
+// NewSparseVector returns the sparse representation of a dense vector.
+func NewSparseVector(dense []float32) SparseVector {
+	v := SparseVector{Dim: uint32(len(dense))}
+	for i, val := range dense {
+		if val != 0 {
+			v.Indices = append(v.Indices, uint32(i))
+			v.Values = append(v.Values, val)
+		}
+	}
+
+	return v
+}
+
+// Dense returns v as a dense vector.
+func (v SparseVector) Dense() []float32 {
+	dense := make([]float32, v.Dim)
+	for i, idx := range v.Indices {
+		if int(idx) < len(dense) && i < len(v.Values) {
+			dense[idx] = v.Values[i]
+		}
+	}
+
+	return dense
+}
+
+// NewOptionalVector is a convenience function for creating an OptionalVector
+// with its value set to v.
+func NewOptionalVector(v []float32) OptionalVector {
+	o := OptionalVector{}
+	o.Set(v)
+	return o
+}
+
+// OptionalVector is an optional ext::pgvector::vector or
+// ext::pgvector::halfvec value. Optional types must be used for out
+// parameters when a shape field is not required.
+type OptionalVector struct {
+	val   []float32
+	isSet bool
+}
+
+// Get returns the value and a boolean indicating if the value is present.
+func (o OptionalVector) Get() ([]float32, bool) { return o.val, o.isSet }
+
+// Set sets the value.
+func (o *OptionalVector) Set(val []float32) {
+	o.val = val
+	o.isSet = true
+}
+
+// Unset marks the value as missing.
+func (o *OptionalVector) Unset() {
+	o.val = nil
+	o.isSet = false
+}
+
+// MarshalJSON returns o marshaled as json.
+func (o OptionalVector) MarshalJSON() ([]byte, error) {
+	if o.isSet {
+		return json.Marshal(o.val)
+	}
+	return json.Marshal(nil)
+}
+
+// UnmarshalJSON unmarshals bytes into *o.
+func (o *OptionalVector) UnmarshalJSON(bytes []byte) error {
+	if bytes[0] == 0x6e { // null
+		o.Unset()
+		return nil
+	}
+
+	if err := json.Unmarshal(bytes, &o.val); err != nil {
+		return err
+	}
+	o.isSet = true
+
+	return nil
+}
+
+// NewOptionalSparseVector is a convenience function for creating an
+// OptionalSparseVector with its value set to v.
+func NewOptionalSparseVector(v SparseVector) OptionalSparseVector {
+	o := OptionalSparseVector{}
+	o.Set(v)
+	return o
+}
+
+// OptionalSparseVector is an optional SparseVector. Optional types must be
+// used for out parameters when a shape field is not required.
+type OptionalSparseVector struct {
+	val   SparseVector
+	isSet bool
+}
+
+// Get returns the value and a boolean indicating if the value is present.
+func (o OptionalSparseVector) Get() (SparseVector, bool) {
+	return o.val, o.isSet
+}
+
+// Set sets the value.
+func (o *OptionalSparseVector) Set(val SparseVector) {
+	o.val = val
+	o.isSet = true
+}
+
+// Unset marks the value as missing.
+func (o *OptionalSparseVector) Unset() {
+	o.val = SparseVector{}
+	o.isSet = false
+}
+
+// MarshalJSON returns o marshaled as json.
+func (o OptionalSparseVector) MarshalJSON() ([]byte, error) {
+	if o.isSet {
+		return json.Marshal(o.val)
+	}
+	return json.Marshal(nil)
+}
+
+// UnmarshalJSON unmarshals bytes into *o.
+func (o *OptionalSparseVector) UnmarshalJSON(bytes []byte) error {
+	if bytes[0] == 0x6e { // null
+		o.Unset()
+		return nil
+	}
+
+	if err := json.Unmarshal(bytes, &o.val); err != nil {
+		return err
+	}
+	o.isSet = true
+
+	return nil
+}
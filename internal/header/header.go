@@ -22,6 +22,15 @@ import "encoding/binary"
 type Header map[uint16][]byte
 
 const (
+	// ImplicitLimit tells the server to limit the number of results.
+	ImplicitLimit uint16 = 0xFF01
+
+	// ImplicitTypeNames tells the server to inject object type names.
+	ImplicitTypeNames uint16 = 0xFF02
+
+	// ImplicitTypeIDs tells the server to inject object type ids.
+	ImplicitTypeIDs uint16 = 0xFF03
+
 	// AllowCapabilities tells the server what capabilities it should allow.
 	AllowCapabilities uint16 = 0xFF04
 	allCapabilities   uint64 = 0xffffffffffffffff
@@ -19,16 +19,36 @@ package introspect
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
-func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+// FieldMatch determines how struct fields are matched to names.
+type FieldMatch int
+
+const (
+	// MatchExact matches a field by its tag or by its exact name.
+	MatchExact FieldMatch = iota
+
+	// MatchCaseInsensitive matches a field by its tag or by its name
+	// ignoring case.
+	MatchCaseInsensitive
+
+	// MatchTagOnly matches a field only by its tag.
+	MatchTagOnly
+)
+
+func fieldByTag(
+	t reflect.Type,
+	name string,
+	key string,
+) (reflect.StructField, bool) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		switch field.Tag.Get("edgedb") {
+		switch field.Tag.Get(key) {
 		case name:
 			return field, true
 		case "$inline":
-			if f, ok := fieldByTag(field.Type, name); ok {
+			if f, ok := fieldByTag(field.Type, name, key); ok {
 				// Accumulate offsets from nested paths.
 				f.Offset += field.Offset
 				return f, true
@@ -39,17 +59,37 @@ func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
 	return reflect.StructField{}, false
 }
 
-// StructField finds a field where name matches either the tag or name.
+// StructField finds a field where name matches either the edgedb tag or name.
 func StructField(t reflect.Type, name string) (reflect.StructField, bool) {
-	if f, ok := fieldByTag(t, name); ok {
-		return f, true
-	}
+	return TaggedStructField(t, name, "edgedb", MatchExact)
+}
 
-	if f, ok := t.FieldByName(name); ok {
+// TaggedStructField finds a field where name matches the tag with the given
+// key. If no tag matches the field name is matched according to match.
+func TaggedStructField(
+	t reflect.Type,
+	name string,
+	key string,
+	match FieldMatch,
+) (reflect.StructField, bool) {
+	if f, ok := fieldByTag(t, name, key); ok {
 		return f, true
 	}
 
-	return reflect.StructField{}, false
+	switch match {
+	case MatchExact:
+		return t.FieldByName(name)
+	case MatchCaseInsensitive:
+		if f, ok := t.FieldByName(name); ok {
+			return f, true
+		}
+
+		return t.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+	default:
+		return reflect.StructField{}, false
+	}
 }
 
 // ValueOf returns the reflect.Value of an out parameter or an error
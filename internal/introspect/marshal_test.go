@@ -190,3 +190,48 @@ func TestValueOfSlice(t *testing.T) {
 	val.SetBytes([]byte{1, 2, 3})
 	assert.Equal(t, []byte{1, 2, 3}, thing)
 }
+
+func TestTaggedStructField(t *testing.T) {
+	type Tagged struct {
+		First  string `edgedb:"second" db:"first"`
+		Second string `db:"second"`
+	}
+
+	typ := reflect.TypeOf(Tagged{})
+	field, ok := TaggedStructField(typ, "second", "db", MatchExact)
+	require.True(t, ok)
+	assert.Equal(t, "Second", field.Name)
+
+	field, ok = TaggedStructField(typ, "second", "edgedb", MatchExact)
+	require.True(t, ok)
+	assert.Equal(t, "First", field.Name)
+
+	// Fields can still be matched by name.
+	field, ok = TaggedStructField(typ, "First", "db", MatchExact)
+	require.True(t, ok)
+	assert.Equal(t, "First", field.Name)
+}
+
+func TestTaggedStructFieldMatch(t *testing.T) {
+	typ := reflect.TypeOf(SomeStruct{})
+
+	_, ok := TaggedStructField(typ, "second", "edgedb", MatchExact)
+	require.False(t, ok)
+
+	fold := MatchCaseInsensitive
+	field, ok := TaggedStructField(typ, "second", "edgedb", fold)
+	require.True(t, ok)
+	assert.Equal(t, "Second", field.Name)
+
+	// Tags are always matched exactly.
+	field, ok = TaggedStructField(typ, "first", "edgedb", fold)
+	require.True(t, ok)
+	assert.Equal(t, "First", field.Name)
+
+	field, ok = TaggedStructField(typ, "First", "edgedb", MatchTagOnly)
+	require.True(t, ok)
+	assert.Equal(t, "Third", field.Name)
+
+	_, ok = TaggedStructField(typ, "Second", "edgedb", MatchTagOnly)
+	require.False(t, ok)
+}
@@ -68,6 +68,27 @@ type StrUnmarshaler interface {
 	UnmarshalEdgeDBStr(data []byte) error
 }
 
+// EnumMarshaler is the interface implemented by an object
+// that can marshal itself into an enum value.
+// https://www.edgedb.com/docs/internals/protocol/dataformats#std-str
+//
+// MarshalEdgeDBEnum returns the name of the enum member
+// that the receiver represents.
+type EnumMarshaler interface {
+	MarshalEdgeDBEnum() ([]byte, error)
+}
+
+// EnumUnmarshaler is the interface implemented by an object
+// that can unmarshal an enum value into itself.
+// https://www.edgedb.com/docs/internals/protocol/dataformats#std-str
+//
+// UnmarshalEdgeDBEnum is only called with values that are members of the
+// enum being decoded. It must copy the data if it wishes to retain the data
+// after returning.
+type EnumUnmarshaler interface {
+	UnmarshalEdgeDBEnum(data []byte) error
+}
+
 // BoolMarshaler is the interface implemented by an object
 // that can marshal itself into the bool wire format.
 // https://www.edgedb.com/docs/internals/protocol/dataformats#std-bool
@@ -18,6 +18,7 @@ package state
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sebastiean/edgedb-go/internal/buff"
@@ -114,24 +115,38 @@ func (c *sparceObjectEncoder) Encode(
 	}
 
 	if seen != elmCount {
-		missing := make(map[string]struct{}, elmCount)
+		names := make([]string, 0, len(in))
 		for name := range in {
-			missing[name] = struct{}{}
+			names = append(names, name)
 		}
-
-		for _, field := range c.fields {
-			if _, ok := in[field.name]; ok {
-				delete(missing, field.name)
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !c.hasField(name) {
+				return fmt.Errorf(
+					"found unknown state value %v",
+					path.AddField(name),
+				)
 			}
 		}
 
-		for name := range missing {
-			return fmt.Errorf(
-				"found unknown state value %v",
-				path.AddField(name),
-			)
-		}
+		// A name was set both with and without the default module.
+		return fmt.Errorf(
+			"found state values that are set more than once in %v", path)
 	}
+
 	w.EndBytes()
 	return nil
 }
+
+// hasField returns true if name is a field or if name is a field in the
+// default module without the module prefix.
+func (c *sparceObjectEncoder) hasField(name string) bool {
+	for _, field := range c.fields {
+		if field.name == name || field.name == "default::"+name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/sebastiean/edgedb-go/internal/buff"
+	"github.com/sebastiean/edgedb-go/internal/codecs"
+	"github.com/sebastiean/edgedb-go/internal/descriptor"
+	types "github.com/sebastiean/edgedb-go/internal/edgedbtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparceObjectEncoderUnknownNames(t *testing.T) {
+	str := descriptor.V2{Type: descriptor.Scalar, ID: codecs.StrID}
+	desc := descriptor.V2{
+		Type: descriptor.InputShape,
+		ID:   types.UUID{1},
+		Fields: []*descriptor.FieldV2{
+			{Name: "default::name", Desc: str},
+			{Name: "cfg::mode", Desc: str},
+		},
+	}
+
+	encoder, err := BuildEncoderV2(&desc, codecs.Path("state"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		in   map[string]interface{}
+		err  string
+	}{
+		{
+			name: "known names",
+			in:   map[string]interface{}{"name": "a", "cfg::mode": "b"},
+		},
+		{
+			name: "unprefixed unknown name",
+			in:   map[string]interface{}{"name": "a", "unknown": "b"},
+			err:  "found unknown state value state.unknown",
+		},
+		{
+			name: "unknown names are reported in order",
+			in: map[string]interface{}{
+				"default::name": "a",
+				"zzz":           "b",
+				"aaa":           "c",
+			},
+			err: "found unknown state value state.aaa",
+		},
+		{
+			name: "duplicate names",
+			in:   map[string]interface{}{"name": "a", "default::name": "b"},
+			err:  "found state values that are set more than once in state",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := buff.NewWriter(nil)
+			w.BeginMessage(0xa)
+			err := encoder.Encode(w, test.in, codecs.Path("state"), false)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import "context"
+
+// QueryChan runs a query with client and sends each result element on the
+// returned element channel as soon as it is decoded, so that elements can be
+// processed by other goroutines while the rest of the result is still being
+// received. See Client.QueryIter.
+//
+// The element channel is closed when the query has finished. The error
+// channel then receives the error that ended the query, or nil, and is
+// closed. A caller that stops receiving elements early must cancel ctx so
+// that the query is stopped and its connection is released.
+func QueryChan[T any](
+	ctx context.Context,
+	client *Client,
+	cmd string,
+	args ...interface{},
+) (<-chan T, <-chan error) {
+	elements := make(chan T)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+
+		iter := client.QueryIter(ctx, cmd, args...)
+		errs <- sendElements(ctx, iter, elements)
+	}()
+
+	return elements, errs
+}
+
+// sendElements sends the elements of iter on elements until there are no
+// more elements or ctx is canceled, then closes elements and iter.
+func sendElements[T any](
+	ctx context.Context,
+	iter *QueryIterator,
+	elements chan<- T,
+) error {
+	defer close(elements)
+
+	for {
+		// Each element is decoded into a new value because the previous
+		// element may still be in use by the receiver.
+		var element T
+		if !iter.Next(&element) {
+			return iter.Close()
+		}
+
+		select {
+		case elements <- element:
+		case <-ctx.Done():
+			_ = iter.Close()
+			return ctx.Err()
+		}
+	}
+}
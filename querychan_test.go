@@ -0,0 +1,59 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb_test
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	edgedb "github.com/sebastiean/edgedb-go"
+)
+
+// Result elements can be fanned out to worker goroutines while the rest of
+// the result is still being received.
+func ExampleQueryChan() {
+	ctx := context.Background()
+	client, err := edgedb.CreateClient(ctx, edgedb.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close() // nolint:errcheck
+
+	type User struct {
+		Name string `edgedb:"name"`
+	}
+
+	users, errs := edgedb.QueryChan[User](
+		ctx, client, "SELECT User { name }")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range users {
+				log.Println(user.Name)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if err := <-errs; err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,366 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stdlib is a [database/sql] driver for EdgeDB. It is layered on
+// edgedb.Client so that code that only speaks database/sql, like migration
+// tools and ORMs, can run EdgeQL queries.
+//
+//	import (
+//	    "database/sql"
+//
+//	    _ "github.com/sebastiean/edgedb-go/stdlib"
+//	)
+//
+//	db, err := sql.Open("edgedb", "edgedb://edgedb@localhost/edgedb")
+//
+// An empty data source name connects using environment variables or the
+// current project like edgedb.CreateClient. OpenDB wraps an existing client.
+//
+// Query arguments are passed to the client unchanged, so any type accepted by
+// the edgedb package can be used. Named arguments created with sql.Named are
+// passed as a map[string]interface{} for queries with named parameters.
+//
+//	rows, err := db.QueryContext(
+//	    ctx,
+//	    "SELECT User { name } FILTER .age > <int64>$age",
+//	    sql.Named("age", int64(21)),
+//	)
+//
+// Each object or named tuple in the result is a row with a column for each of
+// its explicitly selected fields. Tuples have a column for each element named
+// by its index and all other results have a single column named "value".
+// Values are converted to int64, float64, bool, []byte, string or time.Time.
+// Other scalars are converted to their string representation and nested
+// objects, tuples, arrays and sets are converted to json.
+//
+// Transactions are run with edgedb.Client.Tx and are not retried.
+// Only the default and serializable isolation levels are supported.
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+func init() {
+	sql.Register("edgedb", &Driver{})
+}
+
+// Driver is the database/sql driver for EdgeDB.
+type Driver struct{}
+
+// Open returns a new connection with its own client.
+// sql.Open shares a single client between all of its connections instead.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	client, err := createClient(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{client: client, owned: true}, nil
+}
+
+// OpenConnector returns a connector that shares a single client
+// between all of its connections.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	client, err := createClient(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connector{client: client, owned: true}, nil
+}
+
+// OpenDB returns a *sql.DB that runs queries using client.
+// Closing the returned DB does not close client.
+func OpenDB(client *edgedb.Client) *sql.DB {
+	return sql.OpenDB(&connector{client: client})
+}
+
+func createClient(dsn string) (*edgedb.Client, error) {
+	ctx := context.Background()
+	if dsn == "" {
+		return edgedb.CreateClient(ctx, edgedb.Options{})
+	}
+
+	return edgedb.CreateClientDSN(ctx, dsn, edgedb.Options{})
+}
+
+type connector struct {
+	client *edgedb.Client
+
+	// owned is true if the client was created by the driver
+	// and should be closed with the connector.
+	owned bool
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.client.EnsureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	return &conn{client: c.client}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return &Driver{} }
+
+func (c *connector) Close() error {
+	if c.owned {
+		return c.client.Close()
+	}
+
+	return nil
+}
+
+// querier is implemented by *edgedb.Client and *edgedb.Tx.
+type querier interface {
+	Execute(context.Context, string, ...interface{}) error
+	QueryMeta(
+		context.Context,
+		string,
+		interface{},
+		...interface{},
+	) (*edgedb.ResultMeta, error)
+}
+
+type conn struct {
+	client *edgedb.Client
+	tx     *tx
+
+	// owned is true if the client was created for this connection
+	// and should be closed with it.
+	owned bool
+}
+
+var (
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(
+	_ context.Context,
+	query string,
+) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	var err error
+	if c.tx != nil {
+		err = c.tx.Rollback()
+	}
+
+	if c.owned {
+		if e := c.client.Close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(
+	ctx context.Context,
+	opts driver.TxOptions,
+) (driver.Tx, error) {
+	if c.tx != nil {
+		return nil, errors.New("edgedb: already in a transaction")
+	}
+
+	switch level := sql.IsolationLevel(opts.Isolation); level {
+	case sql.LevelDefault, sql.LevelSerializable:
+	default:
+		return nil, fmt.Errorf(
+			"edgedb: unsupported isolation level: %v", level)
+	}
+
+	client := c.client.
+		WithTxOptions(edgedb.NewTxOptions().WithReadOnly(opts.ReadOnly)).
+		WithRetryOptions(edgedb.NewRetryOptions().WithDefault(
+			edgedb.NewRetryRule().WithAttempts(1)))
+
+	t, err := beginTx(ctx, c, client)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = t
+	return t, nil
+}
+
+// CheckNamedValue passes all arguments to the client unchanged.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	queryArgs, err := convertArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.run(ctx, func(q querier) error {
+		return q.Execute(ctx, query, queryArgs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result{}, nil
+}
+
+func (c *conn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryArgs, err := convertArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out  []interface{}
+		meta *edgedb.ResultMeta
+	)
+
+	err = c.run(ctx, func(q querier) error {
+		var e error
+		meta, e = q.QueryMeta(ctx, query, &out, queryArgs...)
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newRows(meta, out), nil
+}
+
+// run runs fn in the connection's transaction if there is one.
+func (c *conn) run(ctx context.Context, fn func(querier) error) error {
+	if c.tx != nil {
+		return c.tx.run(ctx, func(t *edgedb.Tx) error { return fn(t) })
+	}
+
+	return fn(c.client)
+}
+
+// convertArgs converts database/sql arguments into query arguments.
+// Named arguments are passed as a single map[string]interface{}.
+func convertArgs(args []driver.NamedValue) ([]interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	if args[0].Name == "" {
+		out := make([]interface{}, len(args))
+		for i, arg := range args {
+			if arg.Name != "" {
+				return nil, errors.New(
+					"edgedb: cannot mix named and positional arguments")
+			}
+			out[i] = arg.Value
+		}
+		return out, nil
+	}
+
+	named := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		if arg.Name == "" {
+			return nil, errors.New(
+				"edgedb: cannot mix named and positional arguments")
+		}
+		named[arg.Name] = arg.Value
+	}
+
+	return []interface{}{named}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ driver.StmtExecContext  = (*stmt)(nil)
+	_ driver.StmtQueryContext = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error { return nil }
+
+// NumInput returns -1 because the number of arguments is only known
+// after the query has been parsed by the server.
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(
+	ctx context.Context,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(
+	ctx context.Context,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// result is returned by Exec. EdgeDB doesn't report
+// the number of affected rows or inserted ids.
+type result struct{}
+
+func (result) LastInsertId() (int64, error) {
+	return 0, errors.New("edgedb: LastInsertId is not supported")
+}
+
+func (result) RowsAffected() (int64, error) {
+	return 0, errors.New("edgedb: RowsAffected is not supported")
+}
@@ -0,0 +1,133 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/sebastiean/edgedb-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertArgs(t *testing.T) {
+	args, err := convertArgs(nil)
+	require.NoError(t, err)
+	assert.Nil(t, args)
+
+	args, err = convertArgs([]driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), "a"}, args)
+
+	args, err = convertArgs([]driver.NamedValue{
+		{Name: "x", Ordinal: 1, Value: int64(1)},
+		{Name: "y", Ordinal: 2, Value: "a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"x": int64(1), "y": "a"},
+	}, args)
+
+	_, err = convertArgs([]driver.NamedValue{
+		{Name: "x", Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "a"},
+	})
+	assert.EqualError(t, err,
+		"edgedb: cannot mix named and positional arguments")
+}
+
+func TestRowsObject(t *testing.T) {
+	meta := edgedb.ResultMeta{Type: edgedb.ResultType{
+		Kind: "object",
+		Fields: []edgedb.ResultField{
+			{Name: "id", Implicit: true},
+			{Name: "name"},
+			{Name: "age"},
+			{Name: "tags"},
+		},
+	}}
+	id := edgedb.UUID{1}
+	values := []interface{}{
+		map[string]interface{}{
+			"name": "bob",
+			"age":  int32(21),
+			"tags": []interface{}{"a", "b"},
+		},
+		map[string]interface{}{
+			"name": nil,
+			"age":  int32(1),
+			"tags": []interface{}{id},
+		},
+	}
+
+	r := newRows(&meta, values)
+	assert.Equal(t, []string{"name", "age", "tags"}, r.Columns())
+
+	dest := make([]driver.Value, 3)
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t,
+		[]driver.Value{"bob", int64(21), []byte(`["a","b"]`)}, dest)
+
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{
+		nil,
+		int64(1),
+		[]byte(`["` + id.String() + `"]`),
+	}, dest)
+
+	assert.Equal(t, io.EOF, r.Next(dest))
+}
+
+func TestRowsTupleAndScalar(t *testing.T) {
+	meta := edgedb.ResultMeta{Type: edgedb.ResultType{
+		Kind:   "tuple",
+		Fields: []edgedb.ResultField{{Name: "0"}, {Name: "1"}},
+	}}
+
+	r := newRows(&meta, []interface{}{[]interface{}{float32(1.5), testUUID()}})
+	assert.Equal(t, []string{"0", "1"}, r.Columns())
+
+	dest := make([]driver.Value, 2)
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{float64(1.5), testUUID().String()}, dest)
+
+	meta = edgedb.ResultMeta{Type: edgedb.ResultType{Kind: "array"}}
+	r = newRows(&meta, []interface{}{[]interface{}{int64(1), int64(2)}})
+	assert.Equal(t, []string{"value"}, r.Columns())
+
+	dest = make([]driver.Value, 1)
+	require.NoError(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{[]byte("[1,2]")}, dest)
+}
+
+func TestUnsupportedIsolationLevel(t *testing.T) {
+	c := conn{}
+	_, err := c.BeginTx(context.Background(), driver.TxOptions{
+		Isolation: driver.IsolationLevel(sql.LevelReadCommitted),
+	})
+	assert.EqualError(t, err,
+		"edgedb: unsupported isolation level: Read Committed")
+}
+
+func testUUID() edgedb.UUID { return edgedb.UUID{1, 2, 3} }
@@ -0,0 +1,108 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdlib
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+// valueColumn is the column name for results
+// that are not objects or tuples.
+const valueColumn = "value"
+
+type rows struct {
+	columns []string
+	kind    string
+	values  []interface{}
+	next    int
+}
+
+func newRows(meta *edgedb.ResultMeta, values []interface{}) *rows {
+	r := rows{kind: meta.Type.Kind, values: values}
+	switch r.kind {
+	case "object", "namedtuple", "tuple":
+		for _, field := range meta.Type.Fields {
+			if !field.Implicit {
+				r.columns = append(r.columns, field.Name)
+			}
+		}
+	default:
+		r.columns = []string{valueColumn}
+	}
+
+	return &r
+}
+
+func (r *rows) Columns() []string { return r.columns }
+
+func (r *rows) Close() error {
+	r.values = nil
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+
+	row := r.values[r.next]
+	r.next++
+
+	for i, name := range r.columns {
+		var val interface{}
+		switch r.kind {
+		case "object", "namedtuple":
+			val = row.(map[string]interface{})[name]
+		case "tuple":
+			val = row.([]interface{})[i]
+		default:
+			val = row
+		}
+
+		var err error
+		dest[i], err = driverValue(val)
+		if err != nil {
+			return fmt.Errorf("edgedb: column %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// driverValue converts a dynamically decoded value into a driver.Value.
+func driverValue(val interface{}) (driver.Value, error) {
+	switch v := val.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v, nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,125 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sebastiean/edgedb-go"
+)
+
+var (
+	errRollback = errors.New("edgedb: transaction rolled back")
+	errTxDone   = errors.New("edgedb: transaction has already finished")
+)
+
+// tx bridges database/sql's Begin/Commit/Rollback transactions and
+// edgedb.Client.Tx. The transaction block runs in its own goroutine
+// and runs the operations it receives until it is told to finish.
+type tx struct {
+	conn *conn
+	ops  chan func(*edgedb.Tx)
+	end  chan bool
+
+	// done is closed when the transaction block returns.
+	done chan struct{}
+	err  error
+}
+
+func beginTx(
+	ctx context.Context,
+	c *conn,
+	client *edgedb.Client,
+) (*tx, error) {
+	t := &tx{
+		conn: c,
+		ops:  make(chan func(*edgedb.Tx)),
+		end:  make(chan bool),
+		done: make(chan struct{}),
+	}
+
+	started := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		t.err = client.Tx(ctx, func(_ context.Context, etx *edgedb.Tx) error {
+			once.Do(func() { close(started) })
+			for {
+				select {
+				case op := <-t.ops:
+					op(etx)
+				case commit := <-t.end:
+					if commit {
+						return nil
+					}
+					return errRollback
+				}
+			}
+		})
+		close(t.done)
+	}()
+
+	select {
+	case <-started:
+		return t, nil
+	case <-t.done:
+		return nil, t.err
+	}
+}
+
+// run runs fn in the transaction block.
+func (t *tx) run(ctx context.Context, fn func(*edgedb.Tx) error) error {
+	result := make(chan error, 1)
+	op := func(etx *edgedb.Tx) { result <- fn(etx) }
+
+	select {
+	case t.ops <- op:
+		return <-result
+	case <-t.done:
+		if t.err != nil {
+			return t.err
+		}
+		return errTxDone
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *tx) finish(commit bool) error {
+	select {
+	case t.end <- commit:
+	case <-t.done:
+	}
+
+	<-t.done
+	t.conn.tx = nil
+	return t.err
+}
+
+func (t *tx) Commit() error {
+	return t.finish(true)
+}
+
+func (t *tx) Rollback() error {
+	err := t.finish(false)
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return err
+}
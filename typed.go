@@ -0,0 +1,53 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb
+
+import "context"
+
+// QueryAs runs a query with client and returns its result elements as a
+// []T, instead of decoding them into an out argument. It is named QueryAs
+// because Query already runs queries using the default client.
+//
+//	users, err := edgedb.QueryAs[User](ctx, client, "SELECT User { name }")
+func QueryAs[T any](
+	ctx context.Context,
+	client *Client,
+	cmd string,
+	args ...interface{},
+) ([]T, error) {
+	var out []T
+	err := client.Query(ctx, cmd, &out, args...)
+	return out, err
+}
+
+// QuerySingleAs runs a singleton-returning query with client and returns
+// its result as a T. If the query executes successfully but doesn't return a
+// result a NoDataError is returned, unless T is an optional type in which
+// case the result is missing.
+//
+//	user, err := edgedb.QuerySingleAs[User](
+//	    ctx, client, "SELECT User { name } LIMIT 1")
+func QuerySingleAs[T any](
+	ctx context.Context,
+	client *Client,
+	cmd string,
+	args ...interface{},
+) (T, error) {
+	var out T
+	err := client.QuerySingle(ctx, cmd, &out, args...)
+	return out, err
+}
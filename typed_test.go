@@ -0,0 +1,51 @@
+// This source file is part of the EdgeDB open source project.
+//
+// Copyright EdgeDB Inc. and the EdgeDB authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edgedb_test
+
+import (
+	"context"
+	"log"
+
+	edgedb "github.com/sebastiean/edgedb-go"
+)
+
+// The result type is a type parameter instead of an out argument.
+func ExampleQueryAs() {
+	ctx := context.Background()
+	client, err := edgedb.CreateClient(ctx, edgedb.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close() // nolint:errcheck
+
+	type User struct {
+		Name string `edgedb:"name"`
+	}
+
+	users, err := edgedb.QueryAs[User](ctx, client, "SELECT User { name }")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	count, err := edgedb.QuerySingleAs[int64](
+		ctx, client, "SELECT count(User)")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println(len(users), count)
+}